@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/clevertechru/tgbot_aml/internal/config"
+	"github.com/clevertechru/tgbot_aml/internal/domain"
+	"github.com/clevertechru/tgbot_aml/internal/export"
+	"go.uber.org/zap"
+)
+
+// checkTimeout bounds a screening from the terminal
+const checkTimeout = 2 * time.Minute
+
+// checkCommand screens an address or transaction with the bot's services
+// and prints the result, for debugging without Telegram
+func checkCommand(args []string) error {
+	flags := flag.NewFlagSet("check", flag.ContinueOnError)
+	configPath := flags.String("config", defaultConfigPath, "path to the config file")
+	format := flags.String("format", "table", "output format: table or json")
+	verbose := flags.Bool("v", false, "log to stderr")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: bot check [flags] <address|hash>\n")
+		flags.PrintDefaults()
+	}
+	// Flags may follow the target, as in "bot check 0xabc -format json"
+	var positional []string
+	for {
+		if err := parseFlags(flags, args, len(args)); err != nil {
+			return err
+		}
+		if flags.NArg() == 0 {
+			break
+		}
+		positional = append(positional, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+	if len(positional) != 1 {
+		flags.Usage()
+		return errUsage
+	}
+	target := positional[0]
+	if *format != "table" && *format != "json" {
+		return fmt.Errorf("unknown format %q, expected table or json", *format)
+	}
+
+	parsed, ok := domain.ParseTarget(target)
+	if !ok {
+		return fmt.Errorf("%q is not an address or transaction hash", target)
+	}
+
+	cfg, err := config.Read(*configPath, nil)
+	if err != nil {
+		return err
+	}
+	if err := cfg.ValidateOffline(); err != nil {
+		return err
+	}
+
+	logger := zap.NewNop()
+	if *verbose {
+		if logger, err = zap.NewDevelopment(); err != nil {
+			return fmt.Errorf("failed to create logger: %w", err)
+		}
+	}
+	screening, err := newScreening(cfg, logger)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	result, err := screening.service.Screen(ctx, parsed)
+	if err != nil {
+		return fmt.Errorf("failed to screen %s: %w", target, err)
+	}
+
+	if *format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(export.FromResult(result, time.Now()))
+	}
+	return writeResultTable(os.Stdout, result)
+}
+
+// writeResultTable prints a screening result as aligned name and value
+// columns, one line per detail, transfer and provider
+func writeResultTable(w io.Writer, result *domain.ScreeningResult) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	row := func(name, value string) {
+		fmt.Fprintf(table, "%s\t%s\n", name, value)
+	}
+
+	row("Target", result.Target.Value)
+	row("Kind", result.Target.Kind.String())
+	if result.Target.Chain != domain.ChainUnknown {
+		row("Chain", string(result.Target.Chain))
+	}
+	row("Suspicious", strconv.FormatBool(result.IsSuspicious))
+	row("Risk score", strconv.FormatFloat(result.RiskScore, 'f', 2, 64))
+	if result.Verdict != "" {
+		row("Verdict", string(result.Verdict))
+	}
+	if len(result.FiredRules) > 0 {
+		row("Rules", strings.Join(result.FiredRules, ", "))
+	}
+	if len(result.Categories) > 0 {
+		row("Categories", strings.Join(result.Categories, ", "))
+	}
+	if !result.FirstSeen.IsZero() {
+		row("First seen", result.FirstSeen.UTC().Format(time.DateOnly))
+	}
+	for _, factor := range result.Factors {
+		if factor.Unknown {
+			continue
+		}
+		row("Factor", fmt.Sprintf("%s +%.2f", factor.Kind, factor.Contribution))
+	}
+	for _, transfer := range result.Transfers {
+		token := transfer.Transfer.Symbol
+		if token == "" {
+			token = transfer.Transfer.Token
+		}
+		row("Transfer", fmt.Sprintf("%g %s %s -> %s, risk %.2f",
+			transfer.Transfer.Value, token, transfer.Transfer.From, transfer.Transfer.To, transfer.RiskScore))
+	}
+	for _, detail := range result.Details {
+		row("Detail", detail)
+	}
+	for _, response := range result.Responses {
+		row("Provider", fmt.Sprintf("%s: risk %.2f", response.Provider, response.RiskScore))
+	}
+	return table.Flush()
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/clevertechru/tgbot_aml/internal/config"
+	"go.uber.org/zap"
+)
+
+// validateConfigCommand loads the config like run does and reports whether
+// the bot would start with it
+func validateConfigCommand(args []string) error {
+	flags := flag.NewFlagSet("validate-config", flag.ContinueOnError)
+	configPath := flags.String("config", defaultConfigPath, "path to the config file")
+	if err := parseFlags(flags, args, 0); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load(*configPath, nil)
+	if err != nil {
+		return err
+	}
+	// Building the services also checks the files the config points to,
+	// such as the risk policy
+	if _, err := accessConfigFrom(cfg); err != nil {
+		return err
+	}
+	if _, err := newScreening(cfg, zap.NewNop()); err != nil {
+		return err
+	}
+	fmt.Printf("%s is valid\n", *configPath)
+	return nil
+}
+
+// printDefaultConfigCommand prints the built-in defaults, a starting point
+// for a new config file
+func printDefaultConfigCommand(args []string) error {
+	flags := flag.NewFlagSet("print-default-config", flag.ContinueOnError)
+	if err := parseFlags(flags, args, 0); err != nil {
+		return err
+	}
+	return config.DefaultConfig().WriteYAML(os.Stdout)
+}
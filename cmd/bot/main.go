@@ -1,121 +1,208 @@
 package main
 
 import (
-	"context"
-	"log"
+	"errors"
+	"flag"
+	"fmt"
 	"os"
-	"os/signal"
-	"syscall"
+	"strings"
 
 	"github.com/clevertechru/tgbot_aml/internal/config"
 	"github.com/clevertechru/tgbot_aml/internal/domain"
-	"github.com/clevertechru/tgbot_aml/internal/handlers"
+	"github.com/clevertechru/tgbot_aml/internal/graph"
+	"github.com/clevertechru/tgbot_aml/internal/lang"
 	"github.com/clevertechru/tgbot_aml/internal/services"
-	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"go.uber.org/zap"
 )
 
-func main() {
-	// Load config
-	cfg, err := config.Load("config/config.yml")
-	if err != nil {
-		log.Printf("Failed to load config: %v, using defaults", err)
-		cfg = config.DefaultConfig()
-	}
+const defaultConfigPath = "config/config.yml"
+
+const usage = `Usage: bot <command> [flags]
+
+Commands:
+  run                   start the bot (the default)
+  check <address|hash>  screen an address or transaction and print the result
+  validate-config       check the config file and report every problem
+  print-default-config  print the built-in defaults as a config file
+
+Run "bot <command> -h" for the flags of a command.
+`
+
+// errUsage is returned for command lines the flag package already
+// complained about
+var errUsage = errors.New("usage error")
 
-	// Validate required environment variables
-	if cfg.Telegram.Token == "" {
-		log.Fatal("TELEGRAM_BOT_TOKEN is required but not set")
+// commands are the subcommands of the binary
+var commands = map[string]func(args []string) error{
+	"run":                  runCommand,
+	"check":                checkCommand,
+	"validate-config":      validateConfigCommand,
+	"print-default-config": printDefaultConfigCommand,
+}
+
+func main() {
+	// Without a command the bot runs, as it did before there were commands
+	name, args := "run", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
 	}
-	if cfg.AML.APIKey == "" {
-		log.Fatal("AML_API_KEY is required but not set")
+	if name == "help" {
+		fmt.Print(usage)
+		return
 	}
 
-	// Initialize logger
-	var logger *zap.Logger
-	if cfg.Logging.File != "" {
-		file, err := os.OpenFile(cfg.Logging.File, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-		if err != nil {
-			log.Fatalf("Failed to open log file: %v", err)
+	command, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", name, usage)
+		os.Exit(2)
+	}
+	if err := command(args); err != nil {
+		switch {
+		case errors.Is(err, flag.ErrHelp):
+			return
+		case errors.Is(err, errUsage):
+			os.Exit(2)
 		}
-		defer func() {
-			if err := file.Close(); err != nil {
-				log.Printf("Failed to close log file: %v", err)
-			}
-		}()
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
 
-		config := zap.NewProductionConfig()
-		config.OutputPaths = []string{cfg.Logging.File}
-		logger, err = config.Build()
-		if err != nil {
-			log.Fatalf("Failed to create logger: %v", err)
-		}
-	} else {
-		var err error
-		logger, err = zap.NewProduction()
-		if err != nil {
-			log.Fatalf("Failed to create logger: %v", err)
+// parseFlags parses the flags of a command that takes at most maxArgs
+// positional arguments
+func parseFlags(flags *flag.FlagSet, args []string, maxArgs int) error {
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
 		}
+		return errUsage
 	}
-	defer func() {
-		if err := logger.Sync(); err != nil {
-			log.Printf("Failed to sync logger: %v", err)
-		}
-	}()
-
-	// Initialize bot
-	bot, err := tgbotapi.NewBotAPI(cfg.Telegram.Token)
-	if err != nil {
-		logger.Fatal("Failed to create bot", zap.Error(err))
+	if flags.NArg() > maxArgs {
+		fmt.Fprintf(flags.Output(), "unexpected arguments: %s\n", strings.Join(flags.Args()[maxArgs:], " "))
+		flags.Usage()
+		return errUsage
 	}
+	return nil
+}
 
-	// Initialize AML provider
-	amlProvider := domain.NewChainabuseProvider()
-	amlProvider.SetAPIKey(cfg.AML.APIKey)
-	amlProvider.SetBaseURL(cfg.AML.BaseURL)
-
-	// Initialize services
-	amlService := services.NewAMLService(amlProvider)
+// reportTranslationIssues logs keys that are missing or inconsistent between
+// languages
+func reportTranslationIssues(logger *zap.Logger) {
+	for _, issue := range lang.Validate() {
+		logger.Warn("Translation issue",
+			zap.String("language", string(issue.Language)),
+			zap.String("key", issue.Key),
+			zap.String("problem", issue.Problem),
+		)
+	}
+}
 
-	// Initialize handlers
-	handler := handlers.NewHandler(bot, amlService, logger)
+func scanSettingsFrom(cfg *config.Config) services.ScanSettings {
+	return services.ScanSettings{
+		Enabled:        cfg.Autoscan.Enabled,
+		OnlySuspicious: cfg.Autoscan.OnlySuspicious,
+		MinRiskScore:   cfg.Autoscan.MinRiskScore,
+	}
+}
 
-	// Set up update config
-	updateConfig := tgbotapi.NewUpdate(0)
-	updateConfig.Timeout = 60
+func quotaConfigFrom(cfg *config.Config) services.QuotaConfig {
+	return services.QuotaConfig{
+		DefaultTier: cfg.Limits.DefaultTier,
+		Tiers:       cfg.Limits.Tiers,
+		UserTiers:   cfg.Limits.UserTiers,
+	}
+}
 
-	// Get updates channel
-	updates := bot.GetUpdatesChan(updateConfig)
+func riskWeightsFrom(cfg *config.Config) services.RiskWeights {
+	return services.RiskWeights{
+		Direct:    cfg.Risk.Weights.Direct,
+		Exposure:  cfg.Risk.Weights.Exposure,
+		Sanctions: cfg.Risk.Weights.Sanctions,
+		Blocklist: cfg.Risk.Weights.Blocklist,
+		Age:       cfg.Risk.Weights.Age,
+		Transfers: cfg.Risk.Weights.Transfers,
+	}
+}
 
-	// Set up context for graceful shutdown
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+func graphOptionsFrom(cfg *config.Config) graph.Options {
+	return graph.Options{
+		Hops:         cfg.Graph.Hops,
+		MaxAddresses: cfg.Graph.MaxAddresses,
+		Workers:      cfg.Graph.Workers,
+		Timeout:      cfg.Graph.Timeout,
+		CacheTTL:     cfg.Graph.CacheTTL,
+	}
+}
 
-	// Handle OS signals
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+// freezesFrom creates the issuer freeze lookup for the configured chains
+func freezesFrom(cfg *config.Config) *domain.FreezeClient {
+	freezes := domain.NewFreezeClient()
+	for code, url := range cfg.Freezes.RPC {
+		freezes.SetRPC(domain.Chain(strings.ToUpper(code)), url)
+	}
+	if cfg.Freezes.Tron.BaseURL != "" {
+		freezes.SetTron(cfg.Freezes.Tron.BaseURL, cfg.Freezes.Tron.APIKey)
+	}
+	return freezes
+}
 
-	// Start handling updates
-	go func() {
-		for update := range updates {
-			if update.Message == nil {
-				continue
+// chainDataFrom creates the configured chain data sources. Chains listed in
+// the fixture file are served from it instead.
+func chainDataFrom(cfg *config.Config) (domain.ChainDataSources, error) {
+	sources := make(domain.ChainDataSources)
+	for code, source := range cfg.Chains.Sources {
+		chain := domain.Chain(strings.ToUpper(code))
+		switch source.Type {
+		case "etherscan":
+			client := domain.NewEtherscanClient(chain, source.BaseURL)
+			client.SetAPIKey(source.APIKey)
+			client.SetChainID(source.ChainID)
+			sources[chain] = client
+		case "esplora":
+			if chain != domain.ChainBitcoin {
+				return nil, fmt.Errorf("chains.sources.%s: esplora only serves %s", code, domain.ChainBitcoin)
 			}
+			sources[chain] = domain.NewEsploraClient(source.BaseURL)
+		default:
+			return nil, fmt.Errorf("chains.sources.%s: unknown type %q, expected etherscan or esplora", code, source.Type)
+		}
+	}
 
-			if err := handler.HandleMessage(ctx, update.Message); err != nil {
-				logger.Error("Failed to handle message",
-					zap.Error(err),
-					zap.Int64("chat_id", update.Message.Chat.ID),
-					zap.String("text", update.Message.Text),
-				)
-			}
+	if cfg.Chains.Fixture != "" {
+		fixtures, err := domain.LoadChainFixtures(cfg.Chains.Fixture)
+		if err != nil {
+			return nil, err
+		}
+		for chain, fixture := range fixtures {
+			sources[chain] = fixture
 		}
-	}()
+	}
+	return sources, nil
+}
 
-	logger.Info("Bot started", zap.String("username", bot.Self.UserName))
+func accessConfigFrom(cfg *config.Config) (services.AccessConfig, error) {
+	defaultRole, err := services.ParseRole(cfg.Access.DefaultRole)
+	if err != nil {
+		return services.AccessConfig{}, fmt.Errorf("access.default_role: %w", err)
+	}
 
-	// Wait for shutdown signal
-	<-sigChan
-	logger.Info("Shutting down...")
-	cancel()
+	accessConfig := services.AccessConfig{
+		DefaultRole: defaultRole,
+		Users:       make(map[int64]services.Role, len(cfg.Access.Users)),
+		Chats:       make(map[int64]services.Role, len(cfg.Access.Chats)),
+	}
+	for id, name := range cfg.Access.Users {
+		if accessConfig.Users[id], err = services.ParseRole(name); err != nil {
+			return services.AccessConfig{}, fmt.Errorf("access.users.%d: %w", id, err)
+		}
+	}
+	for id, name := range cfg.Access.Chats {
+		if accessConfig.Chats[id], err = services.ParseRole(name); err != nil {
+			return services.AccessConfig{}, fmt.Errorf("access.chats.%d: %w", id, err)
+		}
+		if role := accessConfig.Chats[id]; role != services.MaxChatRole && role.Allows(services.MaxChatRole) {
+			return services.AccessConfig{}, fmt.Errorf("access.chats.%d: %w", id, services.ErrChatRoleTooHigh)
+		}
+	}
+	return accessConfig, nil
 }
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/clevertechru/tgbot_aml/internal/config"
+)
+
+// reloadable lists the settings, or whole sections, that a reload applies
+// to the running bot
+var reloadable = []string{
+	"logging.level",
+	"translations",
+	"risk",
+	"policy",
+	"graph.hops",
+	"graph.max_addresses",
+	"graph.workers",
+	"graph.timeout",
+	"graph.cache_ttl",
+	"graph.labels",
+	"autoscan",
+	"access",
+	"limits",
+}
+
+// restartRequired lists the settings that changed between two configs but
+// only take effect after a restart
+func restartRequired(old, new *config.Config) []string {
+	var keys []string
+	for _, key := range config.Changed(old, new) {
+		if !isReloadable(key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func isReloadable(key string) bool {
+	for _, setting := range reloadable {
+		if key == setting || strings.HasPrefix(key, setting+".") {
+			return true
+		}
+	}
+	return false
+}
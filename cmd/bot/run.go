@@ -0,0 +1,321 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+
+	"github.com/clevertechru/tgbot_aml/internal/config"
+	"github.com/clevertechru/tgbot_aml/internal/graph"
+	"github.com/clevertechru/tgbot_aml/internal/handlers"
+	"github.com/clevertechru/tgbot_aml/internal/lang"
+	"github.com/clevertechru/tgbot_aml/internal/services"
+	"github.com/clevertechru/tgbot_aml/internal/storage"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// runCommand starts the bot
+func runCommand(args []string) error {
+	flags := flag.NewFlagSet("run", flag.ContinueOnError)
+	configPath := flags.String("config", defaultConfigPath, "path to the config file")
+	logLevel := flags.String("log-level", "", "overrides logging.level: debug, info, warn or error")
+	mode := flags.String("mode", "production", "production logs JSON; development logs readable lines to stderr as well")
+	if err := parseFlags(flags, args, 0); err != nil {
+		return err
+	}
+	if *mode != "production" && *mode != "development" {
+		return fmt.Errorf("unknown mode %q, expected production or development", *mode)
+	}
+
+	// Flags take precedence over the file and the environment
+	overrides := make(map[string]string)
+	if *logLevel != "" {
+		overrides["logging.level"] = *logLevel
+	}
+
+	cfg, err := config.Load(*configPath, overrides)
+	if err != nil {
+		return err
+	}
+
+	// Initialize logger
+	level, err := zap.ParseAtomicLevel(cfg.Logging.Level)
+	if err != nil {
+		return fmt.Errorf("invalid log level: %w", err)
+	}
+	zapConfig := zap.NewProductionConfig()
+	if *mode == "development" {
+		zapConfig = zap.NewDevelopmentConfig()
+	}
+	zapConfig.Level = level
+	if cfg.Logging.File != "" {
+		file, err := os.OpenFile(cfg.Logging.File, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to open log file: %w", err)
+		}
+		defer func() {
+			if err := file.Close(); err != nil {
+				log.Printf("Failed to close log file: %v", err)
+			}
+		}()
+		zapConfig.OutputPaths = []string{cfg.Logging.File}
+		if *mode == "development" {
+			zapConfig.OutputPaths = append(zapConfig.OutputPaths, "stderr")
+		}
+	}
+	logger, err := zapConfig.Build()
+	if err != nil {
+		return fmt.Errorf("failed to create logger: %w", err)
+	}
+	defer func() {
+		if err := logger.Sync(); err != nil {
+			log.Printf("Failed to sync logger: %v", err)
+		}
+	}()
+
+	lang.SetLogger(logger)
+	if err := lang.SetOverrideDir(cfg.Translations.Dir); err != nil {
+		logger.Error("Failed to load translation overrides, using built-in translations", zap.Error(err))
+	}
+	reportTranslationIssues(logger)
+
+	// Initialize bot
+	bot, err := tgbotapi.NewBotAPI(cfg.Telegram.Token)
+	if err != nil {
+		return fmt.Errorf("failed to create bot: %w", err)
+	}
+
+	screening, err := newScreening(cfg, logger)
+	if err != nil {
+		return err
+	}
+	amlService := screening.service
+
+	chatSettings, err := services.NewChatSettings(
+		scanSettingsFrom(cfg),
+		storage.NewJSONFile[map[int64]services.ScanSettings](filepath.Join(cfg.Storage.Dir, "chat_settings.json")),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to load chat settings: %w", err)
+	}
+
+	bulkJobs := services.NewBulkJobs(amlService, services.BulkLimits{
+		MaxFileSize: cfg.Bulk.MaxFileSize,
+		MaxEntries:  cfg.Bulk.MaxEntries,
+		Workers:     cfg.Bulk.Workers,
+	})
+
+	quotas, err := services.NewQuotas(
+		quotaConfigFrom(cfg),
+		storage.NewJSONFile[map[int64]services.QuotaUsage](filepath.Join(cfg.Storage.Dir, "quotas.json")),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to load quotas: %w", err)
+	}
+	limits := services.NewLimits(
+		services.NewRateLimiter(cfg.Limits.UserPerMinute, cfg.Limits.UserBurst),
+		services.NewRateLimiter(cfg.Limits.ChatPerMinute, cfg.Limits.ChatBurst),
+		quotas,
+	)
+
+	accessConfig, err := accessConfigFrom(cfg)
+	if err != nil {
+		return fmt.Errorf("invalid access configuration: %w", err)
+	}
+	access, err := services.NewAccessControl(
+		accessConfig,
+		storage.NewJSONFile[services.AccessGrants](filepath.Join(cfg.Storage.Dir, "access.json")),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to load access grants: %w", err)
+	}
+
+	stats, err := services.NewStats(
+		storage.NewJSONFile[services.DailyStats](filepath.Join(cfg.Storage.Dir, "stats.json")),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to load stats: %w", err)
+	}
+
+	knownChats, err := services.NewKnownChats(
+		storage.NewJSONFile[map[int64]services.KnownChat](filepath.Join(cfg.Storage.Dir, "known_chats.json")),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to load known chats: %w", err)
+	}
+
+	languages, err := services.NewLanguages(
+		storage.NewJSONFile[services.LanguageChoices](filepath.Join(cfg.Storage.Dir, "languages.json")),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to load language preferences: %w", err)
+	}
+
+	audit := services.NewAuditLog(
+		storage.NewJSONLines[services.AuditEntry](filepath.Join(cfg.Storage.Dir, "audit.jsonl")),
+	)
+
+	// reload re-reads the config file and translations and applies the
+	// settings that can change without a restart. Every file is loaded
+	// before anything changes, so a rejected reload keeps the last good
+	// config in force.
+	var reloadMu sync.Mutex
+	reload := func() error {
+		reloadMu.Lock()
+		defer reloadMu.Unlock()
+
+		newCfg, err := config.Load(*configPath, overrides)
+		if err != nil {
+			return err
+		}
+		accessConfig, err := accessConfigFrom(newCfg)
+		if err != nil {
+			return err
+		}
+		newLevel, err := zapcore.ParseLevel(newCfg.Logging.Level)
+		if err != nil {
+			return err
+		}
+		policy, err := services.NewPolicy(newCfg.Policy.File)
+		if err != nil {
+			return err
+		}
+		blocklist, err := services.NewBlocklist(newCfg.Risk.Blocklist)
+		if err != nil {
+			return err
+		}
+		labels, err := graph.NewLabelSet(newCfg.Graph.Labels)
+		if err != nil {
+			return err
+		}
+		// Translations swap themselves in, so they load last
+		if err := lang.SetOverrideDir(newCfg.Translations.Dir); err != nil {
+			return err
+		}
+		reportTranslationIssues(logger)
+
+		level.SetLevel(newLevel)
+		screening.policy.Replace(policy)
+		screening.blocklist.Replace(blocklist)
+		screening.labels.Replace(labels)
+		access.SetConfig(accessConfig)
+		quotas.SetConfig(quotaConfigFrom(newCfg))
+		limits.Users().SetRate(newCfg.Limits.UserPerMinute, newCfg.Limits.UserBurst)
+		limits.Chats().SetRate(newCfg.Limits.ChatPerMinute, newCfg.Limits.ChatBurst)
+		chatSettings.SetDefaults(scanSettingsFrom(newCfg))
+		screening.riskModel.SetWeights(riskWeightsFrom(newCfg))
+		if screening.graph != nil {
+			// Also drops exposure cached under the old labels
+			screening.graph.SetOptions(graphOptionsFrom(newCfg))
+		}
+
+		// Compared with the config the bot started with, so that pending
+		// changes are reported on every reload until the restart
+		for _, key := range restartRequired(cfg, newCfg) {
+			logger.Warn("Config change requires restart", zap.String("setting", key))
+		}
+		return nil
+	}
+
+	// Initialize handlers
+	handler := handlers.NewHandler(bot, handlers.Services{
+		AML:          amlService,
+		ChatSettings: chatSettings,
+		BulkJobs:     bulkJobs,
+		Limits:       limits,
+		Access:       access,
+		Stats:        stats,
+		KnownChats:   knownChats,
+		Languages:    languages,
+		Audit:        audit,
+		Policy:       screening.policy,
+		Reload:       reload,
+	}, logger)
+
+	// Set up update config
+	updateConfig := tgbotapi.NewUpdate(0)
+	updateConfig.Timeout = 60
+
+	// Get updates channel
+	updates := bot.GetUpdatesChan(updateConfig)
+
+	// Set up context for graceful shutdown
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Handle OS signals
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+
+	// Reload on SIGHUP and, when watched, whenever the config file changes
+	reloadOn := func(trigger string) {
+		if err := reload(); err != nil {
+			logger.Error("Config reload rejected, keeping the last good config",
+				zap.String("trigger", trigger),
+				zap.Error(err),
+			)
+			return
+		}
+		logger.Info("Config reloaded", zap.String("trigger", trigger))
+	}
+	hupChan := make(chan os.Signal, 1)
+	signal.Notify(hupChan, syscall.SIGHUP)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hupChan:
+				reloadOn("SIGHUP")
+			}
+		}
+	}()
+	if cfg.Reload.Watch {
+		go config.Watch(ctx, *configPath, cfg.Reload.Interval, func() {
+			reloadOn("file change")
+		})
+	}
+
+	// Start handling updates
+	go func() {
+		for update := range updates {
+			if update.CallbackQuery != nil {
+				if err := handler.HandleCallback(ctx, update.CallbackQuery); err != nil {
+					logger.Error("Failed to handle callback",
+						zap.Error(err),
+						zap.String("data", update.CallbackQuery.Data),
+					)
+				}
+				continue
+			}
+
+			if update.Message == nil {
+				continue
+			}
+
+			if err := handler.HandleMessage(ctx, update.Message); err != nil {
+				logger.Error("Failed to handle message",
+					zap.Error(err),
+					zap.Int64("chat_id", update.Message.Chat.ID),
+					zap.String("text", update.Message.Text),
+				)
+			}
+		}
+	}()
+
+	logger.Info("Bot started", zap.String("username", bot.Self.UserName))
+
+	// Wait for shutdown signal
+	<-sigChan
+	logger.Info("Shutting down...")
+	cancel()
+	return nil
+}
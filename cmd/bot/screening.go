@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/clevertechru/tgbot_aml/internal/config"
+	"github.com/clevertechru/tgbot_aml/internal/domain"
+	"github.com/clevertechru/tgbot_aml/internal/graph"
+	"github.com/clevertechru/tgbot_aml/internal/services"
+	"go.uber.org/zap"
+)
+
+// screening is the AML service and the parts of it that a reload updates.
+// The bot and the check command build it the same way.
+type screening struct {
+	service   *services.AMLService
+	blocklist *services.Blocklist
+	riskModel *services.RiskModel
+	policy    *services.Policy
+	labels    *graph.LabelSet
+	// graph is nil when graph analysis is disabled
+	graph *graph.Analyzer
+}
+
+func newScreening(cfg *config.Config, logger *zap.Logger) (*screening, error) {
+	// Initialize AML provider
+	amlProvider := domain.NewChainabuseProvider()
+	amlProvider.SetAPIKey(cfg.AML.APIKey)
+	amlProvider.SetBaseURL(cfg.AML.BaseURL)
+
+	// Initialize services
+	amlService := services.NewAMLService(amlProvider)
+	amlService.SetCache(services.NewResultCache(cfg.AML.CacheTTL))
+
+	blocklist, err := services.NewBlocklist(cfg.Risk.Blocklist)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load blocklist: %w", err)
+	}
+	riskModel := services.NewRiskModel(riskWeightsFrom(cfg), blocklist)
+	amlService.SetRiskModel(riskModel)
+
+	policy, err := services.NewPolicy(cfg.Policy.File)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load risk policy: %w", err)
+	}
+	amlService.SetPolicy(policy)
+
+	labels, err := graph.NewLabelSet(cfg.Graph.Labels)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load address labels: %w", err)
+	}
+	chainData, err := chainDataFrom(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up chain data: %w", err)
+	}
+	amlService.SetChainData(chainData)
+
+	if cfg.Freezes.Enabled {
+		amlService.SetTokenFreezes(freezesFrom(cfg))
+	}
+
+	var graphAnalyzer *graph.Analyzer
+	switch {
+	case cfg.Graph.Fixture != "":
+		// Older configs set a transfer file here before chain data sources
+		// existed; it still turns graph analysis on
+		logger.Warn("graph.fixture is deprecated, use chains.fixture and graph.enabled instead")
+		fixture, err := graph.LoadFixture(cfg.Graph.Fixture)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load graph.fixture: %w", err)
+		}
+		graphAnalyzer = graph.NewAnalyzer(fixture, labels, graphOptionsFrom(cfg))
+	case cfg.Graph.Enabled:
+		if len(chainData) == 0 {
+			logger.Warn("Graph analysis needs a chain data source, none is configured")
+		}
+		graphAnalyzer = graph.NewAnalyzer(
+			graph.NewChainSource(chainData, cfg.Graph.Transactions), labels, graphOptionsFrom(cfg))
+	}
+	if graphAnalyzer != nil {
+		amlService.SetGraph(graphAnalyzer)
+	}
+
+	return &screening{
+		service:   amlService,
+		blocklist: blocklist,
+		riskModel: riskModel,
+		policy:    policy,
+		labels:    labels,
+		graph:     graphAnalyzer,
+	}, nil
+}
@@ -2,7 +2,12 @@ package config
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -12,49 +17,253 @@ type Config struct {
 		Token string `yaml:"token"`
 	} `yaml:"telegram"`
 	AML struct {
-		APIKey  string `yaml:"api_key"`
-		BaseURL string `yaml:"base_url"`
+		APIKey   string        `yaml:"api_key"`
+		BaseURL  string        `yaml:"base_url"`
+		CacheTTL time.Duration `yaml:"cache_ttl"`
 	} `yaml:"aml"`
 	Logging struct {
 		Level string `yaml:"level"`
 		File  string `yaml:"file"`
 	} `yaml:"logging"`
+	// Reload applies changes to the config file without a restart, checking
+	// it every Interval when Watch is set; SIGHUP and /reload always do
+	Reload struct {
+		Watch    bool          `yaml:"watch"`
+		Interval time.Duration `yaml:"interval"`
+	} `yaml:"reload"`
+	// Translations.Dir holds *.yml files that override or add to the
+	// translations built into the binary
+	Translations struct {
+		Dir string `yaml:"dir"`
+	} `yaml:"translations"`
+	Storage struct {
+		Dir string `yaml:"dir"`
+	} `yaml:"storage"`
+	// Risk sets how much each factor adds to a risk score, and the file
+	// listing our own blocked addresses
+	Risk struct {
+		Blocklist string `yaml:"blocklist"`
+		Weights   struct {
+			Direct    float64 `yaml:"direct"`
+			Exposure  float64 `yaml:"exposure"`
+			Sanctions float64 `yaml:"sanctions"`
+			Blocklist float64 `yaml:"blocklist"`
+			Age       float64 `yaml:"age"`
+			Transfers float64 `yaml:"transfers"`
+		} `yaml:"weights"`
+	} `yaml:"risk"`
+	// Policy.File is the YAML risk policy that turns provider answers into
+	// verdicts; empty flags whatever the provider flags for review
+	Policy struct {
+		File string `yaml:"file"`
+	} `yaml:"policy"`
+	// Chains configures where chain data comes from, by chain code (ETH,
+	// BTC). Type is etherscan or esplora; ChainID selects the chain on
+	// multichain Etherscan APIs. Fixture is a JSON file that serves chain
+	// data offline instead, for every chain it lists.
+	Chains struct {
+		Fixture string `yaml:"fixture"`
+		Sources map[string]struct {
+			Type    string `yaml:"type"`
+			BaseURL string `yaml:"base_url"`
+			APIKey  string `yaml:"api_key"`
+			ChainID int    `yaml:"chain_id"`
+		} `yaml:"sources"`
+	} `yaml:"chains"`
+	// Freezes looks up whether stablecoin issuers froze an address, by
+	// calling the token contracts through a JSON-RPC endpoint per EVM chain
+	// code and the TRON HTTP API
+	Freezes struct {
+		Enabled bool              `yaml:"enabled"`
+		RPC     map[string]string `yaml:"rpc"`
+		Tron    struct {
+			BaseURL string `yaml:"base_url"`
+			APIKey  string `yaml:"api_key"`
+		} `yaml:"tron"`
+	} `yaml:"freezes"`
+	// Graph traces exposure through the transactions around an address,
+	// using the chain data sources. Labels lists known risky addresses, one
+	// "address category" per line. Fixture is deprecated: it is a JSON array
+	// of transfers that turns graph analysis on and replaces the chain data
+	// sources for it; use Chains.Fixture instead.
+	Graph struct {
+		Enabled      bool          `yaml:"enabled"`
+		Hops         int           `yaml:"hops"`
+		MaxAddresses int           `yaml:"max_addresses"`
+		Workers      int           `yaml:"workers"`
+		Timeout      time.Duration `yaml:"timeout"`
+		Transactions int           `yaml:"transactions"`
+		CacheTTL     time.Duration `yaml:"cache_ttl"`
+		Labels       string        `yaml:"labels"`
+		Fixture      string        `yaml:"fixture,omitempty"`
+	} `yaml:"graph"`
+	Autoscan struct {
+		Enabled        bool    `yaml:"enabled"`
+		OnlySuspicious bool    `yaml:"only_suspicious"`
+		MinRiskScore   float64 `yaml:"min_risk_score"`
+	} `yaml:"autoscan"`
+	Bulk struct {
+		MaxFileSize int64 `yaml:"max_file_size"`
+		MaxEntries  int   `yaml:"max_entries"`
+		Workers     int   `yaml:"workers"`
+	} `yaml:"bulk"`
+	// Access seeds roles (admin, analyst, viewer) for Telegram user IDs and
+	// group chat IDs; anyone else gets DefaultRole
+	Access struct {
+		DefaultRole string           `yaml:"default_role"`
+		Users       map[int64]string `yaml:"users"`
+		Chats       map[int64]string `yaml:"chats"`
+	} `yaml:"access"`
+	Limits struct {
+		UserPerMinute int              `yaml:"user_per_minute"`
+		UserBurst     int              `yaml:"user_burst"`
+		ChatPerMinute int              `yaml:"chat_per_minute"`
+		ChatBurst     int              `yaml:"chat_burst"`
+		DefaultTier   string           `yaml:"default_tier"`
+		Tiers         map[string]int   `yaml:"tiers"`
+		UserTiers     map[int64]string `yaml:"user_tiers"`
+	} `yaml:"limits"`
+}
+
+// Load reads the configuration like Read and validates it for running the
+// bot
+func Load(configPath string, overrides map[string]string) (*Config, error) {
+	cfg, err := Read(configPath, overrides)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
 }
 
-func Load(configPath string) (*Config, error) {
+// Read builds the configuration from, in increasing precedence, the
+// defaults, the file at configPath, environment variables and overrides,
+// which map dotted keys such as "logging.level" to values and usually come
+// from command line flags. ${VAR} and ${VAR:-default} in the file are
+// replaced with environment variables. Maps set in the file, such as
+// limits.tiers, replace the default ones. The result is not validated.
+func Read(configPath string, overrides map[string]string) (*Config, error) {
 	data, err := os.ReadFile(configPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
-	var cfg Config
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
-		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", configPath, err)
+	}
+	cfg := DefaultConfig()
+	if len(root.Content) > 0 {
+		if err := expandEnv(&root, os.LookupEnv); err != nil {
+			return nil, fmt.Errorf("%s: %w", configPath, err)
+		}
+		if err := checkKeys(root.Content[0], reflect.TypeOf(*cfg), ""); err != nil {
+			return nil, fmt.Errorf("%s: %w", configPath, err)
+		}
+		resetMaps(root.Content[0], reflect.ValueOf(cfg).Elem())
+		if err := root.Decode(cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse config file %s: %w", configPath, err)
+		}
 	}
 
-	return &cfg, nil
+	for _, override := range envOverrides {
+		if value := os.Getenv(override.env); value != "" {
+			if err := cfg.Set(override.key, value); err != nil {
+				return nil, fmt.Errorf("%s: %w", override.env, err)
+			}
+		}
+	}
+	keys := make([]string, 0, len(overrides))
+	for key := range overrides {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := cfg.Set(key, overrides[key]); err != nil {
+			return nil, err
+		}
+	}
+	return cfg, nil
 }
 
-func DefaultConfig() *Config {
-	return &Config{
-		Telegram: struct {
-			Token string `yaml:"token"`
-		}{
-			Token: os.Getenv("TELEGRAM_BOT_TOKEN"),
-		},
-		AML: struct {
-			APIKey  string `yaml:"api_key"`
-			BaseURL string `yaml:"base_url"`
-		}{
-			APIKey:  os.Getenv("AML_API_KEY"),
-			BaseURL: "https://api.aml-provider.com",
-		},
-		Logging: struct {
-			Level string `yaml:"level"`
-			File  string `yaml:"file"`
-		}{
-			Level: "info",
-			File:  "bot.log",
-		},
+// Set sets the setting at a dotted key, such as "aml.base_url", parsing the
+// value as the file would
+func (c *Config) Set(key, value string) error {
+	node := &yaml.Node{Kind: yaml.ScalarNode, Value: value}
+	parts := strings.Split(key, ".")
+	for i := len(parts) - 1; i >= 0; i-- {
+		node = &yaml.Node{
+			Kind:    yaml.MappingNode,
+			Content: []*yaml.Node{{Kind: yaml.ScalarNode, Value: parts[i]}, node},
+		}
+	}
+	if err := checkKeys(node, reflect.TypeOf(*c), ""); err != nil {
+		return err
+	}
+	if err := node.Decode(c); err != nil {
+		return fmt.Errorf("%s: %w", key, err)
 	}
+	return nil
+}
+
+// WriteYAML writes the configuration in the layout of the config file
+func (c *Config) WriteYAML(w io.Writer) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(c); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+func DefaultConfig() *Config {
+	cfg := &Config{}
+
+	cfg.AML.BaseURL = "https://api.aml-provider.com"
+	cfg.AML.CacheTTL = 10 * time.Minute
+
+	cfg.Logging.Level = "info"
+	cfg.Logging.File = "bot.log"
+
+	cfg.Reload.Watch = true
+	cfg.Reload.Interval = 5 * time.Second
+
+	cfg.Storage.Dir = "data"
+
+	cfg.Risk.Weights.Direct = 1
+	cfg.Risk.Weights.Exposure = 0.5
+	cfg.Risk.Weights.Sanctions = 1
+	cfg.Risk.Weights.Blocklist = 1
+	cfg.Risk.Weights.Age = 0.2
+	cfg.Risk.Weights.Transfers = 1
+
+	cfg.Freezes.Tron.BaseURL = "https://api.trongrid.io"
+
+	cfg.Graph.Hops = 2
+	cfg.Graph.MaxAddresses = 200
+	cfg.Graph.Workers = 4
+	cfg.Graph.Timeout = 20 * time.Second
+	cfg.Graph.Transactions = 100
+	cfg.Graph.CacheTTL = 6 * time.Hour
+
+	cfg.Autoscan.Enabled = false
+	cfg.Autoscan.OnlySuspicious = false
+	cfg.Autoscan.MinRiskScore = 0
+
+	cfg.Bulk.MaxFileSize = 1 << 20
+	cfg.Bulk.MaxEntries = 1000
+	cfg.Bulk.Workers = 4
+
+	cfg.Access.DefaultRole = "none"
+
+	cfg.Limits.UserPerMinute = 20
+	cfg.Limits.UserBurst = 5
+	cfg.Limits.ChatPerMinute = 60
+	cfg.Limits.ChatBurst = 20
+	cfg.Limits.DefaultTier = "free"
+	cfg.Limits.Tiers = map[string]int{"free": 100}
+
+	return cfg
 }
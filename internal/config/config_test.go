@@ -0,0 +1,267 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeConfig writes a config file to a temporary directory
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// clearEnv unsets the variables that override settings, for the duration of
+// the test
+func clearEnv(t *testing.T) {
+	t.Helper()
+	for _, override := range envOverrides {
+		t.Setenv(override.env, "")
+	}
+}
+
+func TestLoadExpandsEnv(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("TELEGRAM_BOT_TOKEN", "123:abc")
+	t.Setenv("TEST_WORKERS", "8")
+	path := writeConfig(t, `
+telegram:
+  token: ${TELEGRAM_BOT_TOKEN}
+aml:
+  api_key: "${TEST_UNSET:-fallback}"
+  cache_ttl: ${TEST_TTL:-5m}
+bulk:
+  workers: ${TEST_WORKERS}
+storage:
+  dir: "${TEST_UNSET}/data"
+`)
+
+	cfg, err := Load(path, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Telegram.Token != "123:abc" || cfg.AML.APIKey != "fallback" {
+		t.Errorf("got token %q and API key %q", cfg.Telegram.Token, cfg.AML.APIKey)
+	}
+	if cfg.AML.CacheTTL != 5*time.Minute || cfg.Bulk.Workers != 8 {
+		t.Errorf("got cache TTL %s and %d workers", cfg.AML.CacheTTL, cfg.Bulk.Workers)
+	}
+	if cfg.Storage.Dir != "/data" {
+		t.Errorf("got storage dir %q, want unset variables to be empty", cfg.Storage.Dir)
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	clearEnv(t)
+	path := writeConfig(t, `
+telegram:
+  token: from-file
+aml:
+  api_key: from-file
+  base_url: https://file.example.com
+logging:
+  level: warn
+`)
+
+	cfg, err := Load(path, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.AML.BaseURL != "https://file.example.com" || cfg.Logging.Level != "warn" {
+		t.Errorf("expected the file over the defaults, got %q and %q", cfg.AML.BaseURL, cfg.Logging.Level)
+	}
+	if cfg.Bulk.Workers != 4 {
+		t.Errorf("expected the default for settings the file leaves out, got %d workers", cfg.Bulk.Workers)
+	}
+
+	t.Setenv("AML_BASE_URL", "https://env.example.com")
+	t.Setenv("LOG_LEVEL", "error")
+	cfg, err = Load(path, map[string]string{"logging.level": "debug"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.AML.BaseURL != "https://env.example.com" {
+		t.Errorf("expected the environment over the file, got %q", cfg.AML.BaseURL)
+	}
+	if cfg.Logging.Level != "debug" {
+		t.Errorf("expected flags over the environment, got %q", cfg.Logging.Level)
+	}
+}
+
+func TestLoadDeprecatedGraphFixture(t *testing.T) {
+	clearEnv(t)
+	path := writeConfig(t, "telegram:\n  token: t\naml:\n  api_key: k\ngraph:\n  fixture: testdata/graph.json\n")
+
+	cfg, err := Load(path, nil)
+	if err != nil {
+		t.Fatalf("expected graph.fixture to still load, got %v", err)
+	}
+	if cfg.Graph.Fixture != "testdata/graph.json" {
+		t.Errorf("Graph.Fixture = %q", cfg.Graph.Fixture)
+	}
+}
+
+func TestLoadRateLimitsOff(t *testing.T) {
+	clearEnv(t)
+	path := writeConfig(t, "telegram:\n  token: t\naml:\n  api_key: k\nlimits:\n  user_per_minute: 0\n  user_burst: 0\n  chat_per_minute: 0\n  chat_burst: 0\naccess:\n  users:\n    1: Admin\n  chats:\n    -100: analyst\n")
+
+	cfg, err := Load(path, nil)
+	if err != nil {
+		t.Fatalf("expected zero rate limits and valid roles to load, got %v", err)
+	}
+	if cfg.Limits.UserPerMinute != 0 || cfg.Limits.ChatBurst != 0 {
+		t.Errorf("got limits %+v", cfg.Limits)
+	}
+}
+
+func TestLoadReplacesDefaultTiers(t *testing.T) {
+	clearEnv(t)
+	path := writeConfig(t, "telegram:\n  token: t\naml:\n  api_key: k\nlimits:\n  default_tier: basic\n  tiers:\n    basic: 50\n")
+
+	cfg, err := Load(path, map[string]string{"limits.tiers.staff": "0"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.Limits.Tiers) != 2 || cfg.Limits.Tiers["basic"] != 50 {
+		t.Errorf("expected the file's tiers plus the override, got %v", cfg.Limits.Tiers)
+	}
+	if _, ok := cfg.Limits.Tiers["free"]; ok {
+		t.Error("expected the default free tier to be replaced")
+	}
+
+	// Without tiers in the file the defaults stay
+	path = writeConfig(t, "telegram:\n  token: t\naml:\n  api_key: k\n")
+	if cfg, err = Load(path, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Limits.Tiers["free"] != 100 {
+		t.Errorf("expected the default tiers, got %v", cfg.Limits.Tiers)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	clearEnv(t)
+	valid := "telegram:\n  token: t\naml:\n  api_key: k\n"
+
+	tests := []struct {
+		name      string
+		content   string
+		overrides map[string]string
+		want      []string
+	}{
+		{
+			name:    "unknown setting",
+			content: valid + "graph:\n  hop: 3\n",
+			want:    []string{"line 6: unknown setting graph.hop"},
+		},
+		{
+			name:    "unknown setting in a map",
+			content: valid + "chains:\n  sources:\n    ETH:\n      url: x\n",
+			want:    []string{"unknown setting chains.sources.ETH.url"},
+		},
+		{
+			name:    "missing secrets",
+			content: "logging:\n  level: info\n",
+			want: []string{
+				"telegram.token: is required, set it in the file or with TELEGRAM_BOT_TOKEN",
+				"aml.api_key: is required, set it in the file or with AML_API_KEY",
+			},
+		},
+		{
+			name:    "every invalid setting",
+			content: "telegram:\n  token: t\naml:\n  api_key: k\n  base_url: api.example.com\nlogging:\n  level: loud\ngraph:\n  hops: 9\n",
+			want: []string{
+				`aml.base_url: "api.example.com" is not an http(s) URL`,
+				`logging.level: unknown level "loud"`,
+				"graph.hops: must be between 1 and 5, got 9",
+			},
+		},
+		{
+			name:    "negative rate limit",
+			content: valid + "limits:\n  user_per_minute: -1\n",
+			want:    []string{"limits.user_per_minute: must not be negative, got -1"},
+		},
+		{
+			name:    "roles",
+			content: valid + "access:\n  default_role: guest\n  users:\n    1: owner\n  chats:\n    -100: admin\n",
+			want: []string{
+				`access.default_role: unknown role "guest"`,
+				`access.users.1: unknown role "owner"`,
+				`access.chats.-100: group chats can hold at most the analyst role, got "admin"`,
+			},
+		},
+		{
+			name:    "wrong type",
+			content: valid + "bulk:\n  workers: many\n",
+			want:    []string{"cannot unmarshal"},
+		},
+		{
+			name:      "unknown override",
+			content:   valid,
+			overrides: map[string]string{"logging.colour": "on"},
+			want:      []string{"unknown setting logging.colour"},
+		},
+		{
+			name:      "invalid override",
+			content:   valid,
+			overrides: map[string]string{"logging.level": "loud"},
+			want:      []string{`logging.level: unknown level "loud"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(writeConfig(t, tt.content), tt.overrides)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not mention %q", err, want)
+				}
+			}
+		})
+	}
+
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.yml"), nil); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
+
+func TestLoadShippedConfig(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("TELEGRAM_BOT_TOKEN", "token")
+	t.Setenv("AML_API_KEY", "key")
+
+	if _, err := Load("../../config/config.yml", nil); err != nil {
+		t.Fatalf("config/config.yml does not load: %v", err)
+	}
+}
+
+func TestWriteYAMLRoundTrip(t *testing.T) {
+	clearEnv(t)
+	var b strings.Builder
+	if err := DefaultConfig().WriteYAML(&b); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cfg, err := Read(writeConfig(t, b.String()), nil)
+	if err != nil {
+		t.Fatalf("the printed defaults do not load: %v", err)
+	}
+	// Maps come back empty rather than nil, so compare the printed forms
+	var again strings.Builder
+	if err := cfg.WriteYAML(&again); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if again.String() != b.String() {
+		t.Errorf("the printed defaults load as\n%s\nwant\n%s", again.String(), b.String())
+	}
+}
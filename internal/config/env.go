@@ -0,0 +1,123 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// envOverrides are the environment variables that override settings of the
+// config file
+var envOverrides = []struct {
+	env string
+	key string
+}{
+	{"TELEGRAM_BOT_TOKEN", "telegram.token"},
+	{"AML_API_KEY", "aml.api_key"},
+	{"AML_BASE_URL", "aml.base_url"},
+	{"LOG_LEVEL", "logging.level"},
+}
+
+// envReference matches ${VAR} and ${VAR:-default}
+var envReference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// expandEnv replaces environment variable references in the scalars of a
+// YAML document. Unset variables without a default become empty, as in the
+// shell. Expanded plain scalars are typed by their new value, so that
+// "workers: ${WORKERS:-4}" is a number.
+func expandEnv(node *yaml.Node, lookup func(string) (string, bool)) error {
+	if node.Kind == yaml.ScalarNode {
+		if !strings.Contains(node.Value, "${") {
+			return nil
+		}
+		node.Value = envReference.ReplaceAllStringFunc(node.Value, func(reference string) string {
+			match := envReference.FindStringSubmatch(reference)
+			if value, ok := lookup(match[1]); ok && value != "" {
+				return value
+			}
+			return match[3]
+		})
+		if strings.Contains(node.Value, "${") {
+			return fmt.Errorf("line %d: malformed variable reference in %q", node.Line, node.Value)
+		}
+		if node.Style == 0 {
+			node.Tag = ""
+		}
+		return nil
+	}
+	for _, child := range node.Content {
+		if err := expandEnv(child, lookup); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkKeys reports the first key of a YAML mapping that has no setting in
+// t, which yaml.v3 would otherwise ignore
+func checkKeys(node *yaml.Node, t reflect.Type, path string) error {
+	switch {
+	case node.Kind == yaml.MappingNode && t.Kind() == reflect.Struct:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			field, ok := fieldByTag(t, key.Value)
+			if !ok {
+				return fmt.Errorf("line %d: unknown setting %s", key.Line, joinKey(path, key.Value))
+			}
+			if err := checkKeys(value, field.Type, joinKey(path, key.Value)); err != nil {
+				return err
+			}
+		}
+	case node.Kind == yaml.MappingNode && t.Kind() == reflect.Map:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if err := checkKeys(value, t.Elem(), joinKey(path, key.Value)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// resetMaps clears the maps the file sets, so that they replace the
+// defaults instead of being merged into them; otherwise a default entry
+// such as limits.tiers.free could never be removed. The node must have
+// passed checkKeys.
+func resetMaps(node *yaml.Node, v reflect.Value) {
+	if node.Kind != yaml.MappingNode || v.Kind() != reflect.Struct {
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		field, ok := fieldByTag(v.Type(), node.Content[i].Value)
+		if !ok {
+			continue
+		}
+		value := v.FieldByIndex(field.Index)
+		switch value.Kind() {
+		case reflect.Map:
+			value.Set(reflect.Zero(value.Type()))
+		case reflect.Struct:
+			resetMaps(node.Content[i+1], value)
+		}
+	}
+}
+
+func fieldByTag(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if strings.Split(field.Tag.Get("yaml"), ",")[0] == name {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+func joinKey(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Changed lists the settings that differ between two configurations, as
+// dotted keys such as "aml.base_url". Maps count as one setting.
+func Changed(old, new *Config) []string {
+	var keys []string
+	changed(reflect.ValueOf(*old), reflect.ValueOf(*new), "", &keys)
+	return keys
+}
+
+func changed(old, new reflect.Value, path string, keys *[]string) {
+	if old.Kind() != reflect.Struct {
+		if !reflect.DeepEqual(old.Interface(), new.Interface()) {
+			*keys = append(*keys, path)
+		}
+		return
+	}
+	for i := 0; i < old.NumField(); i++ {
+		name := strings.Split(old.Type().Field(i).Tag.Get("yaml"), ",")[0]
+		changed(old.Field(i), new.Field(i), joinKey(path, name), keys)
+	}
+}
+
+// Watch calls onChange each time the content of the file at path changes,
+// checking every interval until ctx is done. A missing or empty file, as
+// while an editor replaces or rewrites it, is not a change.
+func Watch(ctx context.Context, path string, interval time.Duration, onChange func()) {
+	last, _ := os.ReadFile(path)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil || len(data) == 0 || bytes.Equal(data, last) {
+			continue
+		}
+		last = data
+		onChange()
+	}
+}
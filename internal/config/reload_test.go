@@ -0,0 +1,78 @@
+package config
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestChanged(t *testing.T) {
+	old := DefaultConfig()
+	if keys := Changed(old, DefaultConfig()); len(keys) != 0 {
+		t.Errorf("expected no changes, got %v", keys)
+	}
+
+	new := DefaultConfig()
+	new.AML.BaseURL = "https://other.example.com"
+	new.Logging.Level = "debug"
+	new.Limits.Tiers = map[string]int{"free": 50}
+	want := []string{"aml.base_url", "logging.level", "limits.tiers"}
+	if keys := Changed(old, new); !reflect.DeepEqual(keys, want) {
+		t.Errorf("Changed() = %v, want %v", keys, want)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	path := writeConfig(t, "logging:\n  level: info\n")
+	changes := make(chan struct{}, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		Watch(ctx, path, 10*time.Millisecond, func() { changes <- struct{}{} })
+		close(done)
+	}()
+
+	expect := func(want bool) {
+		t.Helper()
+		select {
+		case <-changes:
+			if !want {
+				t.Error("unexpected change")
+			}
+		case <-time.After(200 * time.Millisecond):
+			if want {
+				t.Error("expected a change")
+			}
+		}
+	}
+
+	// Let Watch read the file before it changes
+	expect(false)
+	if err := os.WriteFile(path, []byte("logging:\n  level: debug\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	expect(true)
+
+	// Rewriting the same content, or the file missing for a while, is not a
+	// change
+	if err := os.WriteFile(path, []byte("logging:\n  level: debug\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	expect(false)
+	if err := os.WriteFile(path, []byte("logging:\n  level: debug\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	expect(false)
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Watch did not stop")
+	}
+}
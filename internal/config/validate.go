@@ -0,0 +1,175 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// logLevels are the levels logging.level accepts
+var logLevels = map[string]bool{"debug": true, "info": true, "warn": true, "error": true}
+
+// roles are the names access roles accept; an empty role means none. Group
+// chats can hold at most analyst, as in services.MaxChatRole.
+var (
+	roles     = map[string]bool{"": true, "none": true, "viewer": true, "analyst": true, "admin": true}
+	chatRoles = map[string]bool{"": true, "none": true, "viewer": true, "analyst": true}
+)
+
+// maxGraphHops matches the deepest trace the graph package allows
+const maxGraphHops = 5
+
+// Validate checks that every setting is usable and reports all problems at
+// once, one per line
+func (c *Config) Validate() error {
+	return c.validate(true)
+}
+
+// ValidateOffline is Validate for screening without Telegram, which needs
+// no bot token
+func (c *Config) ValidateOffline() error {
+	return c.validate(false)
+}
+
+func (c *Config) validate(telegram bool) error {
+	var problems []error
+	add := func(key, format string, args ...interface{}) {
+		problems = append(problems, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+	}
+	required := func(key, value, env string) {
+		if strings.TrimSpace(value) == "" {
+			add(key, "is required, set it in the file or with %s", env)
+		}
+	}
+	httpURL := func(key, value string) {
+		if u, err := url.Parse(value); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			add(key, "%q is not an http(s) URL", value)
+		}
+	}
+	positive := func(key string, value int64) {
+		if value <= 0 {
+			add(key, "must be positive, got %d", value)
+		}
+	}
+	notNegative := func(key string, value time.Duration) {
+		if value < 0 {
+			add(key, "must not be negative, got %s", value)
+		}
+	}
+	notNegativeCount := func(key string, value int64) {
+		if value < 0 {
+			add(key, "must not be negative, got %d", value)
+		}
+	}
+	role := func(key, value string, allowed map[string]bool) {
+		switch name := strings.ToLower(strings.TrimSpace(value)); {
+		case !roles[name]:
+			add(key, "unknown role %q, expected admin, analyst, viewer or none", value)
+		case !allowed[name]:
+			add(key, "group chats can hold at most the analyst role, got %q", value)
+		}
+	}
+
+	if telegram {
+		required("telegram.token", c.Telegram.Token, "TELEGRAM_BOT_TOKEN")
+	}
+	required("aml.api_key", c.AML.APIKey, "AML_API_KEY")
+	httpURL("aml.base_url", c.AML.BaseURL)
+	notNegative("aml.cache_ttl", c.AML.CacheTTL)
+
+	if !logLevels[c.Logging.Level] {
+		add("logging.level", "unknown level %q, expected debug, info, warn or error", c.Logging.Level)
+	}
+
+	if c.Reload.Watch && c.Reload.Interval <= 0 {
+		add("reload.interval", "must be positive to watch the file, got %s", c.Reload.Interval)
+	}
+
+	weights := map[string]float64{
+		"direct":    c.Risk.Weights.Direct,
+		"exposure":  c.Risk.Weights.Exposure,
+		"sanctions": c.Risk.Weights.Sanctions,
+		"blocklist": c.Risk.Weights.Blocklist,
+		"age":       c.Risk.Weights.Age,
+		"transfers": c.Risk.Weights.Transfers,
+	}
+	for _, name := range sortedKeys(weights) {
+		if weights[name] < 0 {
+			add("risk.weights."+name, "must not be negative, got %g", weights[name])
+		}
+	}
+
+	for _, code := range sortedKeys(c.Chains.Sources) {
+		source := c.Chains.Sources[code]
+		if source.Type != "etherscan" && source.Type != "esplora" {
+			add("chains.sources."+code+".type", "unknown type %q, expected etherscan or esplora", source.Type)
+		}
+		httpURL("chains.sources."+code+".base_url", source.BaseURL)
+	}
+
+	if c.Freezes.Enabled {
+		for _, code := range sortedKeys(c.Freezes.RPC) {
+			httpURL("freezes.rpc."+code, c.Freezes.RPC[code])
+		}
+		if c.Freezes.Tron.BaseURL != "" {
+			httpURL("freezes.tron.base_url", c.Freezes.Tron.BaseURL)
+		}
+	}
+
+	if c.Graph.Hops < 1 || c.Graph.Hops > maxGraphHops {
+		add("graph.hops", "must be between 1 and %d, got %d", maxGraphHops, c.Graph.Hops)
+	}
+	positive("graph.max_addresses", int64(c.Graph.MaxAddresses))
+	positive("graph.workers", int64(c.Graph.Workers))
+	notNegative("graph.timeout", c.Graph.Timeout)
+	positive("graph.transactions", int64(c.Graph.Transactions))
+	notNegative("graph.cache_ttl", c.Graph.CacheTTL)
+
+	if c.Autoscan.MinRiskScore < 0 || c.Autoscan.MinRiskScore > 1 {
+		add("autoscan.min_risk_score", "must be between 0 and 1, got %g", c.Autoscan.MinRiskScore)
+	}
+
+	positive("bulk.max_file_size", c.Bulk.MaxFileSize)
+	positive("bulk.max_entries", int64(c.Bulk.MaxEntries))
+	positive("bulk.workers", int64(c.Bulk.Workers))
+
+	role("access.default_role", c.Access.DefaultRole, roles)
+	for _, id := range sortedKeys(c.Access.Users) {
+		role(fmt.Sprintf("access.users.%d", id), c.Access.Users[id], roles)
+	}
+	for _, id := range sortedKeys(c.Access.Chats) {
+		role(fmt.Sprintf("access.chats.%d", id), c.Access.Chats[id], chatRoles)
+	}
+
+	// Zero turns a rate limit off
+	notNegativeCount("limits.user_per_minute", int64(c.Limits.UserPerMinute))
+	notNegativeCount("limits.user_burst", int64(c.Limits.UserBurst))
+	notNegativeCount("limits.chat_per_minute", int64(c.Limits.ChatPerMinute))
+	notNegativeCount("limits.chat_burst", int64(c.Limits.ChatBurst))
+	if _, ok := c.Limits.Tiers[c.Limits.DefaultTier]; !ok {
+		add("limits.default_tier", "tier %q is not in limits.tiers", c.Limits.DefaultTier)
+	}
+	for _, id := range sortedKeys(c.Limits.UserTiers) {
+		if _, ok := c.Limits.Tiers[c.Limits.UserTiers[id]]; !ok {
+			add(fmt.Sprintf("limits.user_tiers.%d", id), "tier %q is not in limits.tiers", c.Limits.UserTiers[id])
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid config:\n%w", errors.Join(problems...))
+	}
+	return nil
+}
+
+// sortedKeys makes problems come out in the same order every time
+func sortedKeys[K string | int64, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
@@ -3,6 +3,7 @@ package domain
 import (
 	"errors"
 	"fmt"
+	"time"
 )
 
 var (
@@ -12,66 +13,123 @@ var (
 
 type AMLResult struct {
 	Address      string
+	Provider     string
 	IsSuspicious bool
 	RiskScore    float64
 	Details      []string
+	Categories   []string
+	Exposure     map[string]float64
 }
 
 type TransactionResult struct {
 	TransactionID string
+	Provider      string
 	IsSuspicious  bool
 	RiskScore     float64
 	Details       []string
+	Categories    []string
+	Exposure      map[string]float64
 }
 
-// FormatAMLResult formats an AMLResult into a human-readable string
-func FormatAMLResult(result *AMLResult) string {
-	if result == nil {
-		return "No result available"
-	}
+// Verdict is what our risk policy decides to do about a screened target
+type Verdict string
 
-	status := "✅ Clean"
-	if result.IsSuspicious {
-		status = "⚠️ Suspicious"
-	}
+const (
+	VerdictAllow  Verdict = "allow"
+	VerdictReview Verdict = "review"
+	VerdictBlock  Verdict = "block"
+)
 
-	return fmt.Sprintf(
-		"Address: %s\nStatus: %s\nRisk Score: %.2f\nDetails:\n%s",
-		result.Address,
-		status,
-		result.RiskScore,
-		formatDetails(result.Details),
-	)
+// ParseVerdict parses a verdict name
+func ParseVerdict(s string) (Verdict, error) {
+	switch v := Verdict(s); v {
+	case VerdictAllow, VerdictReview, VerdictBlock:
+		return v, nil
+	}
+	return "", fmt.Errorf("unknown verdict %q, expected allow, review or block", s)
 }
 
-// FormatTransactionResult formats a TransactionResult into a human-readable string
-func FormatTransactionResult(result *TransactionResult) string {
-	if result == nil {
-		return "No result available"
+// Worse returns the stricter of two verdicts
+func (v Verdict) Worse(other Verdict) Verdict {
+	if other.rank() > v.rank() {
+		return other
 	}
+	return v
+}
 
-	status := "✅ Clean"
-	if result.IsSuspicious {
-		status = "⚠️ Suspicious"
+func (v Verdict) rank() int {
+	switch v {
+	case VerdictBlock:
+		return 2
+	case VerdictReview:
+		return 1
+	default:
+		return 0
 	}
+}
 
-	return fmt.Sprintf(
-		"Transaction: %s\nStatus: %s\nRisk Score: %.2f\nDetails:\n%s",
-		result.TransactionID,
-		status,
-		result.RiskScore,
-		formatDetails(result.Details),
-	)
+// ProviderResponse is what a single provider answered for a target
+type ProviderResponse struct {
+	Provider     string             `json:"provider"`
+	IsSuspicious bool               `json:"is_suspicious"`
+	RiskScore    float64            `json:"risk_score"`
+	Details      []string           `json:"details,omitempty"`
+	Categories   []string           `json:"categories,omitempty"`
+	Exposure     map[string]float64 `json:"exposure,omitempty"`
 }
 
-func formatDetails(details []string) string {
-	if len(details) == 0 {
-		return "  - No details available"
-	}
+// ScreeningResult is the outcome of screening a detected target, whether it
+// turned out to be an address or a transaction
+type ScreeningResult struct {
+	Target       Target
+	IsSuspicious bool
+	RiskScore    float64
+	Details      []string
+	Categories   []string
+	// Exposure is the share of funds, between 0 and 1, that comes from
+	// each category
+	Exposure map[string]float64
+	// Graph is the exposure traced through the transaction graph, nil when
+	// graph analysis is disabled or failed
+	Graph *GraphExposure
+	// Responses holds the answer of every provider the verdict is based on
+	Responses []ProviderResponse
+	// Amount is the payment amount, in whole coins or tokens, when the
+	// target was screened as the recipient of a payment link, and FirstSeen is when the address was
+	// first active; both are zero when unknown
+	Amount    float64
+	FirstSeen time.Time
+	// Transfers screens each token transfer of a transaction separately
+	Transfers []TransferRisk
+	// Factors break RiskScore down into the contributions of each risk
+	// factor, largest first
+	Factors []RiskFactor
+	// Verdict is the decision of the risk policy and FiredRules names the
+	// policy rules that led to it
+	Verdict    Verdict
+	FiredRules []string
+}
 
-	var formatted string
-	for _, detail := range details {
-		formatted += fmt.Sprintf("  - %s\n", detail)
-	}
-	return formatted
+// TransferRisk is one token transfer with its sender, receiver and token
+// contract each screened on their own. The transfer is as risky as the
+// riskiest of the three.
+type TransferRisk struct {
+	Transfer     TokenTransfer
+	Sender       *ScreeningResult
+	Receiver     *ScreeningResult
+	Token        *ScreeningResult
+	IsSuspicious bool
+	RiskScore    float64
+	Verdict      Verdict
+}
+
+// PaymentCheckResult is the outcome of screening a payment request: the
+// recipient and, for token payments, the token contract
+type PaymentCheckResult struct {
+	Request      *PaymentRequest
+	Recipient    *ScreeningResult
+	Token        *ScreeningResult
+	IsSuspicious bool
+	RiskScore    float64
+	Verdict      Verdict
 }
@@ -25,6 +25,31 @@ type CheckResult struct {
 	IsSuspicious bool
 	RiskScore    float64
 	Details      string
+	Categories   []string
+	// Exposure is the share of funds from each category, for providers
+	// that trace where funds came from
+	Exposure map[string]float64
+}
+
+// chainabuseResponse is the provider's answer for an address or a
+// transaction. Exposure maps a category to the share of funds, between 0
+// and 1, that the provider traced to it.
+type chainabuseResponse struct {
+	IsSuspicious bool               `json:"is_suspicious"`
+	RiskScore    float64            `json:"risk_score"`
+	Details      string             `json:"details"`
+	Categories   []string           `json:"categories"`
+	Exposure     map[string]float64 `json:"exposure"`
+}
+
+func (r chainabuseResponse) checkResult() *CheckResult {
+	return &CheckResult{
+		IsSuspicious: r.IsSuspicious,
+		RiskScore:    r.RiskScore,
+		Details:      r.Details,
+		Categories:   r.Categories,
+		Exposure:     r.Exposure,
+	}
 }
 
 type ChainabuseProvider struct {
@@ -46,6 +71,10 @@ func NewChainabuseProvider() *ChainabuseProvider {
 	}
 }
 
+func (p *ChainabuseProvider) Name() string {
+	return "chainabuse"
+}
+
 func (p *ChainabuseProvider) SetAPIKey(apiKey string) {
 	p.apiKey = apiKey
 }
@@ -78,20 +107,11 @@ func (p *ChainabuseProvider) CheckAddress(ctx context.Context, address string) (
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
-	var result struct {
-		IsSuspicious bool    `json:"is_suspicious"`
-		RiskScore    float64 `json:"risk_score"`
-		Details      string  `json:"details"`
-	}
+	var result chainabuseResponse
 	if err := json.Unmarshal(body, &result); err != nil {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
-
-	return &CheckResult{
-		IsSuspicious: result.IsSuspicious,
-		RiskScore:    result.RiskScore,
-		Details:      result.Details,
-	}, nil
+	return result.checkResult(), nil
 }
 
 func (p *ChainabuseProvider) CheckTransaction(ctx context.Context, txHash string) (*CheckResult, error) {
@@ -118,18 +138,9 @@ func (p *ChainabuseProvider) CheckTransaction(ctx context.Context, txHash string
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
-	var result struct {
-		IsSuspicious bool    `json:"is_suspicious"`
-		RiskScore    float64 `json:"risk_score"`
-		Details      string  `json:"details"`
-	}
+	var result chainabuseResponse
 	if err := json.Unmarshal(body, &result); err != nil {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
-
-	return &CheckResult{
-		IsSuspicious: result.IsSuspicious,
-		RiskScore:    result.RiskScore,
-		Details:      result.Details,
-	}, nil
+	return result.checkResult(), nil
 }
@@ -0,0 +1,170 @@
+package domain
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned by chain data sources for unknown transactions and
+// for addresses that have never been used
+var ErrNotFound = errors.New("not found on chain")
+
+// ChainDataSource reads transactions from a blockchain, for features that
+// need more than what AML providers report
+type ChainDataSource interface {
+	Name() string
+	Chain() Chain
+	// Transactions returns up to limit of the latest transactions the
+	// address took part in, newest first
+	Transactions(ctx context.Context, address string, limit int) ([]ChainTransaction, error)
+	// Transaction returns a transaction with its event logs
+	Transaction(ctx context.Context, hash string) (*ChainTransaction, error)
+	// TokenTransfers returns up to limit of the latest token transfers from
+	// or to the address, newest first
+	TokenTransfers(ctx context.Context, address string, limit int) ([]TokenTransfer, error)
+	// FirstSeen returns the block in which the address was first used
+	FirstSeen(ctx context.Context, address string) (Block, error)
+}
+
+// Block identifies a block by height and time
+type Block struct {
+	Number uint64    `json:"number"`
+	Time   time.Time `json:"time"`
+}
+
+// TxLeg is an address and the value it put into or took out of a
+// transaction, in the chain's native unit
+type TxLeg struct {
+	Address string  `json:"address"`
+	Value   float64 `json:"value"`
+}
+
+// EventLog is an event emitted by a contract during a transaction, as the
+// raw topics and data it was logged with
+type EventLog struct {
+	Index   int      `json:"index"`
+	Address string   `json:"address"`
+	Topics  []string `json:"topics"`
+	Data    string   `json:"data"`
+}
+
+// ChainTransaction is a transaction as the chain recorded it. Account-based
+// chains have a single input and output; Bitcoin transactions have as many
+// as they spend and create.
+type ChainTransaction struct {
+	Hash    string     `json:"hash"`
+	Block   Block      `json:"block"`
+	Inputs  []TxLeg    `json:"inputs"`
+	Outputs []TxLeg    `json:"outputs"`
+	Failed  bool       `json:"failed,omitempty"`
+	Logs    []EventLog `json:"logs,omitempty"`
+}
+
+// TokenTransfer is a movement of tokens between two addresses. Value is in
+// whole tokens, already scaled by the token's decimals.
+type TokenTransfer struct {
+	TxHash   string  `json:"tx_hash"`
+	LogIndex int     `json:"log_index"`
+	Block    Block   `json:"block"`
+	Token    string  `json:"token"`
+	Symbol   string  `json:"symbol,omitempty"`
+	From     string  `json:"from"`
+	To       string  `json:"to"`
+	Value    float64 `json:"value"`
+}
+
+// ChainDataSources holds a data source per chain
+type ChainDataSources map[Chain]ChainDataSource
+
+// For returns the data source of a chain, if there is one
+func (s ChainDataSources) For(chain Chain) (ChainDataSource, bool) {
+	source, ok := s[chain]
+	return source, ok && source != nil
+}
+
+// maxResponseSize bounds the body read from chain data APIs
+const maxResponseSize = 16 << 20
+
+// getJSON fetches url and decodes its JSON body into v. A 404 is ErrNotFound.
+func getJSON(ctx context.Context, client *http.Client, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	return doJSON(client, req, v)
+}
+
+// postJSON posts body as JSON to url, with the extra header, and decodes the
+// JSON answer into v like getJSON
+func postJSON(ctx context.Context, client *http.Client, url string, header http.Header, body, v interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return doJSON(client, req, v)
+}
+
+func doJSON(client *http.Client, req *http.Request, v interface{}) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	// Closing a fully read body cannot fail in a way that matters
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
+
+// scaleAmount turns an integer amount in base units, decimal or 0x-prefixed
+// hex, into whole units with the given number of decimals
+func scaleAmount(amount string, decimals int) (float64, error) {
+	n, ok := new(big.Int), false
+	if strings.HasPrefix(amount, "0x") {
+		if amount == "0x" {
+			return 0, nil
+		}
+		n, ok = n.SetString(amount[2:], 16)
+	} else {
+		n, ok = n.SetString(amount, 10)
+	}
+	if !ok {
+		return 0, fmt.Errorf("invalid amount %q", amount)
+	}
+
+	value := new(big.Float).SetInt(n)
+	if decimals > 0 {
+		value.Quo(value, new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)))
+	}
+	f, _ := value.Float64()
+	return f, nil
+}
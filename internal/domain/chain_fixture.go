@@ -0,0 +1,155 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ChainFixture serves chain data from memory, so features built on chain
+// data can run and be tested offline
+type ChainFixture struct {
+	chain          Chain
+	transactions   []ChainTransaction
+	tokenTransfers []TokenTransfer
+}
+
+// chainFixtureFile is one chain's data in a fixture file
+type chainFixtureFile struct {
+	Transactions   []ChainTransaction `json:"transactions"`
+	TokenTransfers []TokenTransfer    `json:"token_transfers"`
+}
+
+func NewChainFixture(chain Chain, transactions []ChainTransaction, tokenTransfers []TokenTransfer) *ChainFixture {
+	return &ChainFixture{
+		chain:          chain,
+		transactions:   transactions,
+		tokenTransfers: tokenTransfers,
+	}
+}
+
+// LoadChainFixtures reads a JSON file with the transactions and token
+// transfers of each chain, keyed by chain code:
+//
+//	{"ETH": {"transactions": [...], "token_transfers": [...]}}
+func LoadChainFixtures(path string) (ChainDataSources, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read chain fixture: %w", err)
+	}
+
+	var file map[Chain]chainFixtureFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse chain fixture %s: %w", path, err)
+	}
+
+	sources := make(ChainDataSources, len(file))
+	for chain, fixture := range file {
+		sources[chain] = NewChainFixture(chain, fixture.Transactions, fixture.TokenTransfers)
+	}
+	return sources, nil
+}
+
+func (f *ChainFixture) Name() string {
+	return "fixture"
+}
+
+func (f *ChainFixture) Chain() Chain {
+	return f.chain
+}
+
+func (f *ChainFixture) Transactions(ctx context.Context, address string, limit int) ([]ChainTransaction, error) {
+	if address == "" {
+		return nil, ErrEmptyAddress
+	}
+
+	var result []ChainTransaction
+	for _, tx := range f.transactions {
+		if tx.involves(address) {
+			result = append(result, tx)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Block.Number > result[j].Block.Number
+	})
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+func (f *ChainFixture) Transaction(ctx context.Context, hash string) (*ChainTransaction, error) {
+	if hash == "" {
+		return nil, ErrEmptyTransaction
+	}
+
+	for _, tx := range f.transactions {
+		if strings.EqualFold(tx.Hash, hash) {
+			return &tx, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (f *ChainFixture) TokenTransfers(ctx context.Context, address string, limit int) ([]TokenTransfer, error) {
+	if address == "" {
+		return nil, ErrEmptyAddress
+	}
+
+	var result []TokenTransfer
+	for _, transfer := range f.tokenTransfers {
+		if strings.EqualFold(transfer.From, address) || strings.EqualFold(transfer.To, address) {
+			result = append(result, transfer)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Block.Number > result[j].Block.Number
+	})
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+func (f *ChainFixture) FirstSeen(ctx context.Context, address string) (Block, error) {
+	if address == "" {
+		return Block{}, ErrEmptyAddress
+	}
+
+	var first *Block
+	consider := func(block Block) {
+		if first == nil || block.Number < first.Number {
+			first = &block
+		}
+	}
+	for _, tx := range f.transactions {
+		if tx.involves(address) {
+			consider(tx.Block)
+		}
+	}
+	for _, transfer := range f.tokenTransfers {
+		if strings.EqualFold(transfer.From, address) || strings.EqualFold(transfer.To, address) {
+			consider(transfer.Block)
+		}
+	}
+	if first == nil {
+		return Block{}, ErrNotFound
+	}
+	return *first, nil
+}
+
+// involves tells whether the address is among the transaction's inputs or
+// outputs. EVM addresses are compared without regard to case.
+func (t ChainTransaction) involves(address string) bool {
+	for _, legs := range [][]TxLeg{t.Inputs, t.Outputs} {
+		for _, leg := range legs {
+			if strings.EqualFold(leg.Address, address) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestChainFixture(t *testing.T) {
+	sources, err := LoadChainFixtures(filepath.Join("testdata", "chain_fixture.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(sources) != 2 {
+		t.Fatalf("got %d chains, want 2", len(sources))
+	}
+	source, ok := sources.For(ChainEthereum)
+	if !ok || source.Chain() != ChainEthereum {
+		t.Fatalf("expected an Ethereum source, got %v", source)
+	}
+	if _, ok := sources.For(ChainTron); ok {
+		t.Error("expected no TRON source")
+	}
+	ctx := context.Background()
+
+	txs, err := source.Transactions(ctx, "0xtarget", 10)
+	if err != nil || len(txs) != 2 || txs[0].Hash != "0xa2" {
+		t.Errorf("Transactions() = %+v, %v, want both, newest first", txs, err)
+	}
+	if txs, _ := source.Transactions(ctx, "0xtarget", 1); len(txs) != 1 {
+		t.Errorf("expected the limit to apply, got %d transactions", len(txs))
+	}
+
+	tx, err := source.Transaction(ctx, "0xA2")
+	if err != nil || len(tx.Logs) != 1 || tx.Logs[0].Index != 4 {
+		t.Errorf("Transaction() = %+v, %v", tx, err)
+	}
+	if _, err := source.Transaction(ctx, "0xff"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	transfers, err := source.TokenTransfers(ctx, "0xTarget", 10)
+	if err != nil || len(transfers) != 1 || transfers[0].Symbol != "USDT" || transfers[0].Value != 100 {
+		t.Errorf("TokenTransfers() = %+v, %v", transfers, err)
+	}
+
+	// The token transfer predates the first transaction
+	block, err := source.FirstSeen(ctx, "0xTarget")
+	if err != nil || block.Number != 50 {
+		t.Errorf("FirstSeen() = %+v, %v, want block 50", block, err)
+	}
+	if _, err := source.FirstSeen(ctx, "0xunused"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,106 @@
+package domain
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Chain identifies the blockchain a target belongs to
+type Chain string
+
+const (
+	ChainUnknown  Chain = ""
+	ChainBitcoin  Chain = "BTC"
+	ChainEthereum Chain = "ETH"
+	ChainTron     Chain = "TRON"
+	ChainSolana   Chain = "SOL"
+)
+
+// TargetKind tells whether a target is an address or a transaction hash
+type TargetKind int
+
+const (
+	KindAddress TargetKind = iota
+	KindTransaction
+)
+
+func (k TargetKind) String() string {
+	if k == KindTransaction {
+		return "transaction"
+	}
+	return "address"
+}
+
+// Target is an address or transaction hash recognized in user input
+type Target struct {
+	Value string
+	Kind  TargetKind
+	Chain Chain
+}
+
+var (
+	evmAddressPattern  = regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`)
+	evmTxPattern       = regexp.MustCompile(`^0x[0-9a-fA-F]{64}$`)
+	rawTxPattern       = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+	btcBech32Pattern   = regexp.MustCompile(`^(bc1|BC1)[02-9ac-hj-np-zAC-HJ-NP-Z]{11,71}$`)
+	btcBase58Pattern   = regexp.MustCompile(`^[13][1-9A-HJ-NP-Za-km-z]{25,34}$`)
+	tronAddressPattern = regexp.MustCompile(`^T[1-9A-HJ-NP-Za-km-z]{33}$`)
+)
+
+// maxDetectedPerInput caps how many targets are taken from a single message
+const maxDetectedPerInput = 10
+
+// ParseTarget classifies a single token as an address or transaction hash.
+// It returns false when the token does not look like either.
+func ParseTarget(s string) (Target, bool) {
+	s = strings.TrimSpace(s)
+	switch {
+	case evmAddressPattern.MatchString(s):
+		return Target{Value: s, Kind: KindAddress, Chain: ChainEthereum}, true
+	case evmTxPattern.MatchString(s):
+		return Target{Value: s, Kind: KindTransaction, Chain: ChainEthereum}, true
+	case rawTxPattern.MatchString(s):
+		return Target{Value: s, Kind: KindTransaction, Chain: ChainUnknown}, true
+	case tronAddressPattern.MatchString(s):
+		return Target{Value: s, Kind: KindAddress, Chain: ChainTron}, true
+	case btcBech32Pattern.MatchString(s), btcBase58Pattern.MatchString(s):
+		return Target{Value: s, Kind: KindAddress, Chain: ChainBitcoin}, true
+	}
+	return Target{}, false
+}
+
+// DetectTargets scans free text for anything that looks like an address or
+// transaction hash. Duplicates are dropped and the order of appearance is kept.
+func DetectTargets(text string) []Target {
+	tokens := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	seen := make(map[string]struct{})
+	var targets []Target
+	for _, token := range tokens {
+		target, ok := ParseTarget(token)
+		if !ok {
+			continue
+		}
+		key := strings.ToLower(target.Value)
+		if _, dup := seen[key]; dup {
+			continue
+		}
+		seen[key] = struct{}{}
+		targets = append(targets, target)
+		if len(targets) == maxDetectedPerInput {
+			break
+		}
+	}
+	return targets
+}
+
+// ShortValue abbreviates long addresses and hashes for compact display
+func (t Target) ShortValue() string {
+	if len(t.Value) <= 16 {
+		return t.Value
+	}
+	return t.Value[:8] + "…" + t.Value[len(t.Value)-6:]
+}
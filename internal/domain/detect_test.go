@@ -0,0 +1,93 @@
+package domain
+
+import (
+	"testing"
+)
+
+func TestParseTarget(t *testing.T) {
+	cases := []struct {
+		input string
+		ok    bool
+		kind  TargetKind
+		chain Chain
+	}{
+		{"0x742d35Cc6634C0532925a3b844Bc454e4438f44e", true, KindAddress, ChainEthereum},
+		{"0x5c504ed432cb51138bcf09aa5e8a410dd4a1e204ef84bfed1be16dfba1b22060", true, KindTransaction, ChainEthereum},
+		{"5c504ed432cb51138bcf09aa5e8a410dd4a1e204ef84bfed1be16dfba1b22060", true, KindTransaction, ChainUnknown},
+		{"bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", true, KindAddress, ChainBitcoin},
+		{"1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", true, KindAddress, ChainBitcoin},
+		{"TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t", true, KindAddress, ChainTron},
+		{"hello", false, 0, ChainUnknown},
+		{"0x1234", false, 0, ChainUnknown},
+	}
+
+	for _, tc := range cases {
+		target, ok := ParseTarget(tc.input)
+		if ok != tc.ok {
+			t.Errorf("ParseTarget(%q): expected ok=%v, got %v", tc.input, tc.ok, ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if target.Kind != tc.kind || target.Chain != tc.chain {
+			t.Errorf("ParseTarget(%q): expected %v/%q, got %v/%q", tc.input, tc.kind, tc.chain, target.Kind, target.Chain)
+		}
+	}
+}
+
+func TestDetectTargets(t *testing.T) {
+	text := "send to 0x742d35Cc6634C0532925a3b844Bc454e4438f44e, or (TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t). " +
+		"again: 0x742d35cc6634c0532925a3b844bc454e4438f44e"
+
+	targets := DetectTargets(text)
+	if len(targets) != 2 {
+		t.Fatalf("expected 2 targets, got %d: %v", len(targets), targets)
+	}
+	if targets[0].Chain != ChainEthereum || targets[1].Chain != ChainTron {
+		t.Errorf("unexpected chains: %v", targets)
+	}
+}
+
+func TestTargetShortValue(t *testing.T) {
+	target := Target{Value: "0x742d35Cc6634C0532925a3b844Bc454e4438f44e"}
+	if got := target.ShortValue(); got != "0x742d35…38f44e" {
+		t.Errorf("unexpected short value %q", got)
+	}
+}
+
+func TestTargetExplorer(t *testing.T) {
+	cases := []struct {
+		target Target
+		name   string
+		link   string
+		ok     bool
+	}{
+		{Target{Value: "0x742d35Cc6634C0532925a3b844Bc454e4438f44e", Kind: KindAddress, Chain: ChainEthereum},
+			"Etherscan", "https://etherscan.io/address/0x742d35Cc6634C0532925a3b844Bc454e4438f44e", true},
+		{Target{Value: "abc", Kind: KindTransaction, Chain: ChainTron},
+			"Tronscan", "https://tronscan.org/#/transaction/abc", true},
+		{Target{Value: "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", Kind: KindAddress, Chain: ChainBitcoin},
+			"mempool.space", "https://mempool.space/address/bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", true},
+		{Target{Value: "5c504ed4", Kind: KindTransaction, Chain: ChainUnknown}, "", "", false},
+	}
+
+	for _, tc := range cases {
+		name, link, ok := tc.target.Explorer()
+		if name != tc.name || link != tc.link || ok != tc.ok {
+			t.Errorf("Explorer(%+v) = %q, %q, %v", tc.target, name, link, ok)
+		}
+	}
+}
+
+func TestCategorySeverity(t *testing.T) {
+	if got := CategorySeverity("Sanctions"); got != SeverityHigh {
+		t.Errorf("expected sanctions to be high, got %v", got)
+	}
+	if got := CategorySeverity("high risk exchange"); got != SeverityMedium {
+		t.Errorf("expected high risk exchange to be medium, got %v", got)
+	}
+	if got := CategorySeverity("exchange"); got != SeverityLow {
+		t.Errorf("expected exchange to be low, got %v", got)
+	}
+}
@@ -0,0 +1,194 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	satoshisPerBitcoin = 1e8
+	// esploraPageSize is how many confirmed transactions Esplora lists per
+	// page
+	esploraPageSize = 25
+	// esploraMaxPages bounds the pages FirstSeen walks through to reach an
+	// address's oldest transaction
+	esploraMaxPages = 20
+)
+
+// errTooManyTransactions is returned by FirstSeen for addresses whose
+// history is too long to page through
+var errTooManyTransactions = errors.New("too many transactions to find the first one")
+
+// EsploraClient reads Bitcoin chain data from an Esplora-compatible API,
+// such as blockstream.info or mempool.space
+type EsploraClient struct {
+	client  *http.Client
+	baseURL string
+}
+
+func NewEsploraClient(baseURL string) *EsploraClient {
+	return &EsploraClient{
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		baseURL: strings.TrimRight(baseURL, "/"),
+	}
+}
+
+func (c *EsploraClient) Name() string {
+	return "esplora"
+}
+
+func (c *EsploraClient) Chain() Chain {
+	return ChainBitcoin
+}
+
+type esploraTx struct {
+	TxID string `json:"txid"`
+	Vin  []struct {
+		Prevout *esploraOutput `json:"prevout"`
+	} `json:"vin"`
+	Vout   []esploraOutput `json:"vout"`
+	Status struct {
+		Confirmed   bool   `json:"confirmed"`
+		BlockHeight uint64 `json:"block_height"`
+		BlockTime   int64  `json:"block_time"`
+	} `json:"status"`
+}
+
+type esploraOutput struct {
+	Address string `json:"scriptpubkey_address"`
+	Value   int64  `json:"value"`
+}
+
+func (o esploraOutput) leg() TxLeg {
+	return TxLeg{Address: o.Address, Value: float64(o.Value) / satoshisPerBitcoin}
+}
+
+func (t esploraTx) transaction() ChainTransaction {
+	tx := ChainTransaction{Hash: t.TxID}
+	if t.Status.Confirmed {
+		tx.Block = Block{Number: t.Status.BlockHeight, Time: time.Unix(t.Status.BlockTime, 0).UTC()}
+	}
+	for _, in := range t.Vin {
+		// Coinbase inputs spend nothing
+		if in.Prevout != nil {
+			tx.Inputs = append(tx.Inputs, in.Prevout.leg())
+		}
+	}
+	for _, out := range t.Vout {
+		tx.Outputs = append(tx.Outputs, out.leg())
+	}
+	return tx
+}
+
+func (c *EsploraClient) get(ctx context.Context, path string, v interface{}) error {
+	return getJSON(ctx, c.client, c.baseURL+path, v)
+}
+
+// Transactions lists unconfirmed transactions first, then pages through
+// confirmed ones until limit is reached
+func (c *EsploraClient) Transactions(ctx context.Context, address string, limit int) ([]ChainTransaction, error) {
+	if address == "" {
+		return nil, ErrEmptyAddress
+	}
+	if limit < 1 {
+		limit = esploraPageSize
+	}
+
+	path := "/address/" + url.PathEscape(address) + "/txs"
+	var result []ChainTransaction
+	for {
+		var txs []esploraTx
+		err := c.get(ctx, path, &txs)
+		if errors.Is(err, ErrNotFound) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list transactions: %w", err)
+		}
+
+		var confirmed int
+		for _, tx := range txs {
+			result = append(result, tx.transaction())
+			if tx.Status.Confirmed {
+				confirmed++
+			}
+		}
+		if len(result) >= limit || confirmed < esploraPageSize {
+			break
+		}
+		path = "/address/" + url.PathEscape(address) + "/txs/chain/" + url.PathEscape(txs[len(txs)-1].TxID)
+	}
+
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+func (c *EsploraClient) Transaction(ctx context.Context, hash string) (*ChainTransaction, error) {
+	if hash == "" {
+		return nil, ErrEmptyTransaction
+	}
+
+	var tx esploraTx
+	if err := c.get(ctx, "/tx/"+url.PathEscape(hash), &tx); err != nil {
+		return nil, fmt.Errorf("failed to get transaction: %w", err)
+	}
+	result := tx.transaction()
+	return &result, nil
+}
+
+// TokenTransfers returns nothing, Bitcoin has no token contracts
+func (c *EsploraClient) TokenTransfers(ctx context.Context, address string, limit int) ([]TokenTransfer, error) {
+	return nil, nil
+}
+
+// FirstSeen pages to the end of the address's confirmed history
+func (c *EsploraClient) FirstSeen(ctx context.Context, address string) (Block, error) {
+	if address == "" {
+		return Block{}, ErrEmptyAddress
+	}
+
+	var stats struct {
+		ChainStats struct {
+			TxCount int `json:"tx_count"`
+		} `json:"chain_stats"`
+	}
+	if err := c.get(ctx, "/address/"+url.PathEscape(address), &stats); err != nil {
+		return Block{}, fmt.Errorf("failed to get address: %w", err)
+	}
+	count := stats.ChainStats.TxCount
+	if count == 0 {
+		return Block{}, ErrNotFound
+	}
+	if count > esploraPageSize*esploraMaxPages {
+		return Block{}, errTooManyTransactions
+	}
+
+	path := "/address/" + url.PathEscape(address) + "/txs/chain"
+	var last *esploraTx
+	for page := 0; page <= esploraMaxPages; page++ {
+		var txs []esploraTx
+		if err := c.get(ctx, path, &txs); err != nil {
+			return Block{}, fmt.Errorf("failed to list transactions: %w", err)
+		}
+		if len(txs) > 0 {
+			last = &txs[len(txs)-1]
+		}
+		if len(txs) < esploraPageSize {
+			break
+		}
+		path = "/address/" + url.PathEscape(address) + "/txs/chain/" + url.PathEscape(last.TxID)
+	}
+	if last == nil {
+		return Block{}, ErrNotFound
+	}
+	return last.transaction().Block, nil
+}
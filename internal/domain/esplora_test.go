@@ -0,0 +1,105 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// esploraHistory serves an address with count confirmed transactions, the
+// newest numbered highest, paged like Esplora does
+func esploraHistory(t *testing.T, address string, count int) *EsploraClient {
+	t.Helper()
+	tx := func(n int) string {
+		return fmt.Sprintf(`{"txid":"tx%d","vin":[{"prevout":{"scriptpubkey_address":"in%d","value":150000000}},{"prevout":null}],"vout":[{"scriptpubkey_address":%q,"value":100000000},{"scriptpubkey_address":"change","value":49990000}],"status":{"confirmed":true,"block_height":%d,"block_time":%d}}`,
+			n, n, address, 800000+n, 1700000000+n)
+	}
+	page := func(newest int) string {
+		var txs []string
+		for n := newest; n > 0 && len(txs) < esploraPageSize; n-- {
+			txs = append(txs, tx(n))
+		}
+		return "[" + strings.Join(txs, ",") + "]"
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		prefix := "/address/" + address
+		var after int
+		switch path := r.URL.Path; {
+		case path == prefix:
+			fmt.Fprintf(w, `{"chain_stats":{"tx_count":%d}}`, count)
+		case path == prefix+"/txs" || path == prefix+"/txs/chain":
+			fmt.Fprint(w, page(count))
+		case strings.HasPrefix(path, prefix+"/txs/chain/tx"):
+			_, _ = fmt.Sscanf(strings.TrimPrefix(path, prefix+"/txs/chain/tx"), "%d", &after)
+			fmt.Fprint(w, page(after-1))
+		case path == "/tx/tx3":
+			fmt.Fprint(w, tx(3))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return NewEsploraClient(server.URL + "/")
+}
+
+func TestEsploraTransactions(t *testing.T) {
+	client := esploraHistory(t, "bc1qtarget", 60)
+
+	txs, err := client.Transactions(context.Background(), "bc1qtarget", 30)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(txs) != 30 || txs[0].Hash != "tx60" || txs[29].Hash != "tx31" {
+		t.Fatalf("expected the 30 newest transactions across two pages, got %d", len(txs))
+	}
+	first := txs[0]
+	if len(first.Inputs) != 1 || first.Inputs[0] != (TxLeg{Address: "in60", Value: 1.5}) {
+		t.Errorf("unexpected inputs %+v", first.Inputs)
+	}
+	if len(first.Outputs) != 2 || first.Outputs[0] != (TxLeg{Address: "bc1qtarget", Value: 1}) {
+		t.Errorf("unexpected outputs %+v", first.Outputs)
+	}
+	if first.Block.Number != 800060 {
+		t.Errorf("unexpected block %+v", first.Block)
+	}
+
+	tx, err := client.Transaction(context.Background(), "tx3")
+	if err != nil || tx.Hash != "tx3" {
+		t.Errorf("Transaction() = %+v, %v", tx, err)
+	}
+	if _, err := client.Transaction(context.Background(), "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestEsploraFirstSeen(t *testing.T) {
+	tests := []struct {
+		count     int
+		wantBlock uint64
+		wantErr   error
+	}{
+		{count: 3, wantBlock: 800001},
+		{count: 50, wantBlock: 800001},
+		{count: 51, wantBlock: 800001},
+		{count: 0, wantErr: ErrNotFound},
+		{count: esploraPageSize*esploraMaxPages + 1, wantErr: errTooManyTransactions},
+	}
+	for _, tt := range tests {
+		client := esploraHistory(t, "bc1qtarget", tt.count)
+		block, err := client.FirstSeen(context.Background(), "bc1qtarget")
+		if tt.wantErr != nil {
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("%d transactions: expected %v, got %v", tt.count, tt.wantErr, err)
+			}
+			continue
+		}
+		if err != nil || block.Number != tt.wantBlock {
+			t.Errorf("%d transactions: FirstSeen() = %+v, %v, want block %d", tt.count, block, err, tt.wantBlock)
+		}
+	}
+}
@@ -0,0 +1,369 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// etherDecimals converts wei to ether
+	etherDecimals = 18
+	// etherscanMaxPage is the largest page Etherscan-compatible APIs serve
+	etherscanMaxPage = 10000
+)
+
+// EtherscanClient reads chain data from an Etherscan-compatible API, such
+// as Etherscan itself or the explorers of other EVM chains built on it
+type EtherscanClient struct {
+	client  *http.Client
+	chain   Chain
+	baseURL string
+	apiKey  string
+	chainID int
+}
+
+func NewEtherscanClient(chain Chain, baseURL string) *EtherscanClient {
+	return &EtherscanClient{
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		chain:   chain,
+		baseURL: strings.TrimRight(baseURL, "/"),
+	}
+}
+
+func (c *EtherscanClient) Name() string {
+	return "etherscan"
+}
+
+func (c *EtherscanClient) Chain() Chain {
+	return c.chain
+}
+
+func (c *EtherscanClient) SetAPIKey(apiKey string) {
+	c.apiKey = apiKey
+}
+
+// SetChainID selects the chain on multichain APIs such as Etherscan V2. Zero
+// leaves the parameter out.
+func (c *EtherscanClient) SetChainID(chainID int) {
+	c.chainID = chainID
+}
+
+// etherscanResponse is the envelope of every answer. Account endpoints set
+// Status and Message; proxy endpoints answer in JSON-RPC form.
+type etherscanResponse struct {
+	Status  string          `json:"status"`
+	Message string          `json:"message"`
+	Result  json.RawMessage `json:"result"`
+	Error   *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// call queries module/action with params and decodes the result into v. It
+// returns ErrNotFound when the API reports no records or a null result.
+func (c *EtherscanClient) call(ctx context.Context, module, action string, params url.Values, v interface{}) error {
+	params.Set("module", module)
+	params.Set("action", action)
+	if c.apiKey != "" {
+		params.Set("apikey", c.apiKey)
+	}
+	if c.chainID != 0 {
+		params.Set("chainid", strconv.Itoa(c.chainID))
+	}
+
+	var response etherscanResponse
+	if err := getJSON(ctx, c.client, c.baseURL+"?"+params.Encode(), &response); err != nil {
+		return err
+	}
+	if response.Error != nil {
+		return fmt.Errorf("etherscan: %s", response.Error.Message)
+	}
+	if response.Status == "0" {
+		if strings.HasPrefix(response.Message, "No ") {
+			return ErrNotFound
+		}
+		// Errors put their explanation in the result
+		var reason string
+		if json.Unmarshal(response.Result, &reason) != nil || reason == "" {
+			reason = response.Message
+		}
+		return fmt.Errorf("etherscan: %s", reason)
+	}
+	if len(response.Result) == 0 || string(response.Result) == "null" {
+		return ErrNotFound
+	}
+
+	if err := json.Unmarshal(response.Result, v); err != nil {
+		return fmt.Errorf("failed to parse %s result: %w", action, err)
+	}
+	return nil
+}
+
+// etherscanTx is a transaction as the account module lists it
+type etherscanTx struct {
+	BlockNumber string `json:"blockNumber"`
+	TimeStamp   string `json:"timeStamp"`
+	Hash        string `json:"hash"`
+	From        string `json:"from"`
+	To          string `json:"to"`
+	Value       string `json:"value"`
+	IsError     string `json:"isError"`
+	// ContractAddress is the new contract for deployments, and the token
+	// for token transfers
+	ContractAddress string `json:"contractAddress"`
+	TokenSymbol     string `json:"tokenSymbol"`
+	TokenDecimal    string `json:"tokenDecimal"`
+	LogIndex        string `json:"logIndex"`
+}
+
+func (t etherscanTx) block() (Block, error) {
+	number, err := strconv.ParseUint(t.BlockNumber, 10, 64)
+	if err != nil {
+		return Block{}, fmt.Errorf("invalid block number %q", t.BlockNumber)
+	}
+	seconds, err := strconv.ParseInt(t.TimeStamp, 10, 64)
+	if err != nil {
+		return Block{}, fmt.Errorf("invalid timestamp %q", t.TimeStamp)
+	}
+	return Block{Number: number, Time: time.Unix(seconds, 0).UTC()}, nil
+}
+
+func (t etherscanTx) transaction() (ChainTransaction, error) {
+	block, err := t.block()
+	if err != nil {
+		return ChainTransaction{}, err
+	}
+	value, err := scaleAmount(t.Value, etherDecimals)
+	if err != nil {
+		return ChainTransaction{}, err
+	}
+	to := t.To
+	if to == "" {
+		to = t.ContractAddress
+	}
+	return ChainTransaction{
+		Hash:    t.Hash,
+		Block:   block,
+		Inputs:  []TxLeg{{Address: t.From, Value: value}},
+		Outputs: []TxLeg{{Address: to, Value: value}},
+		Failed:  t.IsError == "1",
+	}, nil
+}
+
+func (t etherscanTx) tokenTransfer() (TokenTransfer, error) {
+	block, err := t.block()
+	if err != nil {
+		return TokenTransfer{}, err
+	}
+	decimals, err := strconv.Atoi(t.TokenDecimal)
+	if err != nil {
+		return TokenTransfer{}, fmt.Errorf("invalid token decimals %q", t.TokenDecimal)
+	}
+	value, err := scaleAmount(t.Value, decimals)
+	if err != nil {
+		return TokenTransfer{}, err
+	}
+	logIndex, _ := strconv.Atoi(t.LogIndex)
+	return TokenTransfer{
+		TxHash:   t.Hash,
+		LogIndex: logIndex,
+		Block:    block,
+		Token:    t.ContractAddress,
+		Symbol:   t.TokenSymbol,
+		From:     t.From,
+		To:       t.To,
+		Value:    value,
+	}, nil
+}
+
+// list pages through an account module listing, oldest or newest first
+func (c *EtherscanClient) list(ctx context.Context, action, address string, limit int, sortOrder string) ([]etherscanTx, error) {
+	if address == "" {
+		return nil, ErrEmptyAddress
+	}
+	if limit < 1 || limit > etherscanMaxPage {
+		limit = etherscanMaxPage
+	}
+
+	params := url.Values{}
+	params.Set("address", address)
+	params.Set("page", "1")
+	params.Set("offset", strconv.Itoa(limit))
+	params.Set("sort", sortOrder)
+
+	var txs []etherscanTx
+	err := c.call(ctx, "account", action, params, &txs)
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	return txs, err
+}
+
+func (c *EtherscanClient) Transactions(ctx context.Context, address string, limit int) ([]ChainTransaction, error) {
+	txs, err := c.list(ctx, "txlist", address, limit, "desc")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list transactions: %w", err)
+	}
+
+	result := make([]ChainTransaction, 0, len(txs))
+	for _, tx := range txs {
+		transaction, err := tx.transaction()
+		if err != nil {
+			return nil, fmt.Errorf("transaction %s: %w", tx.Hash, err)
+		}
+		result = append(result, transaction)
+	}
+	return result, nil
+}
+
+func (c *EtherscanClient) TokenTransfers(ctx context.Context, address string, limit int) ([]TokenTransfer, error) {
+	txs, err := c.list(ctx, "tokentx", address, limit, "desc")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list token transfers: %w", err)
+	}
+
+	result := make([]TokenTransfer, 0, len(txs))
+	for _, tx := range txs {
+		transfer, err := tx.tokenTransfer()
+		if err != nil {
+			return nil, fmt.Errorf("token transfer in %s: %w", tx.Hash, err)
+		}
+		result = append(result, transfer)
+	}
+	return result, nil
+}
+
+// FirstSeen is the earlier of the first transaction and the first token
+// transfer, since addresses that only ever received tokens have no
+// transactions of their own
+func (c *EtherscanClient) FirstSeen(ctx context.Context, address string) (Block, error) {
+	var first []Block
+	for _, action := range []string{"txlist", "tokentx"} {
+		txs, err := c.list(ctx, action, address, 1, "asc")
+		if err != nil {
+			return Block{}, fmt.Errorf("failed to find first transaction: %w", err)
+		}
+		if len(txs) == 0 {
+			continue
+		}
+		block, err := txs[0].block()
+		if err != nil {
+			return Block{}, err
+		}
+		first = append(first, block)
+	}
+	if len(first) == 0 {
+		return Block{}, ErrNotFound
+	}
+	sort.Slice(first, func(i, j int) bool { return first[i].Number < first[j].Number })
+	return first[0], nil
+}
+
+// etherscanRPCTx, etherscanReceipt and etherscanBlock are the JSON-RPC
+// objects the proxy module returns, with quantities in hex
+type etherscanRPCTx struct {
+	Hash        string `json:"hash"`
+	BlockNumber string `json:"blockNumber"`
+	From        string `json:"from"`
+	To          string `json:"to"`
+	Value       string `json:"value"`
+}
+
+type etherscanReceipt struct {
+	Status          string `json:"status"`
+	ContractAddress string `json:"contractAddress"`
+	Logs            []struct {
+		Address  string   `json:"address"`
+		Topics   []string `json:"topics"`
+		Data     string   `json:"data"`
+		LogIndex string   `json:"logIndex"`
+	} `json:"logs"`
+}
+
+type etherscanBlock struct {
+	Timestamp string `json:"timestamp"`
+}
+
+// Transaction combines the transaction, its receipt for the status and logs,
+// and its block for the time
+func (c *EtherscanClient) Transaction(ctx context.Context, hash string) (*ChainTransaction, error) {
+	if hash == "" {
+		return nil, ErrEmptyTransaction
+	}
+
+	var tx etherscanRPCTx
+	if err := c.call(ctx, "proxy", "eth_getTransactionByHash", url.Values{"txhash": {hash}}, &tx); err != nil {
+		return nil, fmt.Errorf("failed to get transaction: %w", err)
+	}
+	if tx.BlockNumber == "" {
+		// Still in the mempool
+		return nil, ErrNotFound
+	}
+
+	var receipt etherscanReceipt
+	if err := c.call(ctx, "proxy", "eth_getTransactionReceipt", url.Values{"txhash": {hash}}, &receipt); err != nil {
+		return nil, fmt.Errorf("failed to get transaction receipt: %w", err)
+	}
+
+	var block etherscanBlock
+	params := url.Values{"tag": {tx.BlockNumber}, "boolean": {"false"}}
+	if err := c.call(ctx, "proxy", "eth_getBlockByNumber", params, &block); err != nil {
+		return nil, fmt.Errorf("failed to get block: %w", err)
+	}
+
+	number, err := parseHexUint(tx.BlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	seconds, err := parseHexUint(block.Timestamp)
+	if err != nil {
+		return nil, err
+	}
+	value, err := scaleAmount(tx.Value, etherDecimals)
+	if err != nil {
+		return nil, err
+	}
+	to := tx.To
+	if to == "" {
+		to = receipt.ContractAddress
+	}
+
+	result := &ChainTransaction{
+		Hash:    tx.Hash,
+		Block:   Block{Number: number, Time: time.Unix(int64(seconds), 0).UTC()},
+		Inputs:  []TxLeg{{Address: tx.From, Value: value}},
+		Outputs: []TxLeg{{Address: to, Value: value}},
+		Failed:  receipt.Status == "0x0",
+	}
+	for _, log := range receipt.Logs {
+		index, err := parseHexUint(log.LogIndex)
+		if err != nil {
+			return nil, err
+		}
+		result.Logs = append(result.Logs, EventLog{
+			Index:   int(index),
+			Address: log.Address,
+			Topics:  log.Topics,
+			Data:    log.Data,
+		})
+	}
+	return result, nil
+}
+
+func parseHexUint(s string) (uint64, error) {
+	n, err := strconv.ParseUint(strings.TrimPrefix(s, "0x"), 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid hex quantity %q", s)
+	}
+	return n, nil
+}
@@ -0,0 +1,167 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// etherscanStub answers like an Etherscan-compatible API, keyed by action
+func etherscanStub(t *testing.T, answers map[string]string) *EtherscanClient {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("apikey") != "key" || query.Get("chainid") != "1" {
+			t.Errorf("unexpected query %s", r.URL.RawQuery)
+		}
+		key := query.Get("action")
+		if sort := query.Get("sort"); sort != "" {
+			key += ":" + sort
+		}
+		answer, ok := answers[key]
+		if !ok {
+			t.Errorf("unexpected request %s", r.URL.RawQuery)
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(answer))
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewEtherscanClient(ChainEthereum, server.URL+"/api")
+	client.SetAPIKey("key")
+	client.SetChainID(1)
+	return client
+}
+
+func TestEtherscanTransactions(t *testing.T) {
+	client := etherscanStub(t, map[string]string{
+		"txlist:desc": `{"status":"1","message":"OK","result":[
+			{"blockNumber":"19000001","timeStamp":"1705000000","hash":"0xaa","from":"0x01","to":"0x02","value":"1500000000000000000","isError":"0"},
+			{"blockNumber":"19000000","timeStamp":"1704990000","hash":"0xbb","from":"0x02","to":"","contractAddress":"0x03","value":"0","isError":"1"}
+		]}`,
+		"tokentx:desc": `{"status":"1","message":"OK","result":[
+			{"blockNumber":"19000002","timeStamp":"1705000100","hash":"0xcc","from":"0x02","to":"0x04","value":"2500000","contractAddress":"0xdac17f958d2ee523a2206206994597c13d831ec7","tokenSymbol":"USDT","tokenDecimal":"6","logIndex":"7"}
+		]}`,
+	})
+
+	txs, err := client.Transactions(context.Background(), "0x02", 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(txs) != 2 {
+		t.Fatalf("got %d transactions, want 2", len(txs))
+	}
+	first := txs[0]
+	if first.Hash != "0xaa" || first.Block.Number != 19000001 || !first.Block.Time.Equal(time.Unix(1705000000, 0)) {
+		t.Errorf("unexpected transaction %+v", first)
+	}
+	if first.Inputs[0] != (TxLeg{Address: "0x01", Value: 1.5}) || first.Outputs[0] != (TxLeg{Address: "0x02", Value: 1.5}) {
+		t.Errorf("unexpected legs %+v -> %+v", first.Inputs, first.Outputs)
+	}
+	if !txs[1].Failed || txs[1].Outputs[0].Address != "0x03" {
+		t.Errorf("expected a failed contract deployment, got %+v", txs[1])
+	}
+
+	transfers, err := client.TokenTransfers(context.Background(), "0x02", 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := TokenTransfer{
+		TxHash:   "0xcc",
+		LogIndex: 7,
+		Block:    Block{Number: 19000002, Time: time.Unix(1705000100, 0).UTC()},
+		Token:    "0xdac17f958d2ee523a2206206994597c13d831ec7",
+		Symbol:   "USDT",
+		From:     "0x02",
+		To:       "0x04",
+		Value:    2.5,
+	}
+	if len(transfers) != 1 || transfers[0] != want {
+		t.Errorf("TokenTransfers() = %+v, want %+v", transfers, want)
+	}
+}
+
+func TestEtherscanFirstSeen(t *testing.T) {
+	client := etherscanStub(t, map[string]string{
+		"txlist:asc":  `{"status":"1","message":"OK","result":[{"blockNumber":"150","timeStamp":"1500","hash":"0x1","value":"0"}]}`,
+		"tokentx:asc": `{"status":"1","message":"OK","result":[{"blockNumber":"120","timeStamp":"1200","hash":"0x2","value":"0","tokenDecimal":"0"}]}`,
+	})
+	block, err := client.FirstSeen(context.Background(), "0x02")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if block.Number != 120 || !block.Time.Equal(time.Unix(1200, 0)) {
+		t.Errorf("FirstSeen() = %+v, want block 120", block)
+	}
+
+	unused := etherscanStub(t, map[string]string{
+		"txlist:asc":  `{"status":"0","message":"No transactions found","result":[]}`,
+		"tokentx:asc": `{"status":"0","message":"No transactions found","result":[]}`,
+	})
+	if _, err := unused.FirstSeen(context.Background(), "0x05"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestEtherscanTransaction(t *testing.T) {
+	client := etherscanStub(t, map[string]string{
+		"eth_getTransactionByHash":  `{"jsonrpc":"2.0","id":1,"result":{"hash":"0xaa","blockNumber":"0x10","from":"0x01","to":"0xdac17f958d2ee523a2206206994597c13d831ec7","value":"0x0"}}`,
+		"eth_getTransactionReceipt": `{"jsonrpc":"2.0","id":1,"result":{"status":"0x1","logs":[{"address":"0xdac17f958d2ee523a2206206994597c13d831ec7","topics":["0xddf252ad"],"data":"0x01","logIndex":"0x3"}]}}`,
+		"eth_getBlockByNumber":      `{"jsonrpc":"2.0","id":1,"result":{"timestamp":"0x64"}}`,
+	})
+
+	tx, err := client.Transaction(context.Background(), "0xaa")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tx.Block.Number != 16 || !tx.Block.Time.Equal(time.Unix(100, 0)) || tx.Failed {
+		t.Errorf("unexpected transaction %+v", tx)
+	}
+	if len(tx.Logs) != 1 || tx.Logs[0].Index != 3 || tx.Logs[0].Topics[0] != "0xddf252ad" {
+		t.Errorf("unexpected logs %+v", tx.Logs)
+	}
+
+	missing := etherscanStub(t, map[string]string{
+		"eth_getTransactionByHash": `{"jsonrpc":"2.0","id":1,"result":null}`,
+	})
+	if _, err := missing.Transaction(context.Background(), "0xff"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestEtherscanErrors(t *testing.T) {
+	client := etherscanStub(t, map[string]string{
+		"txlist:desc": `{"status":"0","message":"NOTOK","result":"Invalid API Key"}`,
+	})
+	_, err := client.Transactions(context.Background(), "0x02", 10)
+	if err == nil || err.Error() != "failed to list transactions: etherscan: Invalid API Key" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestScaleAmount(t *testing.T) {
+	tests := []struct {
+		amount   string
+		decimals int
+		want     float64
+	}{
+		{"1000000000000000000", 18, 1},
+		{"0x0de0b6b3a7640000", 18, 1},
+		{"2500000", 6, 2.5},
+		{"0x", 18, 0},
+		{"42", 0, 42},
+	}
+	for _, tt := range tests {
+		got, err := scaleAmount(tt.amount, tt.decimals)
+		if err != nil || got != tt.want {
+			t.Errorf("scaleAmount(%q, %d) = %v, %v, want %v", tt.amount, tt.decimals, got, err, tt.want)
+		}
+	}
+	if _, err := scaleAmount("12abc", 0); err == nil {
+		t.Error("expected an error for an invalid amount")
+	}
+}
@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Severity ranks how serious an AML category is
+type Severity int
+
+const (
+	SeverityLow Severity = iota
+	SeverityMedium
+	SeverityHigh
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityHigh:
+		return "high"
+	case SeverityMedium:
+		return "medium"
+	default:
+		return "low"
+	}
+}
+
+// categorySeverities lists the provider categories that are more than low risk
+var categorySeverities = map[string]Severity{
+	"sanctions":          SeverityHigh,
+	"terrorism":          SeverityHigh,
+	"ransomware":         SeverityHigh,
+	"darknet":            SeverityHigh,
+	"darknet_market":     SeverityHigh,
+	"child_abuse":        SeverityHigh,
+	"stolen_funds":       SeverityHigh,
+	"hack":               SeverityHigh,
+	"scam":               SeverityHigh,
+	"phishing":           SeverityHigh,
+	CategoryIssuerFrozen: SeverityHigh,
+	"mixer":              SeverityMedium,
+	"gambling":           SeverityMedium,
+	"high_risk_exchange": SeverityMedium,
+	"p2p_exchange":       SeverityMedium,
+	"fraud":              SeverityMedium,
+}
+
+// CategorySeverity returns the severity of a provider category. Unknown
+// categories are low.
+func CategorySeverity(category string) Severity {
+	key := strings.ReplaceAll(strings.ToLower(strings.TrimSpace(category)), " ", "_")
+	return categorySeverities[key]
+}
+
+// explorer is a block explorer with URL prefixes for addresses and
+// transactions
+type explorer struct {
+	name        string
+	address     string
+	transaction string
+}
+
+var explorers = map[Chain]explorer{
+	ChainBitcoin:  {"mempool.space", "https://mempool.space/address/", "https://mempool.space/tx/"},
+	ChainEthereum: {"Etherscan", "https://etherscan.io/address/", "https://etherscan.io/tx/"},
+	ChainTron:     {"Tronscan", "https://tronscan.org/#/address/", "https://tronscan.org/#/transaction/"},
+	ChainSolana:   {"Solscan", "https://solscan.io/account/", "https://solscan.io/tx/"},
+}
+
+// Explorer returns the name of a block explorer and the target's page on it.
+// It returns false when the chain is unknown.
+func (t Target) Explorer() (name, link string, ok bool) {
+	e, ok := explorers[t.Chain]
+	if !ok || t.Value == "" {
+		return "", "", false
+	}
+	prefix := e.address
+	if t.Kind == KindTransaction {
+		prefix = e.transaction
+	}
+	return e.name, prefix + url.PathEscape(t.Value), true
+}
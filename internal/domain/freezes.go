@@ -0,0 +1,183 @@
+package domain
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// freezeSelectors are the 4-byte selectors of the freeze view functions,
+// the start of the Keccak-256 hash of their signatures
+var freezeSelectors = map[string]string{
+	freezeTether: "e47d6060",
+	freezeCircle: "fe575a87",
+}
+
+// errUnsupportedChain is returned for chains without a configured endpoint
+var errUnsupportedChain = errors.New("no endpoint for chain")
+
+// FreezeClient asks stablecoin contracts whether their issuer froze an
+// address, by calling their blacklist view functions through an EVM
+// JSON-RPC endpoint per chain and the TRON HTTP API
+type FreezeClient struct {
+	client     *http.Client
+	rpc        map[Chain]string
+	tronURL    string
+	tronAPIKey string
+}
+
+func NewFreezeClient() *FreezeClient {
+	return &FreezeClient{
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		rpc: make(map[Chain]string),
+	}
+}
+
+func (c *FreezeClient) Name() string {
+	return "freezes"
+}
+
+// SetRPC sets the JSON-RPC endpoint of an EVM chain
+func (c *FreezeClient) SetRPC(chain Chain, url string) {
+	c.rpc[chain] = url
+}
+
+// SetTron sets the TRON HTTP API, such as TronGrid, and its API key
+func (c *FreezeClient) SetTron(baseURL, apiKey string) {
+	c.tronURL = strings.TrimRight(baseURL, "/")
+	c.tronAPIKey = apiKey
+}
+
+// Supports tells whether freezes can be looked up on the chain
+func (c *FreezeClient) Supports(chain Chain) bool {
+	if chain == ChainTron {
+		return c.tronURL != ""
+	}
+	return c.rpc[chain] != ""
+}
+
+// Frozen tells whether the issuer of token froze address. Tokens without a
+// known freeze function are never frozen.
+func (c *FreezeClient) Frozen(ctx context.Context, chain Chain, token, address string) (bool, error) {
+	known, ok := KnownToken(chain, token)
+	if !ok || known.FreezeMethod == "" {
+		return false, nil
+	}
+	if !c.Supports(chain) {
+		return false, errUnsupportedChain
+	}
+
+	raw, ok := addressBytes(chain, address)
+	if !ok {
+		// Addresses of another chain cannot be frozen on this one
+		return false, nil
+	}
+	argument := strings.Repeat("0", 24) + hex.EncodeToString(raw)
+
+	var result string
+	var err error
+	if chain == ChainTron {
+		result, err = c.callTron(ctx, token, address, known.FreezeMethod, argument)
+	} else {
+		result, err = c.callEVM(ctx, c.rpc[chain], strings.ToLower(token), freezeSelectors[known.FreezeMethod]+argument)
+	}
+	if err != nil {
+		return false, fmt.Errorf("%s %s: %w", known.Symbol, known.FreezeMethod, err)
+	}
+	return decodeBool(result)
+}
+
+// callEVM runs eth_call against the latest block
+func (c *FreezeClient) callEVM(ctx context.Context, url, contract, data string) (string, error) {
+	request := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "eth_call",
+		"params": []interface{}{
+			map[string]string{"to": contract, "data": "0x" + data},
+			"latest",
+		},
+	}
+	var response struct {
+		Result string `json:"result"`
+		Error  *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := postJSON(ctx, c.client, url, nil, request, &response); err != nil {
+		return "", err
+	}
+	if response.Error != nil {
+		return "", fmt.Errorf("eth_call: %s", response.Error.Message)
+	}
+	return response.Result, nil
+}
+
+// callTron runs a constant contract call, the TRON equivalent of eth_call.
+// The address being checked doubles as the caller.
+func (c *FreezeClient) callTron(ctx context.Context, contract, owner, method, parameter string) (string, error) {
+	request := map[string]interface{}{
+		"owner_address":     owner,
+		"contract_address":  contract,
+		"function_selector": method,
+		"parameter":         parameter,
+		"visible":           true,
+	}
+	var header http.Header
+	if c.tronAPIKey != "" {
+		header = http.Header{"TRON-PRO-API-KEY": {c.tronAPIKey}}
+	}
+	var response struct {
+		ConstantResult []string `json:"constant_result"`
+		Result         struct {
+			Result  bool   `json:"result"`
+			Message string `json:"message"`
+		} `json:"result"`
+	}
+	if err := postJSON(ctx, c.client, c.tronURL+"/wallet/triggerconstantcontract", header, request, &response); err != nil {
+		return "", err
+	}
+	if !response.Result.Result || len(response.ConstantResult) == 0 {
+		// TronGrid hex-encodes its error messages
+		message := response.Result.Message
+		if decoded, err := hex.DecodeString(message); err == nil {
+			message = string(decoded)
+		}
+		return "", fmt.Errorf("triggerconstantcontract: %s", message)
+	}
+	return response.ConstantResult[0], nil
+}
+
+// addressBytes decodes the 20 bytes of an EVM or TRON address
+func addressBytes(chain Chain, address string) ([]byte, bool) {
+	if chain == ChainTron {
+		return tronRaw(address)
+	}
+	address = strings.TrimPrefix(strings.ToLower(address), "0x")
+	raw, err := hex.DecodeString(address)
+	if err != nil || len(raw) != 20 {
+		return nil, false
+	}
+	return raw, true
+}
+
+// decodeBool reads an ABI-encoded bool. An empty result means the contract
+// has no such function.
+func decodeBool(result string) (bool, error) {
+	result = normalizeHex(result)
+	if len(result) != 64 {
+		return false, fmt.Errorf("unexpected result %q", result)
+	}
+	n, ok := new(big.Int).SetString(result, 16)
+	if !ok {
+		return false, fmt.Errorf("unexpected result %q", result)
+	}
+	return n.Sign() != 0, nil
+}
@@ -0,0 +1,132 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const (
+	usdtContract = "0xdac17f958d2ee523a2206206994597c13d831ec7"
+	frozenEVM    = "0x00000000000000000000000000000000000000f1"
+	cleanEVM     = "0x00000000000000000000000000000000000000c1"
+	tronUSDT     = "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t"
+	// tronFrozen holds the same 20 bytes as frozenEVM
+	tronFrozen = "T9yD14Nj9j7xAB4dbGeiX9h8unknQZuo4w"
+)
+
+// abiBool encodes a bool as a contract returns it
+func abiBool(b bool) string {
+	if b {
+		return strings.Repeat("0", 63) + "1"
+	}
+	return strings.Repeat("0", 64)
+}
+
+// rpcStub answers eth_call like an EVM JSON-RPC node whose USDT contract
+// froze frozenEVM
+func rpcStub(t *testing.T) string {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Method != "eth_call" || len(request.Params) != 2 {
+			t.Errorf("unexpected request %+v (%v)", request, err)
+			return
+		}
+		var call struct {
+			To   string `json:"to"`
+			Data string `json:"data"`
+		}
+		_ = json.Unmarshal(request.Params[0], &call)
+		if call.To != usdtContract || !strings.HasPrefix(call.Data, "0xe47d6060") {
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"execution reverted"}}`))
+			return
+		}
+		frozen := strings.HasSuffix(call.Data, strings.TrimPrefix(frozenEVM, "0x"))
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"0x` + abiBool(frozen) + `"}`))
+	}))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+// tronStub answers triggerconstantcontract like TronGrid whose USDT contract
+// froze tronFrozen
+func tronStub(t *testing.T) string {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/wallet/triggerconstantcontract" || r.Header.Get("TRON-PRO-API-KEY") != "key" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		var request struct {
+			Contract  string `json:"contract_address"`
+			Selector  string `json:"function_selector"`
+			Parameter string `json:"parameter"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		if request.Contract != tronUSDT || request.Selector != "isBlackListed(address)" {
+			t.Errorf("unexpected call %+v", request)
+		}
+		frozen := strings.HasSuffix(request.Parameter, strings.TrimPrefix(frozenEVM, "0x"))
+		_, _ = w.Write([]byte(`{"result":{"result":true},"constant_result":["` + abiBool(frozen) + `"]}`))
+	}))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func TestFreezeClient(t *testing.T) {
+	client := NewFreezeClient()
+	client.SetRPC(ChainEthereum, rpcStub(t))
+	client.SetTron(tronStub(t), "key")
+
+	tests := []struct {
+		name    string
+		chain   Chain
+		token   string
+		address string
+		want    bool
+		wantErr bool
+	}{
+		{"frozen on Ethereum", ChainEthereum, usdtContract, frozenEVM, true, false},
+		{"contract case ignored", ChainEthereum, "0x" + strings.ToUpper(usdtContract[2:]), frozenEVM, true, false},
+		{"clean on Ethereum", ChainEthereum, usdtContract, cleanEVM, false, false},
+		{"frozen on TRON", ChainTron, tronUSDT, tronFrozen, true, false},
+		{"clean on TRON", ChainTron, tronUSDT, "TLZVYZskxoJt4M4bYHPJg5BzHr73oZRZzc", false, false},
+		{"token without freezes", ChainEthereum, "0x6b175474e89094c44da98b954eedeac495271d0f", frozenEVM, false, false},
+		{"address of another chain", ChainEthereum, usdtContract, tronFrozen, false, false},
+		{"node error", ChainEthereum, "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48", frozenEVM, false, true},
+		{"unknown chain", "BSC", usdtContract, frozenEVM, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := client.Frozen(context.Background(), tt.chain, tt.token, tt.address)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Frozen() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Frozen() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if client.Supports(ChainBitcoin) || !client.Supports(ChainTron) {
+		t.Error("expected support for the configured chains only")
+	}
+}
+
+func TestTronRaw(t *testing.T) {
+	raw, ok := tronRaw(tronFrozen)
+	if !ok || tronBase58(raw) != tronFrozen {
+		t.Fatalf("tronRaw(%s) did not round-trip", tronFrozen)
+	}
+	// A changed character breaks the checksum
+	if _, ok := tronRaw("T9yD14Nj9j7xAB4dbGeiX9h8unknQZuo4x"); ok {
+		t.Error("expected a checksum error")
+	}
+}
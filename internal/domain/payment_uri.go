@@ -0,0 +1,250 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrNotPaymentURI = errors.New("not a payment URI")
+	ErrInvalidURI    = errors.New("invalid payment URI")
+)
+
+var solanaAddressPattern = regexp.MustCompile(`^[1-9A-HJ-NP-Za-km-z]{32,44}$`)
+
+// PaymentRequest is the structured content of a payment URI: BIP-21
+// (bitcoin:), EIP-681 (ethereum:), TRON (tron:) or Solana Pay (solana:)
+type PaymentRequest struct {
+	Scheme string
+	Chain  Chain
+	// ChainID is the EIP-155 network id of an ethereum: URI, if given.
+	// Only Ethereum mainnet is accepted.
+	ChainID string
+	// Address is the recipient of the payment
+	Address string
+	// TokenContract is the token being sent, empty for the native coin
+	TokenContract string
+	// Amount is in whole coins or tokens, empty when unknown
+	Amount string
+	// Unit names what Amount is counted in (BTC, ETH, a token symbol),
+	// empty for tokens the bot does not know
+	Unit string
+	Memo string
+}
+
+// Target returns the recipient as a screening target
+func (p *PaymentRequest) Target() Target {
+	return Target{Value: p.Address, Kind: KindAddress, Chain: p.Chain}
+}
+
+// TokenTarget returns the token contract as a screening target
+func (p *PaymentRequest) TokenTarget() (Target, bool) {
+	if p.TokenContract == "" {
+		return Target{}, false
+	}
+	return Target{Value: p.TokenContract, Kind: KindAddress, Chain: p.Chain}, true
+}
+
+// ParsePaymentURI parses a payment URI. It returns ErrNotPaymentURI for
+// input without a known scheme and ErrInvalidURI for malformed URIs.
+func ParsePaymentURI(raw string) (*PaymentRequest, error) {
+	raw = strings.TrimSpace(raw)
+	scheme, rest, ok := strings.Cut(raw, ":")
+	if !ok {
+		return nil, ErrNotPaymentURI
+	}
+
+	switch strings.ToLower(scheme) {
+	case "bitcoin":
+		return parseBIP21(rest)
+	case "ethereum":
+		return parseEIP681(rest)
+	case "tron":
+		return parseTronURI(rest)
+	case "solana":
+		return parseSolanaPay(rest)
+	}
+	return nil, ErrNotPaymentURI
+}
+
+func splitURI(rest string) (string, url.Values, error) {
+	path, query, _ := strings.Cut(strings.TrimPrefix(rest, "//"), "?")
+	params, err := url.ParseQuery(query)
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: %v", ErrInvalidURI, err)
+	}
+	return path, params, nil
+}
+
+func parseBIP21(rest string) (*PaymentRequest, error) {
+	address, params, err := splitURI(rest)
+	if err != nil {
+		return nil, err
+	}
+	if target, ok := ParseTarget(address); !ok || target.Chain != ChainBitcoin {
+		return nil, fmt.Errorf("%w: bad bitcoin address %q", ErrInvalidURI, address)
+	}
+
+	return &PaymentRequest{
+		Scheme:  "bitcoin",
+		Chain:   ChainBitcoin,
+		Address: address,
+		Amount:  params.Get("amount"),
+		Unit:    "BTC",
+		Memo:    params.Get("message"),
+	}, nil
+}
+
+// ethereumMainnetID is the EIP-155 id of the only EVM chain screened
+const ethereumMainnetID = "1"
+
+// parseEIP681 handles both plain transfers (ethereum:<to>?value=…) and token
+// transfers (ethereum:<token>/transfer?address=<to>&uint256=…). Amounts in
+// these URIs are in wei or token base units and are scaled to whole coins.
+func parseEIP681(rest string) (*PaymentRequest, error) {
+	path, params, err := splitURI(strings.TrimPrefix(rest, "pay-"))
+	if err != nil {
+		return nil, err
+	}
+
+	path, function, _ := strings.Cut(path, "/")
+	address, chainID, _ := strings.Cut(path, "@")
+	if !evmAddressPattern.MatchString(address) {
+		return nil, fmt.Errorf("%w: bad ethereum address %q", ErrInvalidURI, address)
+	}
+	// Addresses on other EVM chains look the same but would be screened
+	// as Ethereum mainnet ones, so refuse them rather than mislabel them
+	if chainID != "" && chainID != ethereumMainnetID {
+		return nil, fmt.Errorf("%w: unsupported chain id %q, only Ethereum mainnet (%s) is screened",
+			ErrInvalidURI, chainID, ethereumMainnetID)
+	}
+
+	request := &PaymentRequest{
+		Scheme:  "ethereum",
+		Chain:   ChainEthereum,
+		ChainID: chainID,
+		Address: address,
+	}
+
+	switch function {
+	case "":
+		request.Unit = "ETH"
+		if request.Amount, err = wholeUnits(params.Get("value"), etherDecimals); err != nil {
+			return nil, err
+		}
+	case "transfer":
+		recipient := params.Get("address")
+		if !evmAddressPattern.MatchString(recipient) {
+			return nil, fmt.Errorf("%w: bad transfer recipient %q", ErrInvalidURI, recipient)
+		}
+		request.TokenContract = address
+		request.Address = recipient
+		// The decimals of an unknown token are unknown too, so its
+		// amount is left empty rather than guessed
+		if token, ok := KnownToken(ChainEthereum, address); ok {
+			request.Unit = token.Symbol
+			if request.Amount, err = wholeUnits(params.Get("uint256"), token.Decimals); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, fmt.Errorf("%w: unsupported function %q", ErrInvalidURI, function)
+	}
+	return request, nil
+}
+
+// wholeUnits converts an amount in base units, which EIP-681 allows in
+// scientific notation (2.014e18), to a decimal in whole units
+func wholeUnits(amount string, decimals int) (string, error) {
+	if amount == "" {
+		return "", nil
+	}
+	value, ok := new(big.Float).SetPrec(256).SetString(amount)
+	if !ok || value.Sign() < 0 {
+		return "", fmt.Errorf("%w: bad amount %q", ErrInvalidURI, amount)
+	}
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	value.Quo(value, new(big.Float).SetPrec(256).SetInt(scale))
+	f, _ := value.Float64()
+	return strconv.FormatFloat(f, 'f', -1, 64), nil
+}
+
+func parseTronURI(rest string) (*PaymentRequest, error) {
+	address, params, err := splitURI(rest)
+	if err != nil {
+		return nil, err
+	}
+	if !tronAddressPattern.MatchString(address) {
+		return nil, fmt.Errorf("%w: bad tron address %q", ErrInvalidURI, address)
+	}
+
+	token := params.Get("token")
+	if token == "" {
+		token = params.Get("contract")
+	}
+	if token != "" && !tronAddressPattern.MatchString(token) {
+		return nil, fmt.Errorf("%w: bad token contract %q", ErrInvalidURI, token)
+	}
+
+	memo := params.Get("memo")
+	if memo == "" {
+		memo = params.Get("message")
+	}
+
+	return &PaymentRequest{
+		Scheme:        "tron",
+		Chain:         ChainTron,
+		Address:       address,
+		TokenContract: token,
+		Amount:        params.Get("amount"),
+		Unit:          nativeOrTokenUnit(ChainTron, "TRX", token),
+		Memo:          memo,
+	}, nil
+}
+
+func parseSolanaPay(rest string) (*PaymentRequest, error) {
+	address, params, err := splitURI(rest)
+	if err != nil {
+		return nil, err
+	}
+	if !solanaAddressPattern.MatchString(address) {
+		return nil, fmt.Errorf("%w: bad solana address %q", ErrInvalidURI, address)
+	}
+
+	token := params.Get("spl-token")
+	if token != "" && !solanaAddressPattern.MatchString(token) {
+		return nil, fmt.Errorf("%w: bad token mint %q", ErrInvalidURI, token)
+	}
+
+	memo := params.Get("memo")
+	if memo == "" {
+		memo = params.Get("message")
+	}
+
+	return &PaymentRequest{
+		Scheme:        "solana",
+		Chain:         ChainSolana,
+		Address:       address,
+		TokenContract: token,
+		Amount:        params.Get("amount"),
+		Unit:          nativeOrTokenUnit(ChainSolana, "SOL", token),
+		Memo:          memo,
+	}, nil
+}
+
+// nativeOrTokenUnit names the unit of a TRON or Solana Pay amount, which
+// those URIs already give in whole coins or tokens
+func nativeOrTokenUnit(chain Chain, native, token string) string {
+	if token == "" {
+		return native
+	}
+	if known, ok := KnownToken(chain, token); ok {
+		return known.Symbol
+	}
+	return ""
+}
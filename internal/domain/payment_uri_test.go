@@ -0,0 +1,93 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParsePaymentURITokens(t *testing.T) {
+	cases := []struct {
+		input   string
+		chain   Chain
+		address string
+		token   string
+		amount  string
+		memo    string
+	}{
+		{
+			"ethereum:0xdAC17F958D2ee523a2206206994597C13D831ec7@1/transfer?address=0x742d35Cc6634C0532925a3b844Bc454e4438f44e&uint256=1000000",
+			ChainEthereum, "0x742d35Cc6634C0532925a3b844Bc454e4438f44e", "0xdAC17F958D2ee523a2206206994597C13D831ec7", "1", "",
+		},
+		{
+			"tron:TQn9Y2khEsLJW1ChVWFMSMeRDow5KcbLSE?token=TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t&amount=25&memo=order-42",
+			ChainTron, "TQn9Y2khEsLJW1ChVWFMSMeRDow5KcbLSE", "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t", "25", "order-42",
+		},
+		{
+			"solana:mvines9iiHiQTysrwkJjGf2gb9Ex9jXJX8ns3qwf2kN?amount=1&spl-token=EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v&memo=OrderId5678",
+			ChainSolana, "mvines9iiHiQTysrwkJjGf2gb9Ex9jXJX8ns3qwf2kN", "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v", "1", "OrderId5678",
+		},
+	}
+
+	for _, tc := range cases {
+		request, err := ParsePaymentURI(tc.input)
+		if err != nil {
+			t.Errorf("ParsePaymentURI(%q): unexpected error: %v", tc.input, err)
+			continue
+		}
+		if request.Chain != tc.chain || request.Address != tc.address || request.TokenContract != tc.token ||
+			request.Amount != tc.amount || request.Memo != tc.memo {
+			t.Errorf("ParsePaymentURI(%q): got %+v", tc.input, request)
+		}
+		if token, ok := request.TokenTarget(); !ok || token.Value != tc.token {
+			t.Errorf("ParsePaymentURI(%q): unexpected token target %+v", tc.input, token)
+		}
+	}
+
+	if _, err := ParsePaymentURI("ethereum:0xdAC17F958D2ee523a2206206994597C13D831ec7/approve?address=0x742d35Cc6634C0532925a3b844Bc454e4438f44e"); !errors.Is(err, ErrInvalidURI) {
+		t.Errorf("expected ErrInvalidURI for unsupported function, got %v", err)
+	}
+	for _, input := range []string{
+		"ethereum:0x742d35Cc6634C0532925a3b844Bc454e4438f44e@56?value=1e18",
+		"ethereum:0xdAC17F958D2ee523a2206206994597C13D831ec7@137/transfer?address=0x742d35Cc6634C0532925a3b844Bc454e4438f44e&uint256=1",
+	} {
+		if _, err := ParsePaymentURI(input); !errors.Is(err, ErrInvalidURI) {
+			t.Errorf("ParsePaymentURI(%q): expected ErrInvalidURI for a non-mainnet chain, got %v", input, err)
+		}
+	}
+}
+
+func TestParsePaymentURI(t *testing.T) {
+	cases := []struct {
+		input   string
+		chain   Chain
+		address string
+		amount  string
+		unit    string
+	}{
+		{"bitcoin:bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq?amount=0.1&label=shop", ChainBitcoin, "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", "0.1", "BTC"},
+		{"BITCOIN:1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", ChainBitcoin, "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", "", "BTC"},
+		{"ethereum:0x742d35Cc6634C0532925a3b844Bc454e4438f44e@1?value=2.014e18", ChainEthereum, "0x742d35Cc6634C0532925a3b844Bc454e4438f44e", "2.014", "ETH"},
+		{"ethereum:0x742d35Cc6634C0532925a3b844Bc454e4438f44e?value=1500000000000000000", ChainEthereum, "0x742d35Cc6634C0532925a3b844Bc454e4438f44e", "1.5", "ETH"},
+		{"ethereum:0x6B175474E89094C44Da98b954EedeAC495271d0F/transfer?address=0x742d35Cc6634C0532925a3b844Bc454e4438f44e&uint256=2.5e19", ChainEthereum, "0x742d35Cc6634C0532925a3b844Bc454e4438f44e", "25", "DAI"},
+		// An unknown token's decimals are unknown, so is its amount
+		{"ethereum:0x1111111111111111111111111111111111111111/transfer?address=0x742d35Cc6634C0532925a3b844Bc454e4438f44e&uint256=1000000", ChainEthereum, "0x742d35Cc6634C0532925a3b844Bc454e4438f44e", "", ""},
+	}
+
+	for _, tc := range cases {
+		request, err := ParsePaymentURI(tc.input)
+		if err != nil {
+			t.Errorf("ParsePaymentURI(%q): unexpected error: %v", tc.input, err)
+			continue
+		}
+		if request.Chain != tc.chain || request.Address != tc.address || request.Amount != tc.amount || request.Unit != tc.unit {
+			t.Errorf("ParsePaymentURI(%q): got %+v", tc.input, request)
+		}
+	}
+
+	if _, err := ParsePaymentURI("0x742d35Cc6634C0532925a3b844Bc454e4438f44e"); !errors.Is(err, ErrNotPaymentURI) {
+		t.Errorf("expected ErrNotPaymentURI, got %v", err)
+	}
+	if _, err := ParsePaymentURI("bitcoin:not-an-address"); !errors.Is(err, ErrInvalidURI) {
+		t.Errorf("expected ErrInvalidURI, got %v", err)
+	}
+}
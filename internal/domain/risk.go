@@ -0,0 +1,83 @@
+package domain
+
+// RiskFactorKind names a source of risk the score is made of
+type RiskFactorKind string
+
+const (
+	// FactorDirect is what providers report about the target itself
+	FactorDirect RiskFactorKind = "direct"
+	// FactorExposure is funds received from risky counterparties
+	FactorExposure RiskFactorKind = "exposure"
+	// FactorSanctions is a hit on a sanctions list
+	FactorSanctions RiskFactorKind = "sanctions"
+	// FactorBlocklist is a hit on our own blocklist
+	FactorBlocklist RiskFactorKind = "blocklist"
+	// FactorAge is the risk of a freshly created address
+	FactorAge RiskFactorKind = "age"
+	// FactorTransfers is the riskiest token transfer of a transaction
+	FactorTransfers RiskFactorKind = "transfers"
+)
+
+// RiskFactor is one factor's share of a risk score. Signal is how strongly
+// the factor is present, between 0 and 1, and Contribution is Signal scaled
+// by the factor's Weight. Sources say where the signal came from.
+type RiskFactor struct {
+	Kind         RiskFactorKind `json:"kind"`
+	Weight       float64        `json:"weight"`
+	Signal       float64        `json:"signal"`
+	Contribution float64        `json:"contribution"`
+	Sources      []string       `json:"sources,omitempty"`
+	// Unknown is set when no data source could tell
+	Unknown bool `json:"unknown,omitempty"`
+}
+
+// categorySignal is how much a category of the given severity counts
+// towards exposure
+var categorySignal = map[Severity]float64{
+	SeverityHigh:   1,
+	SeverityMedium: 0.5,
+	SeverityLow:    0,
+}
+
+// ExposureSignal weighs the shares of funds by the severity of their
+// category, between 0 and 1
+func ExposureSignal(exposure map[string]float64) float64 {
+	var signal float64
+	for category, share := range exposure {
+		signal += share * categorySignal[CategorySeverity(category)]
+	}
+	if signal > 1 {
+		signal = 1
+	}
+	return signal
+}
+
+// CategoryExposure is the share of an address's funds traced to a category
+// through the transaction graph, and how many hops away the closest
+// address of that category is
+type CategoryExposure struct {
+	Category string  `json:"category"`
+	Share    float64 `json:"share"`
+	Hops     int     `json:"hops"`
+}
+
+// GraphExposure is the outcome of walking the transaction graph around an
+// address. Incoming is where its funds came from, Outgoing where they went.
+type GraphExposure struct {
+	Hops     int                `json:"hops"`
+	Incoming []CategoryExposure `json:"incoming,omitempty"`
+	Outgoing []CategoryExposure `json:"outgoing,omitempty"`
+	// Addresses is how many addresses were looked at. Truncated is set when
+	// the walk stopped at its address limit before reaching every hop.
+	Addresses int  `json:"addresses"`
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// Shares returns the exposures as a map from category to share
+func Shares(exposures []CategoryExposure) map[string]float64 {
+	shares := make(map[string]float64, len(exposures))
+	for _, exposure := range exposures {
+		shares[exposure.Category] = exposure.Share
+	}
+	return shares
+}
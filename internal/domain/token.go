@@ -0,0 +1,201 @@
+package domain
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"math/big"
+	"sort"
+	"strings"
+)
+
+// TransferEventTopic is the first topic of the Transfer(address,address,uint256)
+// event that ERC-20 and TRC-20 tokens emit
+const TransferEventTopic = "ddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"
+
+// CategoryIssuerFrozen is the category of addresses a token's issuer froze,
+// such as those on Tether's blacklist
+const CategoryIssuerFrozen = "issuer_frozen"
+
+// Freeze view functions of stablecoin contracts. Tether's takes a capital L.
+const (
+	freezeTether = "isBlackListed(address)"
+	freezeCircle = "isBlacklisted(address)"
+)
+
+// Token is what we know about a token contract. FreezeMethod is the view
+// function that tells whether the issuer froze an address, if it has one.
+type Token struct {
+	Symbol       string
+	Decimals     int
+	FreezeMethod string
+}
+
+// knownTokens lists the stablecoins most payments are made in, by chain and
+// contract address. EVM addresses are in lower case; TRON's Base58 is case
+// sensitive.
+var knownTokens = map[Chain]map[string]Token{
+	ChainEthereum: {
+		"0xdac17f958d2ee523a2206206994597c13d831ec7": {Symbol: "USDT", Decimals: 6, FreezeMethod: freezeTether},
+		"0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48": {Symbol: "USDC", Decimals: 6, FreezeMethod: freezeCircle},
+		"0x6b175474e89094c44da98b954eedeac495271d0f": {Symbol: "DAI", Decimals: 18},
+	},
+	ChainTron: {
+		"TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t": {Symbol: "USDT", Decimals: 6, FreezeMethod: freezeTether},
+		"TEkxiTehnzSmSe2XqrBj4w32RUN966rdz8": {Symbol: "USDC", Decimals: 6, FreezeMethod: freezeCircle},
+	},
+}
+
+// KnownToken looks up a token contract
+func KnownToken(chain Chain, contract string) (Token, bool) {
+	if chain != ChainTron {
+		contract = strings.ToLower(contract)
+	}
+	token, ok := knownTokens[chain][contract]
+	return token, ok
+}
+
+// FreezableTokens lists the contracts of the chain's known tokens whose
+// issuer can freeze addresses
+func FreezableTokens(chain Chain) []string {
+	var contracts []string
+	for contract, token := range knownTokens[chain] {
+		if token.FreezeMethod == "" {
+			continue
+		}
+		contracts = append(contracts, contract)
+	}
+	sort.Strings(contracts)
+	return contracts
+}
+
+// DecodeTokenTransfers decodes the token Transfer events in a transaction's
+// logs. Amounts of unknown tokens are left in base units. NFT transfers,
+// which index the token ID as a fourth topic, are skipped.
+func DecodeTokenTransfers(chain Chain, tx *ChainTransaction) []TokenTransfer {
+	var transfers []TokenTransfer
+	for _, log := range tx.Logs {
+		if len(log.Topics) != 3 || normalizeHex(log.Topics[0]) != TransferEventTopic {
+			continue
+		}
+		from, ok := topicAddress(chain, log.Topics[1])
+		if !ok {
+			continue
+		}
+		to, ok := topicAddress(chain, log.Topics[2])
+		if !ok {
+			continue
+		}
+		contract, ok := contractAddress(chain, log.Address)
+		if !ok {
+			continue
+		}
+
+		token, _ := KnownToken(chain, contract)
+		value, err := scaleAmount("0x"+normalizeHex(log.Data), token.Decimals)
+		if err != nil {
+			continue
+		}
+		transfers = append(transfers, TokenTransfer{
+			TxHash:   tx.Hash,
+			LogIndex: log.Index,
+			Block:    tx.Block,
+			Token:    contract,
+			Symbol:   token.Symbol,
+			From:     from,
+			To:       to,
+			Value:    value,
+		})
+	}
+	return transfers
+}
+
+func normalizeHex(s string) string {
+	return strings.ToLower(strings.TrimPrefix(s, "0x"))
+}
+
+// topicAddress decodes an address indexed as a 32-byte topic
+func topicAddress(chain Chain, topic string) (string, bool) {
+	topic = normalizeHex(topic)
+	if len(topic) != 64 || strings.Trim(topic[:24], "0") != "" {
+		return "", false
+	}
+	return hexAddress(chain, topic[24:])
+}
+
+// contractAddress normalizes the address that emitted a log. TRON nodes
+// report it as hex, with or without the 41 prefix, or in base58.
+func contractAddress(chain Chain, address string) (string, bool) {
+	if chain == ChainTron && tronAddressPattern.MatchString(address) {
+		return address, true
+	}
+	address = normalizeHex(address)
+	if chain == ChainTron && len(address) == 42 && strings.HasPrefix(address, "41") {
+		address = address[2:]
+	}
+	if len(address) != 40 {
+		return "", false
+	}
+	return hexAddress(chain, address)
+}
+
+// hexAddress writes 20 address bytes in hex the way the chain shows them
+func hexAddress(chain Chain, hex40 string) (string, bool) {
+	raw, err := hex.DecodeString(hex40)
+	if err != nil || len(raw) != 20 {
+		return "", false
+	}
+	if chain == ChainTron {
+		return tronBase58(raw), true
+	}
+	return "0x" + hex40, true
+}
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// tronBase58 encodes 20 address bytes as a TRON address: the 0x41 prefix
+// and a double SHA-256 checksum in Base58
+func tronBase58(raw []byte) string {
+	payload := append([]byte{0x41}, raw...)
+	first := sha256.Sum256(payload)
+	second := sha256.Sum256(first[:])
+	payload = append(payload, second[:4]...)
+
+	n := new(big.Int).SetBytes(payload)
+	base, mod := big.NewInt(58), new(big.Int)
+	var encoded []byte
+	for n.Sign() > 0 {
+		n.DivMod(n, base, mod)
+		encoded = append(encoded, base58Alphabet[mod.Int64()])
+	}
+	// The 0x41 prefix means there are no leading zero bytes to encode
+	for i, j := 0, len(encoded)-1; i < j; i, j = i+1, j-1 {
+		encoded[i], encoded[j] = encoded[j], encoded[i]
+	}
+	return string(encoded)
+}
+
+// tronRaw decodes a Base58 TRON address into its 20 address bytes,
+// verifying the prefix and checksum
+func tronRaw(address string) ([]byte, bool) {
+	n := new(big.Int)
+	base := big.NewInt(58)
+	for _, r := range address {
+		digit := strings.IndexRune(base58Alphabet, r)
+		if digit < 0 {
+			return nil, false
+		}
+		n.Mul(n, base).Add(n, big.NewInt(int64(digit)))
+	}
+
+	payload := n.Bytes()
+	if len(payload) != 25 || payload[0] != 0x41 {
+		return nil, false
+	}
+	first := sha256.Sum256(payload[:21])
+	second := sha256.Sum256(first[:])
+	if !bytes.Equal(second[:4], payload[21:]) {
+		return nil, false
+	}
+	return payload[1:21], true
+}
@@ -0,0 +1,98 @@
+package domain
+
+import (
+	"encoding/hex"
+	"reflect"
+	"testing"
+)
+
+func TestTronBase58(t *testing.T) {
+	raw, _ := hex.DecodeString("a614f803b6fd780986a42c78ec9c7f77e6ded13c")
+	if got := tronBase58(raw); got != "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t" {
+		t.Errorf("tronBase58() = %s, want the USDT contract address", got)
+	}
+}
+
+func TestDecodeTokenTransfers(t *testing.T) {
+	const (
+		from   = "0x000000000000000000000000742d35cc6634c0532925a3b844bc454e4438f44e"
+		to     = "0x0000000000000000000000005aeda56215b167893e80b4fe645ba6d5bab767de"
+		amount = "0x000000000000000000000000000000000000000000000000000000004a817c80" // 1250000000
+	)
+	tests := []struct {
+		name  string
+		chain Chain
+		logs  []EventLog
+		want  []TokenTransfer
+	}{
+		{
+			name:  "ERC-20 USDT",
+			chain: ChainEthereum,
+			logs: []EventLog{{
+				Index:   2,
+				Address: "0xdAC17F958D2ee523a2206206994597C13D831ec7",
+				Topics:  []string{"0x" + TransferEventTopic, from, to},
+				Data:    amount,
+			}},
+			want: []TokenTransfer{{
+				TxHash:   "0xaa",
+				LogIndex: 2,
+				Token:    "0xdac17f958d2ee523a2206206994597c13d831ec7",
+				Symbol:   "USDT",
+				From:     "0x742d35cc6634c0532925a3b844bc454e4438f44e",
+				To:       "0x5aeda56215b167893e80b4fe645ba6d5bab767de",
+				Value:    1250,
+			}},
+		},
+		{
+			name:  "TRC-20 USDT as TronGrid reports it",
+			chain: ChainTron,
+			logs: []EventLog{{
+				Address: "a614f803b6fd780986a42c78ec9c7f77e6ded13c",
+				Topics:  []string{TransferEventTopic, from[2:], to[2:]},
+				Data:    amount[2:],
+			}},
+			want: []TokenTransfer{{
+				TxHash: "0xaa",
+				Token:  "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t",
+				Symbol: "USDT",
+				From:   "TLZVYZskxoJt4M4bYHPJg5BzHr73oZRZzc",
+				To:     "TJFzWFzJUNc2KmZJpp2P5BWHPVDSJVJ6Mk",
+				Value:  1250,
+			}},
+		},
+		{
+			name:  "unknown token keeps base units",
+			chain: ChainEthereum,
+			logs: []EventLog{{
+				Address: "0x1111111111111111111111111111111111111111",
+				Topics:  []string{TransferEventTopic, from, to},
+				Data:    amount,
+			}},
+			want: []TokenTransfer{{
+				TxHash: "0xaa",
+				Token:  "0x1111111111111111111111111111111111111111",
+				From:   "0x742d35cc6634c0532925a3b844bc454e4438f44e",
+				To:     "0x5aeda56215b167893e80b4fe645ba6d5bab767de",
+				Value:  1250000000,
+			}},
+		},
+		{
+			name:  "NFT transfers and other events are skipped",
+			chain: ChainEthereum,
+			logs: []EventLog{
+				{Address: "0x1111111111111111111111111111111111111111", Topics: []string{TransferEventTopic, from, to, amount}},
+				{Address: "0x1111111111111111111111111111111111111111", Topics: []string{"0x8c5be1e5", from, to}, Data: amount},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DecodeTokenTransfers(tt.chain, &ChainTransaction{Hash: "0xaa", Logs: tt.logs})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DecodeTokenTransfers() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
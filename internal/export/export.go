@@ -0,0 +1,237 @@
+// Package export writes screening history in machine-readable formats for
+// downstream tools
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/clevertechru/tgbot_aml/internal/domain"
+	"github.com/clevertechru/tgbot_aml/internal/services"
+)
+
+// SchemaVersion is the version of the Record layout. It changes whenever a
+// field is renamed, removed or changes meaning; adding fields keeps it.
+const SchemaVersion = 1
+
+// Format is a file format for exports
+type Format string
+
+const (
+	FormatJSONLines Format = "jsonl"
+	FormatCSV       Format = "csv"
+)
+
+// ParseFormat parses a format name, accepting "json" for JSON Lines
+func ParseFormat(s string) (Format, bool) {
+	switch strings.ToLower(s) {
+	case "jsonl", "json":
+		return FormatJSONLines, true
+	case "csv":
+		return FormatCSV, true
+	}
+	return "", false
+}
+
+// Extension returns the file extension for the format
+func (f Format) Extension() string {
+	return "." + string(f)
+}
+
+// Record is one exported check. Address is set for address checks and
+// TransactionID for transaction checks, as in domain.AMLResult and
+// domain.TransactionResult. Provider lists the providers that answered,
+// separated by commas.
+type Record struct {
+	SchemaVersion int       `json:"schema_version"`
+	AuditID       string    `json:"audit_id"`
+	CheckedAt     time.Time `json:"checked_at"`
+	Kind          string    `json:"kind"`
+	Address       string    `json:"address"`
+	TransactionID string    `json:"transaction_id"`
+	Chain         string    `json:"chain"`
+	Provider      string    `json:"provider"`
+	IsSuspicious  bool      `json:"is_suspicious"`
+	RiskScore     float64   `json:"risk_score"`
+	Categories    []string  `json:"categories"`
+	Details       []string  `json:"details"`
+	AnalystID     int64     `json:"analyst_id"`
+	ChatID        int64     `json:"chat_id"`
+	Verdict       string    `json:"verdict"`
+	FiredRules    []string  `json:"fired_rules"`
+}
+
+// csvHeader lists the CSV columns in the order Record fields are written
+var csvHeader = []string{
+	"schema_version", "audit_id", "checked_at", "kind", "address", "transaction_id",
+	"chain", "provider", "is_suspicious", "risk_score", "categories", "details",
+	"analyst_id", "chat_id", "verdict", "fired_rules",
+}
+
+// FromAudit converts an audit log entry to an export record
+func FromAudit(entry services.AuditEntry) Record {
+	providers := make([]string, len(entry.Responses))
+	for i, response := range entry.Responses {
+		providers[i] = response.Provider
+	}
+
+	record := Record{
+		SchemaVersion: SchemaVersion,
+		AuditID:       entry.ID,
+		CheckedAt:     entry.Time.UTC(),
+		Kind:          entry.Kind,
+		Chain:         entry.Chain,
+		Provider:      strings.Join(providers, ","),
+		IsSuspicious:  entry.IsSuspicious,
+		RiskScore:     entry.RiskScore,
+		Categories:    nonNil(entry.Categories),
+		Details:       nonNil(entry.Details),
+		AnalystID:     entry.Analyst.UserID,
+		ChatID:        entry.ChatID,
+		Verdict:       string(entry.Verdict),
+		FiredRules:    nonNil(entry.FiredRules),
+	}
+	if entry.Kind == "transaction" {
+		record.TransactionID = entry.Query
+	} else {
+		record.Address = entry.Query
+	}
+	return record
+}
+
+// FromResult converts a screening result that was not recorded in the audit
+// log, such as one from the command line, to an export record
+func FromResult(result *domain.ScreeningResult, checkedAt time.Time) Record {
+	providers := make([]string, len(result.Responses))
+	for i, response := range result.Responses {
+		providers[i] = response.Provider
+	}
+
+	record := Record{
+		SchemaVersion: SchemaVersion,
+		CheckedAt:     checkedAt.UTC(),
+		Kind:          result.Target.Kind.String(),
+		Chain:         string(result.Target.Chain),
+		Provider:      strings.Join(providers, ","),
+		IsSuspicious:  result.IsSuspicious,
+		RiskScore:     result.RiskScore,
+		Categories:    nonNil(result.Categories),
+		Details:       nonNil(result.Details),
+		Verdict:       string(result.Verdict),
+		FiredRules:    nonNil(result.FiredRules),
+	}
+	if result.Target.Kind == domain.KindTransaction {
+		record.TransactionID = result.Target.Value
+	} else {
+		record.Address = result.Target.Value
+	}
+	return record
+}
+
+// nonNil keeps empty lists as [] rather than null in JSON
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+// Writer writes records one at a time
+type Writer interface {
+	Write(Record) error
+	// Close flushes buffered records; it does not close the underlying writer
+	Close() error
+}
+
+// NewWriter returns a Writer for the format
+func NewWriter(w io.Writer, format Format) (Writer, error) {
+	switch format {
+	case FormatJSONLines:
+		return &jsonLinesWriter{enc: json.NewEncoder(w)}, nil
+	case FormatCSV:
+		return &csvWriter{w: csv.NewWriter(w)}, nil
+	}
+	return nil, fmt.Errorf("unsupported export format %q", format)
+}
+
+type jsonLinesWriter struct {
+	enc *json.Encoder
+}
+
+func (j *jsonLinesWriter) Write(r Record) error {
+	return j.enc.Encode(r)
+}
+
+func (j *jsonLinesWriter) Close() error {
+	return nil
+}
+
+type csvWriter struct {
+	w           *csv.Writer
+	wroteHeader bool
+}
+
+func (c *csvWriter) Write(r Record) error {
+	if !c.wroteHeader {
+		if err := c.w.Write(csvHeader); err != nil {
+			return err
+		}
+		c.wroteHeader = true
+	}
+	return c.w.Write([]string{
+		strconv.Itoa(r.SchemaVersion),
+		r.AuditID,
+		r.CheckedAt.Format(time.RFC3339),
+		r.Kind,
+		r.Address,
+		r.TransactionID,
+		r.Chain,
+		r.Provider,
+		strconv.FormatBool(r.IsSuspicious),
+		strconv.FormatFloat(r.RiskScore, 'f', -1, 64),
+		strings.Join(r.Categories, ";"),
+		strings.Join(r.Details, ";"),
+		strconv.FormatInt(r.AnalystID, 10),
+		strconv.FormatInt(r.ChatID, 10),
+		r.Verdict,
+		strings.Join(r.FiredRules, ";"),
+	})
+}
+
+func (c *csvWriter) Close() error {
+	if !c.wroteHeader {
+		if err := c.w.Write(csvHeader); err != nil {
+			return err
+		}
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// Export streams the audit log entries recorded in [from, to) that keep
+// accepts to w and returns the number of records written. A nil keep
+// exports every entry.
+func Export(w io.Writer, format Format, log *services.AuditLog, from, to time.Time, keep func(services.AuditEntry) bool) (int, error) {
+	writer, err := NewWriter(w, format)
+	if err != nil {
+		return 0, err
+	}
+
+	var n int
+	err = log.Scan(from, to, func(entry services.AuditEntry) error {
+		if keep != nil && !keep(entry) {
+			return nil
+		}
+		n++
+		return writer.Write(FromAudit(entry))
+	})
+	if err != nil {
+		return n, err
+	}
+	return n, writer.Close()
+}
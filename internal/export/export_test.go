@@ -0,0 +1,217 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/clevertechru/tgbot_aml/internal/domain"
+	"github.com/clevertechru/tgbot_aml/internal/services"
+	"github.com/clevertechru/tgbot_aml/internal/storage"
+)
+
+func testEntry() services.AuditEntry {
+	return services.AuditEntry{
+		ID:           "20240501-1f3a9c0e2b7d",
+		Time:         time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Analyst:      services.Analyst{UserID: 42, Username: "alice"},
+		ChatID:       -100,
+		Query:        "0xabc",
+		Kind:         "address",
+		Chain:        "Ethereum",
+		IsSuspicious: true,
+		RiskScore:    0.75,
+		Details:      []string{"reported scam"},
+		Categories:   []string{"scam", "phishing"},
+		Responses:    []domain.ProviderResponse{{Provider: "chainabuse"}, {Provider: "mock"}},
+		Verdict:      domain.VerdictBlock,
+		FiredRules:   []string{"scams", "high score"},
+	}
+}
+
+func TestJSONLinesSchema(t *testing.T) {
+	var buf bytes.Buffer
+	writer, err := NewWriter(&buf, FormatJSONLines)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tx := testEntry()
+	tx.Kind, tx.Query, tx.Details, tx.Categories = "transaction", "0xdef", nil, nil
+	for _, entry := range []services.AuditEntry{testEntry(), tx} {
+		if err := writer.Write(FromAudit(entry)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The field names are the schema downstream tools depend on
+	wantKeys := []string{
+		"address", "analyst_id", "audit_id", "categories", "chain", "chat_id", "checked_at",
+		"details", "fired_rules", "is_suspicious", "kind", "provider", "risk_score", "schema_version",
+		"transaction_id", "verdict",
+	}
+	var lines []map[string]any
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var line map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", scanner.Text(), err)
+		}
+		keys := make([]string, 0, len(line))
+		for key := range line {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		if !reflect.DeepEqual(keys, wantKeys) {
+			t.Errorf("keys = %v, want %v", keys, wantKeys)
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2", len(lines))
+	}
+
+	first := lines[0]
+	if first["schema_version"] != float64(SchemaVersion) || first["audit_id"] != "20240501-1f3a9c0e2b7d" ||
+		first["address"] != "0xabc" || first["transaction_id"] != "" || first["chain"] != "Ethereum" ||
+		first["provider"] != "chainabuse,mock" || first["checked_at"] != "2024-05-01T12:00:00Z" {
+		t.Errorf("unexpected record: %v", first)
+	}
+	second := lines[1]
+	if second["address"] != "" || second["transaction_id"] != "0xdef" {
+		t.Errorf("unexpected transaction record: %v", second)
+	}
+	if categories, ok := second["categories"].([]any); !ok || len(categories) != 0 {
+		t.Errorf("empty categories = %v, want []", second["categories"])
+	}
+}
+
+func TestCSV(t *testing.T) {
+	var buf bytes.Buffer
+	writer, err := NewWriter(&buf, FormatCSV)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := writer.Write(FromAudit(testEntry())); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	want := [][]string{csvHeader, {
+		"1", "20240501-1f3a9c0e2b7d", "2024-05-01T12:00:00Z", "address", "0xabc", "",
+		"Ethereum", "chainabuse,mock", "true", "0.75", "scam;phishing", "reported scam", "42", "-100",
+		"block", "scams;high score",
+	}}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %v, want %v", rows, want)
+	}
+}
+
+func TestCSVEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	writer, _ := NewWriter(&buf, FormatCSV)
+	if err := writer.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rows, _ := csv.NewReader(&buf).ReadAll()
+	if len(rows) != 1 {
+		t.Errorf("empty export has %d rows, want the header only", len(rows))
+	}
+}
+
+func TestExportFiltersByTime(t *testing.T) {
+	log := services.NewAuditLog(storage.NewJSONLines[services.AuditEntry](filepath.Join(t.TempDir(), "audit.jsonl")))
+	for i, value := range []string{"addr1", "addr2", "addr3"} {
+		_, err := log.Record(services.Analyst{UserID: 1}, int64(1+i/2), &domain.ScreeningResult{
+			Target: domain.Target{Value: value, Kind: domain.KindAddress},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	now := time.Now()
+	var buf bytes.Buffer
+	n, err := Export(&buf, FormatJSONLines, log, now.Add(-time.Hour), now.Add(time.Hour), nil)
+	if err != nil || n != 3 {
+		t.Fatalf("Export() = %d, %v, want 3 records", n, err)
+	}
+	if got := bytes.Count(buf.Bytes(), []byte("\n")); got != 3 {
+		t.Errorf("got %d lines, want 3", got)
+	}
+
+	buf.Reset()
+	inChat := func(entry services.AuditEntry) bool { return entry.ChatID == 1 }
+	n, err = Export(&buf, FormatJSONLines, log, now.Add(-time.Hour), now.Add(time.Hour), inChat)
+	if err != nil || n != 2 || bytes.Contains(buf.Bytes(), []byte("addr3")) {
+		t.Errorf("Export() of one chat = %d, %v, %q", n, err, buf.String())
+	}
+
+	buf.Reset()
+	n, err = Export(&buf, FormatJSONLines, log, now.Add(time.Hour), now.Add(2*time.Hour), nil)
+	if err != nil || n != 0 || buf.Len() != 0 {
+		t.Errorf("Export() outside the range = %d, %v, %q", n, err, buf.String())
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Format
+		ok   bool
+	}{
+		{"jsonl", FormatJSONLines, true},
+		{"JSON", FormatJSONLines, true},
+		{"csv", FormatCSV, true},
+		{"xml", "", false},
+	}
+	for _, tt := range tests {
+		if got, ok := ParseFormat(tt.in); got != tt.want || ok != tt.ok {
+			t.Errorf("ParseFormat(%q) = %q, %v, want %q, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestFromResult(t *testing.T) {
+	checkedAt := time.Date(2024, 5, 1, 14, 0, 0, 0, time.FixedZone("CEST", 2*60*60))
+	result := &domain.ScreeningResult{
+		Target:       domain.Target{Value: "0xabc", Kind: domain.KindTransaction, Chain: domain.ChainEthereum},
+		IsSuspicious: true,
+		RiskScore:    0.75,
+		Responses:    []domain.ProviderResponse{{Provider: "chainabuse"}, {Provider: "freezes"}},
+		Verdict:      domain.VerdictReview,
+	}
+
+	got := FromResult(result, checkedAt)
+	want := Record{
+		SchemaVersion: SchemaVersion,
+		CheckedAt:     time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Kind:          "transaction",
+		TransactionID: "0xabc",
+		Chain:         "ETH",
+		Provider:      "chainabuse,freezes",
+		IsSuspicious:  true,
+		RiskScore:     0.75,
+		Categories:    []string{},
+		Details:       []string{},
+		Verdict:       "review",
+		FiredRules:    []string{},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FromResult() =\n%+v\nwant\n%+v", got, want)
+	}
+}
@@ -0,0 +1,72 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/clevertechru/tgbot_aml/internal/domain"
+)
+
+// ChainSource reads transfers from the chain data source of each chain. It
+// follows native currency only, since token amounts cannot be weighed
+// against each other without prices.
+type ChainSource struct {
+	sources domain.ChainDataSources
+	limit   int
+}
+
+// NewChainSource loads up to limit of the latest transactions per address
+func NewChainSource(sources domain.ChainDataSources, limit int) *ChainSource {
+	return &ChainSource{sources: sources, limit: limit}
+}
+
+func (s *ChainSource) Transfers(ctx context.Context, chain domain.Chain, address string) ([]Transfer, error) {
+	source, ok := s.sources.For(chain)
+	if !ok {
+		return nil, fmt.Errorf("no chain data source for %q", chain)
+	}
+
+	txs, err := source.Transactions(ctx, address, s.limit)
+	if err != nil {
+		return nil, err
+	}
+	var transfers []Transfer
+	for _, tx := range txs {
+		transfers = append(transfers, transfersOf(tx)...)
+	}
+	return transfers, nil
+}
+
+// transfersOf splits a transaction into transfers from every input to every
+// output, each input's value divided among the outputs in proportion to
+// their values. Failed transactions moved nothing.
+func transfersOf(tx domain.ChainTransaction) []Transfer {
+	if tx.Failed {
+		return nil
+	}
+
+	var total float64
+	for _, out := range tx.Outputs {
+		total += out.Value
+	}
+	if total <= 0 {
+		return nil
+	}
+
+	var transfers []Transfer
+	for _, in := range tx.Inputs {
+		for _, out := range tx.Outputs {
+			if in.Address == "" || out.Address == "" || addressKey(in.Address) == addressKey(out.Address) {
+				continue
+			}
+			transfers = append(transfers, Transfer{
+				TxHash: tx.Hash,
+				From:   in.Address,
+				To:     out.Address,
+				Value:  in.Value * out.Value / total,
+				Time:   tx.Block.Time,
+			})
+		}
+	}
+	return transfers
+}
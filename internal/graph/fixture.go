@@ -0,0 +1,41 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/clevertechru/tgbot_aml/internal/domain"
+)
+
+// Fixture is a fixed transaction graph, used by tests and by the deprecated
+// graph.fixture setting for offline runs. It serves the same transfers for
+// every chain.
+type Fixture []Transfer
+
+// LoadFixture reads a JSON array of transfers
+func LoadFixture(path string) (Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transaction graph: %w", err)
+	}
+
+	var fixture Fixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("failed to parse transaction graph %s: %w", path, err)
+	}
+	return fixture, nil
+}
+
+// Transfers returns every transfer the address sent or received
+func (f Fixture) Transfers(_ context.Context, _ domain.Chain, address string) ([]Transfer, error) {
+	key := addressKey(address)
+	var transfers []Transfer
+	for _, transfer := range f {
+		if addressKey(transfer.From) == key || addressKey(transfer.To) == key {
+			transfers = append(transfers, transfer)
+		}
+	}
+	return transfers, nil
+}
@@ -0,0 +1,377 @@
+// Package graph traces where an address's funds came from and where they
+// went by walking the transaction graph around it, and scores how much of
+// that value touches addresses of known risky categories.
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/clevertechru/tgbot_aml/internal/domain"
+)
+
+// MaxHops bounds how far from the screened address a walk may go
+const MaxHops = 5
+
+// Transfer is a movement of value from one address to another
+type Transfer struct {
+	TxHash string    `json:"tx_hash"`
+	From   string    `json:"from"`
+	To     string    `json:"to"`
+	Value  float64   `json:"value"`
+	Time   time.Time `json:"time,omitempty"`
+}
+
+// Source supplies the transfers an address sent or received
+type Source interface {
+	Transfers(ctx context.Context, chain domain.Chain, address string) ([]Transfer, error)
+}
+
+// Labels tells which risky categories an address belongs to
+type Labels interface {
+	Categories(address string) []string
+}
+
+// Options limit how far and how wide the analyzer walks, how many addresses
+// it loads at once, how long a walk may take and how long its results are
+// cached. A zero Timeout or CacheTTL disables the deadline or the cache.
+type Options struct {
+	Hops         int
+	MaxAddresses int
+	Workers      int
+	Timeout      time.Duration
+	CacheTTL     time.Duration
+}
+
+// maxCacheEntries bounds the cached walks; when the cache is full the walk
+// closest to expiry makes room for a new one
+const maxCacheEntries = 10000
+
+type cacheEntry struct {
+	exposure *domain.GraphExposure
+	expires  time.Time
+}
+
+// Analyzer computes graph exposure with value-weighted propagation: the
+// screened address starts with a share of 1, which every hop splits among
+// counterparties in proportion to the value they exchanged. Shares that
+// reach a labelled address count towards its categories and stop there.
+type Analyzer struct {
+	source Source
+	labels Labels
+
+	mu         sync.Mutex
+	options    Options
+	cache      map[string]cacheEntry
+	maxEntries int
+	swept      time.Time
+	now        func() time.Time
+}
+
+func NewAnalyzer(source Source, labels Labels, options Options) *Analyzer {
+	return &Analyzer{
+		source:     source,
+		labels:     labels,
+		options:    normalize(options),
+		cache:      make(map[string]cacheEntry),
+		maxEntries: maxCacheEntries,
+		now:        time.Now,
+	}
+}
+
+func normalize(options Options) Options {
+	if options.Hops < 1 {
+		options.Hops = 1
+	}
+	if options.Hops > MaxHops {
+		options.Hops = MaxHops
+	}
+	if options.MaxAddresses < 1 {
+		options.MaxAddresses = 1
+	}
+	if options.Workers < 1 {
+		options.Workers = 1
+	}
+	return options
+}
+
+// SetOptions changes the walk limits and drops cached results
+func (a *Analyzer) SetOptions(options Options) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.options = normalize(options)
+	a.cache = make(map[string]cacheEntry)
+}
+
+// Flush drops cached results, for example after the labels changed
+func (a *Analyzer) Flush() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.cache = make(map[string]cacheEntry)
+}
+
+// Analyze walks the graph around an address in both directions. Results
+// are cached per address and must not be modified.
+func (a *Analyzer) Analyze(ctx context.Context, target domain.Target) (*domain.GraphExposure, error) {
+	key := string(target.Chain) + ":" + addressKey(target.Value)
+
+	a.mu.Lock()
+	options := a.options
+	entry, ok := a.cache[key]
+	now := a.now()
+	a.mu.Unlock()
+	if ok && now.Before(entry.expires) {
+		return entry.exposure, nil
+	}
+
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
+	w := &walk{
+		ctx:       ctx,
+		source:    a.source,
+		labels:    a.labels,
+		chain:     target.Chain,
+		root:      addressKey(target.Value),
+		limit:     options.MaxAddresses,
+		workers:   options.Workers,
+		transfers: make(map[string][]Transfer),
+	}
+	exposure := &domain.GraphExposure{Hops: options.Hops}
+	err := w.prefetch([]string{target.Value})
+	if err == nil {
+		exposure.Incoming, err = w.exposure(target.Value, incoming, options.Hops)
+	}
+	if err == nil {
+		exposure.Outgoing, err = w.exposure(target.Value, outgoing, options.Hops)
+	}
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("graph walk took longer than %s: %w", options.Timeout, err)
+		}
+		return nil, err
+	}
+	exposure.Addresses = len(w.transfers)
+	exposure.Truncated = w.truncated
+
+	if options.CacheTTL > 0 {
+		a.store(key, cacheEntry{exposure: exposure, expires: now.Add(options.CacheTTL)}, options.CacheTTL)
+	}
+	return exposure, nil
+}
+
+// store caches a walk. Expired walks are swept once per TTL, and when the
+// cache is full the walk closest to expiry is dropped.
+func (a *Analyzer) store(key string, entry cacheEntry, ttl time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := a.now()
+	if now.Sub(a.swept) >= ttl {
+		a.sweep(now)
+	}
+	if _, ok := a.cache[key]; !ok && len(a.cache) >= a.maxEntries {
+		a.sweep(now)
+		if len(a.cache) >= a.maxEntries {
+			var oldest string
+			var expires time.Time
+			for key, entry := range a.cache {
+				if oldest == "" || entry.expires.Before(expires) {
+					oldest, expires = key, entry.expires
+				}
+			}
+			delete(a.cache, oldest)
+		}
+	}
+	a.cache[key] = entry
+}
+
+// sweep drops expired walks. The caller holds the lock.
+func (a *Analyzer) sweep(now time.Time) {
+	for key, entry := range a.cache {
+		if !now.Before(entry.expires) {
+			delete(a.cache, key)
+		}
+	}
+	a.swept = now
+}
+
+type direction int
+
+const (
+	incoming direction = iota
+	outgoing
+)
+
+// walk holds the state of one analysis: the transfers loaded so far, shared
+// by both directions, and whether the address limit was hit
+type walk struct {
+	ctx       context.Context
+	source    Source
+	labels    Labels
+	chain     domain.Chain
+	root      string
+	limit     int
+	workers   int
+	transfers map[string][]Transfer
+	truncated bool
+}
+
+// prefetch loads the transfers of addresses not loaded yet, up to the
+// address limit, with at most workers requests in flight. Addresses are
+// taken in order, so the limit cuts off the same ones on every walk.
+func (w *walk) prefetch(addresses []string) error {
+	var pending []string
+	queued := make(map[string]bool)
+	for _, address := range addresses {
+		key := addressKey(address)
+		if _, ok := w.transfers[key]; ok || queued[key] {
+			continue
+		}
+		if len(w.transfers)+len(pending) >= w.limit {
+			w.truncated = true
+			break
+		}
+		queued[key] = true
+		pending = append(pending, address)
+	}
+
+	ctx, cancel := context.WithCancel(w.ctx)
+	defer cancel()
+
+	results := make([][]Transfer, len(pending))
+	errs := make([]error, len(pending))
+	slots := make(chan struct{}, w.workers)
+	var wg sync.WaitGroup
+	for i, address := range pending {
+		slots <- struct{}{}
+		wg.Add(1)
+		go func(i int, address string) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			if results[i], errs[i] = w.source.Transfers(ctx, w.chain, address); errs[i] != nil {
+				// One failure fails the walk, so stop the others early
+				cancel()
+			}
+		}(i, address)
+	}
+	wg.Wait()
+
+	for i, address := range pending {
+		if errs[i] != nil {
+			return fmt.Errorf("failed to load transfers of %s: %w", address, errs[i])
+		}
+		w.transfers[addressKey(address)] = results[i]
+	}
+	return nil
+}
+
+// exposure propagates shares hop by hop in one direction and collects the
+// shares that reach labelled addresses
+func (w *walk) exposure(root string, dir direction, hops int) ([]domain.CategoryExposure, error) {
+	found := make(map[string]*domain.CategoryExposure)
+	frontier := map[string]float64{root: 1}
+
+	for hop := 1; hop <= hops && len(frontier) > 0; hop++ {
+		addresses := sortedKeys(frontier)
+		if err := w.prefetch(addresses); err != nil {
+			return nil, err
+		}
+
+		next := make(map[string]float64)
+		for _, address := range addresses {
+			if _, ok := w.transfers[addressKey(address)]; !ok {
+				// Past the address limit
+				continue
+			}
+
+			counterparties, total := w.counterparties(address, dir)
+			for _, counterparty := range sortedKeys(counterparties) {
+				if addressKey(counterparty) == w.root {
+					// Funds going round in a circle add nothing
+					continue
+				}
+				share := frontier[address] * counterparties[counterparty] / total
+				categories := w.labels.Categories(counterparty)
+				if len(categories) == 0 {
+					next[counterparty] += share
+					continue
+				}
+				for _, category := range categories {
+					exposure, ok := found[category]
+					if !ok {
+						exposure = &domain.CategoryExposure{Category: category, Hops: hop}
+						found[category] = exposure
+					}
+					exposure.Share += share
+				}
+			}
+		}
+		frontier = next
+	}
+
+	result := make([]domain.CategoryExposure, 0, len(found))
+	for _, exposure := range found {
+		if exposure.Share > 1 {
+			exposure.Share = 1
+		}
+		result = append(result, *exposure)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Share != result[j].Share {
+			return result[i].Share > result[j].Share
+		}
+		return result[i].Category < result[j].Category
+	})
+	return result, nil
+}
+
+// counterparties sums the value an address received from (incoming) or sent
+// to (outgoing) each counterparty
+func (w *walk) counterparties(address string, dir direction) (map[string]float64, float64) {
+	key := addressKey(address)
+	values := make(map[string]float64)
+	var total float64
+	for _, transfer := range w.transfers[key] {
+		if transfer.Value <= 0 {
+			continue
+		}
+		self, other := transfer.To, transfer.From
+		if dir == outgoing {
+			self, other = transfer.From, transfer.To
+		}
+		if addressKey(self) != key || addressKey(other) == key {
+			continue
+		}
+		values[other] += transfer.Value
+		total += transfer.Value
+	}
+	return values, total
+}
+
+// addressKey ignores case, since EVM addresses are often written in
+// mixed-case checksum form
+func addressKey(address string) string {
+	return strings.ToLower(strings.TrimSpace(address))
+}
+
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
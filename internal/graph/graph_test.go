@@ -0,0 +1,358 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/clevertechru/tgbot_aml/internal/domain"
+)
+
+var target = domain.Target{Value: "0xTarget", Kind: domain.KindAddress, Chain: domain.ChainEthereum}
+
+func loadTestGraph(t *testing.T, name string) (Fixture, *LabelSet) {
+	t.Helper()
+	fixture, err := LoadFixture(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	labels, err := NewLabelSet(filepath.Join("testdata", "labels.txt"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return fixture, labels
+}
+
+func TestAnalyze(t *testing.T) {
+	fixture, labels := loadTestGraph(t, "two_hops.json")
+
+	tests := []struct {
+		name     string
+		hops     int
+		incoming []domain.CategoryExposure
+		outgoing []domain.CategoryExposure
+	}{
+		{
+			name:     "one hop",
+			hops:     1,
+			incoming: []domain.CategoryExposure{{Category: "exchange", Share: 0.6, Hops: 1}},
+			outgoing: []domain.CategoryExposure{{Category: "gambling", Share: 0.25, Hops: 1}},
+		},
+		{
+			name: "two hops",
+			hops: 2,
+			incoming: []domain.CategoryExposure{
+				{Category: "exchange", Share: 0.6, Hops: 1},
+				{Category: "mixer", Share: 0.4, Hops: 2},
+			},
+			outgoing: []domain.CategoryExposure{
+				{Category: "darknet_market", Share: 0.75, Hops: 2},
+				{Category: "gambling", Share: 0.25, Hops: 1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := NewAnalyzer(fixture, labels, Options{Hops: tt.hops, MaxAddresses: 100})
+			exposure, err := analyzer.Analyze(context.Background(), target)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if exposure.Hops != tt.hops || exposure.Truncated {
+				t.Errorf("Hops = %d, Truncated = %v", exposure.Hops, exposure.Truncated)
+			}
+			if !reflect.DeepEqual(exposure.Incoming, tt.incoming) {
+				t.Errorf("Incoming = %+v, want %+v", exposure.Incoming, tt.incoming)
+			}
+			if !reflect.DeepEqual(exposure.Outgoing, tt.outgoing) {
+				t.Errorf("Outgoing = %+v, want %+v", exposure.Outgoing, tt.outgoing)
+			}
+		})
+	}
+}
+
+func TestAnalyzeCycle(t *testing.T) {
+	labels, err := NewLabelSet(filepath.Join("testdata", "labels.txt"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fixture := Fixture{
+		{From: "0xTarget", To: "0xA", Value: 50},
+		{From: "0xA", To: "0xB", Value: 50},
+		{From: "0xB", To: "0xA", Value: 50},
+		{From: "0xMixer", To: "0xB", Value: 50},
+		{From: "0xB", To: "0xTarget", Value: 100},
+	}
+
+	analyzer := NewAnalyzer(fixture, labels, Options{Hops: MaxHops, MaxAddresses: 100})
+	exposure, err := analyzer.Analyze(context.Background(), target)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Half of what reaches B comes from the mixer, the other half circles
+	// through A and is split again on the next round
+	if len(exposure.Incoming) != 1 || exposure.Incoming[0].Category != "mixer" || exposure.Incoming[0].Hops != 2 {
+		t.Fatalf("Incoming = %+v", exposure.Incoming)
+	}
+	if share := exposure.Incoming[0].Share; share < 0.5 || share > 1 {
+		t.Errorf("mixer share = %v, want between 0.5 and 1", share)
+	}
+	if exposure.Addresses != 3 {
+		t.Errorf("Addresses = %d, want 3", exposure.Addresses)
+	}
+}
+
+func TestAnalyzeTruncated(t *testing.T) {
+	fixture, labels := loadTestGraph(t, "two_hops.json")
+
+	analyzer := NewAnalyzer(fixture, labels, Options{Hops: 2, MaxAddresses: 2})
+	exposure, err := analyzer.Analyze(context.Background(), target)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !exposure.Truncated || exposure.Addresses != 2 {
+		t.Errorf("Truncated = %v, Addresses = %d, want a truncated walk of 2 addresses", exposure.Truncated, exposure.Addresses)
+	}
+}
+
+type countingSource struct {
+	Fixture
+	mu    sync.Mutex
+	calls int
+	err   error
+}
+
+func (s *countingSource) Transfers(ctx context.Context, chain domain.Chain, address string) ([]Transfer, error) {
+	s.mu.Lock()
+	s.calls++
+	s.mu.Unlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.Fixture.Transfers(ctx, chain, address)
+}
+
+func TestAnalyzeCache(t *testing.T) {
+	fixture, labels := loadTestGraph(t, "two_hops.json")
+	source := &countingSource{Fixture: fixture}
+
+	analyzer := NewAnalyzer(source, labels, Options{Hops: 2, MaxAddresses: 100, CacheTTL: time.Hour})
+	now := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	analyzer.now = func() time.Time { return now }
+
+	first, err := analyzer.Analyze(context.Background(), target)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	calls := source.calls
+
+	lower := domain.Target{Value: "0xtarget", Chain: domain.ChainEthereum}
+	second, err := analyzer.Analyze(context.Background(), lower)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if second != first || source.calls != calls {
+		t.Errorf("expected a cached result, got %d more source calls", source.calls-calls)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if _, err := analyzer.Analyze(context.Background(), target); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if source.calls == calls {
+		t.Error("expected an expired result to be recomputed")
+	}
+
+	calls = source.calls
+	analyzer.Flush()
+	if _, err := analyzer.Analyze(context.Background(), target); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if source.calls == calls {
+		t.Error("expected Flush to drop cached results")
+	}
+}
+
+func TestAnalyzeCacheBounds(t *testing.T) {
+	fixture, labels := loadTestGraph(t, "two_hops.json")
+	analyzer := NewAnalyzer(fixture, labels, Options{Hops: 1, MaxAddresses: 100, CacheTTL: time.Hour})
+	now := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	analyzer.now = func() time.Time { return now }
+	analyzer.maxEntries = 2
+
+	for i := 0; i < 3; i++ {
+		address := domain.Target{Value: fmt.Sprintf("0x%040d", i), Chain: domain.ChainEthereum}
+		if _, err := analyzer.Analyze(context.Background(), address); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		now = now.Add(time.Minute)
+	}
+	if n := len(analyzer.cache); n != 2 {
+		t.Errorf("expected the cache to hold 2 walks, got %d", n)
+	}
+	if _, ok := analyzer.cache[string(domain.ChainEthereum)+":"+fmt.Sprintf("0x%040d", 0)]; ok {
+		t.Error("expected the oldest walk to make room")
+	}
+
+	now = now.Add(2 * time.Hour)
+	if _, err := analyzer.Analyze(context.Background(), target); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := len(analyzer.cache); n != 1 {
+		t.Errorf("expected expired walks to be swept, got %d", n)
+	}
+}
+
+func TestAnalyzeSourceError(t *testing.T) {
+	source := &countingSource{err: errors.New("rate limited")}
+	analyzer := NewAnalyzer(source, nil, Options{Hops: 2, MaxAddresses: 100, CacheTTL: time.Hour})
+
+	if _, err := analyzer.Analyze(context.Background(), target); err == nil {
+		t.Fatal("expected an error")
+	}
+	if _, err := analyzer.Analyze(context.Background(), target); err == nil || source.calls != 2 {
+		t.Errorf("expected failures not to be cached, got %d calls", source.calls)
+	}
+}
+
+// slowSource answers after a delay and records how many requests overlap
+type slowSource struct {
+	Fixture
+	delay time.Duration
+
+	mu       sync.Mutex
+	inFlight int
+	peak     int
+}
+
+func (s *slowSource) Transfers(ctx context.Context, chain domain.Chain, address string) ([]Transfer, error) {
+	s.mu.Lock()
+	s.inFlight++
+	s.peak = max(s.peak, s.inFlight)
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.inFlight--
+		s.mu.Unlock()
+	}()
+
+	select {
+	case <-time.After(s.delay):
+		return s.Fixture.Transfers(ctx, chain, address)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func TestAnalyzeWorkers(t *testing.T) {
+	_, labels := loadTestGraph(t, "two_hops.json")
+	// Four unlabelled senders make a hop wide enough to load concurrently
+	fixture := Fixture{
+		{From: "0xA", To: "0xTarget", Value: 10},
+		{From: "0xB", To: "0xTarget", Value: 10},
+		{From: "0xC", To: "0xTarget", Value: 10},
+		{From: "0xD", To: "0xTarget", Value: 10},
+		{From: "0xMixer", To: "0xA", Value: 10},
+	}
+	want, err := NewAnalyzer(fixture, labels, Options{Hops: 2, MaxAddresses: 100}).Analyze(context.Background(), target)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	source := &slowSource{Fixture: fixture, delay: 10 * time.Millisecond}
+	got, err := NewAnalyzer(source, labels, Options{Hops: 2, MaxAddresses: 100, Workers: 2}).Analyze(context.Background(), target)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("concurrent walk = %+v, want %+v", got, want)
+	}
+	if len(got.Incoming) != 1 || got.Incoming[0].Share != 0.25 {
+		t.Errorf("Incoming = %+v, want a quarter from the mixer", got.Incoming)
+	}
+	if source.peak != 2 {
+		t.Errorf("peak concurrency = %d, want 2", source.peak)
+	}
+}
+
+func TestAnalyzeTimeout(t *testing.T) {
+	fixture, labels := loadTestGraph(t, "two_hops.json")
+	source := &slowSource{Fixture: fixture, delay: time.Second}
+
+	analyzer := NewAnalyzer(source, labels, Options{Hops: 2, MaxAddresses: 100, Timeout: 20 * time.Millisecond})
+	start := time.Now()
+	_, err := analyzer.Analyze(context.Background(), target)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the walk to time out, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("the walk took %s despite the timeout", elapsed)
+	}
+}
+
+func TestLabelSet(t *testing.T) {
+	labels, err := NewLabelSet(filepath.Join("testdata", "labels.txt"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if labels.Len() != 4 {
+		t.Errorf("Len() = %d, want 4", labels.Len())
+	}
+	if got := labels.Categories("0xEXCHANGE"); !reflect.DeepEqual(got, []string{"exchange"}) {
+		t.Errorf("Categories() = %v, want [exchange]", got)
+	}
+
+	path := filepath.Join(t.TempDir(), "labels.txt")
+	if err := os.WriteFile(path, []byte("0xabc mixer\n0xdef\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := labels.Load(path); err == nil || err.Error() != "address labels line 2: expected an address and a category" {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if labels.Len() != 4 {
+		t.Error("expected a failed load to keep the current labels")
+	}
+}
+
+func TestChainSource(t *testing.T) {
+	chainData := domain.ChainDataSources{
+		domain.ChainBitcoin: domain.NewChainFixture(domain.ChainBitcoin, []domain.ChainTransaction{
+			{
+				Hash:    "tx1",
+				Inputs:  []domain.TxLeg{{Address: "a", Value: 3}, {Address: "b", Value: 1}},
+				Outputs: []domain.TxLeg{{Address: "target", Value: 3}, {Address: "a", Value: 1}},
+			},
+			{
+				Hash:    "tx2",
+				Failed:  true,
+				Inputs:  []domain.TxLeg{{Address: "c", Value: 5}},
+				Outputs: []domain.TxLeg{{Address: "target", Value: 5}},
+			},
+		}, nil),
+	}
+	source := NewChainSource(chainData, 10)
+
+	transfers, err := source.Transfers(context.Background(), domain.ChainBitcoin, "target")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []Transfer{
+		{TxHash: "tx1", From: "a", To: "target", Value: 2.25},
+		{TxHash: "tx1", From: "b", To: "target", Value: 0.75},
+		{TxHash: "tx1", From: "b", To: "a", Value: 0.25},
+	}
+	if !reflect.DeepEqual(transfers, want) {
+		t.Errorf("Transfers() = %+v, want %+v", transfers, want)
+	}
+
+	if _, err := source.Transfers(context.Background(), domain.ChainTron, "target"); err == nil {
+		t.Error("expected an error for a chain without a data source")
+	}
+}
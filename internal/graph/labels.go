@@ -0,0 +1,100 @@
+package graph
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// LabelSet holds known addresses of risky categories, such as mixers or
+// darknet markets. The file lists one address and its category per line,
+// optionally followed by "# note"; blank lines and lines starting with "#"
+// are ignored. An address may be listed under several categories.
+type LabelSet struct {
+	mu     sync.RWMutex
+	labels map[string][]string
+}
+
+// NewLabelSet loads the labels at path. An empty path is an empty set.
+func NewLabelSet(path string) (*LabelSet, error) {
+	l := &LabelSet{}
+	if err := l.Load(path); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Load replaces the labels with the ones at path. On error the current
+// labels stay in force.
+func (l *LabelSet) Load(path string) error {
+	labels := make(map[string][]string)
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read address labels: %w", err)
+		}
+		if labels, err = parseLabels(data); err != nil {
+			return err
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.labels = labels
+	return nil
+}
+
+// Replace takes over the labels of other, for reloads that must apply
+// every file or none
+func (l *LabelSet) Replace(other *LabelSet) {
+	other.mu.RLock()
+	labels := other.labels
+	other.mu.RUnlock()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.labels = labels
+}
+
+func parseLabels(data []byte) (map[string][]string, error) {
+	labels := make(map[string][]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("address labels line %d: expected an address and a category", n)
+		}
+		key := addressKey(fields[0])
+		labels[key] = append(labels[key], strings.ToLower(fields[1]))
+	}
+	return labels, scanner.Err()
+}
+
+// Categories returns the categories an address is labelled with
+func (l *LabelSet) Categories(address string) []string {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.labels[addressKey(address)]
+}
+
+// Len returns the number of labelled addresses
+func (l *LabelSet) Len() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return len(l.labels)
+}
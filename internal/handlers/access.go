@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/clevertechru/tgbot_aml/internal/lang"
+	"github.com/clevertechru/tgbot_aml/internal/services"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// commandRoles lists the minimum role for each command. Commands that are
+// not listed need RoleViewer.
+var commandRoles = map[string]services.Role{
+	"cancel":    services.RoleAnalyst,
+	"export":    services.RoleAnalyst,
+	"grant":     services.RoleAdmin,
+	"revoke":    services.RoleAdmin,
+	"access":    services.RoleAdmin,
+	"stats":     services.RoleAdmin,
+	"providers": services.RoleAdmin,
+	"cache":     services.RoleAdmin,
+	"reload":    services.RoleAdmin,
+	"broadcast": services.RoleAdmin,
+	"rules":     services.RoleAdmin,
+}
+
+// requiredRole returns the minimum role needed to handle a message
+func requiredRole(msg *tgbotapi.Message) services.Role {
+	if msg.IsCommand() {
+		if role, ok := commandRoles[msg.Command()]; ok {
+			return role
+		}
+		return services.RoleViewer
+	}
+	if msg.Document != nil {
+		return services.RoleAnalyst
+	}
+	return services.RoleViewer
+}
+
+// roleOf returns the sender's effective role in the chat of the message
+func (h *Handler) roleOf(msg *tgbotapi.Message) services.Role {
+	if msg.From == nil {
+		return services.RoleNone
+	}
+	return h.access.RoleIn(msg.From.ID, msg.Chat.ID)
+}
+
+// authorize checks the sender's role against the message. Refusals are
+// logged and answered politely, except for passive group chatter.
+func (h *Handler) authorize(msg *tgbotapi.Message, userLang lang.Language) (bool, error) {
+	required := requiredRole(msg)
+	role := h.roleOf(msg)
+	if role.Allows(required) {
+		return true, nil
+	}
+
+	fields := []zap.Field{
+		zap.Int64("chat_id", msg.Chat.ID),
+		zap.String("command", msg.Command()),
+		zap.String("role", string(role)),
+		zap.String("required", string(required)),
+	}
+	if msg.From != nil {
+		fields = append(fields, zap.Int64("user_id", msg.From.ID), zap.String("username", msg.From.UserName))
+	}
+	h.logger.Warn("Access denied", fields...)
+
+	if !msg.IsCommand() && isGroupChat(msg.Chat) {
+		return false, nil
+	}
+	if role == services.RoleNone {
+		var userID int64
+		if msg.From != nil {
+			userID = msg.From.ID
+		}
+		return false, h.reply(msg, lang.Get(userLang, "access_denied", userID))
+	}
+	return false, h.reply(msg, lang.Get(userLang, "access_insufficient", required))
+}
+
+// handleGrant gives a role: /grant <id> <role>, or /grant <role> in reply to
+// a user's message. Negative IDs are group chats.
+func (h *Handler) handleGrant(msg *tgbotapi.Message, userLang lang.Language) error {
+	args := strings.Fields(msg.CommandArguments())
+	id, args, ok := accessTarget(msg, args)
+	if !ok || len(args) != 1 {
+		return h.reply(msg, lang.Get(userLang, "grant_usage"))
+	}
+
+	role, err := services.ParseRole(args[0])
+	if err != nil || role == services.RoleNone {
+		return h.reply(msg, lang.Get(userLang, "grant_usage"))
+	}
+
+	err = h.access.Grant(id, role)
+	if errors.Is(err, services.ErrChatRoleTooHigh) {
+		return h.reply(msg, lang.Get(userLang, "grant_chat_role", services.MaxChatRole))
+	}
+	if err != nil {
+		h.logger.Error("Failed to save access grant", zap.Error(err))
+		return h.reply(msg, lang.Get(userLang, "access_save_failed"))
+	}
+	h.logger.Info("Role granted",
+		zap.Int64("admin_id", msg.From.ID),
+		zap.Int64("target_id", id),
+		zap.String("role", string(role)),
+	)
+	return h.reply(msg, lang.Get(userLang, "grant_done", id, role))
+}
+
+// handleRevoke removes a role: /revoke <id>, or /revoke in reply to a user's
+// message
+func (h *Handler) handleRevoke(msg *tgbotapi.Message, userLang lang.Language) error {
+	args := strings.Fields(msg.CommandArguments())
+	id, args, ok := accessTarget(msg, args)
+	if !ok || len(args) != 0 {
+		return h.reply(msg, lang.Get(userLang, "revoke_usage"))
+	}
+	if id == msg.From.ID {
+		return h.reply(msg, lang.Get(userLang, "revoke_self"))
+	}
+
+	if err := h.access.Revoke(id); err != nil {
+		h.logger.Error("Failed to save access revocation", zap.Error(err))
+		return h.reply(msg, lang.Get(userLang, "access_save_failed"))
+	}
+	h.logger.Info("Role revoked",
+		zap.Int64("admin_id", msg.From.ID),
+		zap.Int64("target_id", id),
+	)
+	return h.reply(msg, lang.Get(userLang, "revoke_done", id))
+}
+
+// handleAccess lists every user and chat with an explicit role
+func (h *Handler) handleAccess(msg *tgbotapi.Message, userLang lang.Language) error {
+	entries := h.access.List()
+	if len(entries) == 0 {
+		return h.reply(msg, lang.Get(userLang, "access_list_empty"))
+	}
+
+	lines := []string{lang.Get(userLang, "access_list_header")}
+	for _, entry := range entries {
+		if entry.Revoked {
+			lines = append(lines, fmt.Sprintf("%d — %s", entry.ID, lang.Get(userLang, "access_revoked")))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%d — %s", entry.ID, entry.Role))
+	}
+	return h.reply(msg, strings.Join(lines, "\n"))
+}
+
+// accessTarget picks the user or chat a /grant or /revoke applies to: the
+// author of the replied-to message, "chat" for the current group, or an
+// explicit numeric ID as the first argument
+func accessTarget(msg *tgbotapi.Message, args []string) (int64, []string, bool) {
+	if len(args) > 0 {
+		if args[0] == "chat" && isGroupChat(msg.Chat) {
+			return msg.Chat.ID, args[1:], true
+		}
+		if id, err := strconv.ParseInt(args[0], 10, 64); err == nil {
+			return id, args[1:], true
+		}
+	}
+	if msg.ReplyToMessage != nil && msg.ReplyToMessage.From != nil {
+		return msg.ReplyToMessage.From.ID, args, true
+	}
+	return 0, args, false
+}
@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/clevertechru/tgbot_aml/internal/lang"
+	"github.com/clevertechru/tgbot_aml/internal/services"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	callbackBroadcastSend   = "broadcast_send"
+	callbackBroadcastCancel = "broadcast_cancel"
+
+	// broadcastTTL is how long a broadcast waits for confirmation
+	broadcastTTL = 10 * time.Minute
+	// broadcastInterval keeps broadcasts below Telegram's global send limit
+	broadcastInterval = 50 * time.Millisecond
+
+	statsTopUsers = 5
+)
+
+// handleStats shows today's check volume, suspicious rate and top users
+func (h *Handler) handleStats(msg *tgbotapi.Message, userLang lang.Language) error {
+	today := h.stats.Today()
+
+	lines := []string{lang.Get(userLang, "stats_summary",
+		today.Day, today.Checks, today.Suspicious, today.SuspiciousRate()*100)}
+	if top := today.TopUsers(statsTopUsers); len(top) > 0 {
+		lines = append(lines, "", lang.Get(userLang, "stats_top_users"))
+		for i, user := range top {
+			lines = append(lines, fmt.Sprintf("%d. %d — %d", i+1, user.UserID, user.Checks))
+		}
+	}
+	return h.reply(msg, strings.Join(lines, "\n"))
+}
+
+// handleProviders shows call counts, error rates and latency per provider
+func (h *Handler) handleProviders(msg *tgbotapi.Message, userLang lang.Language) error {
+	var lines []string
+	for _, health := range h.amlService.ProviderHealth() {
+		status := "✅"
+		if !health.Healthy() {
+			status = "❌"
+		}
+		lines = append(lines, lang.Get(userLang, "providers_entry",
+			status, health.Name, health.Calls, health.Errors,
+			health.AvgLatency().Milliseconds(), health.LastLatency.Milliseconds()))
+		if health.LastError != "" {
+			lines = append(lines, lang.Get(userLang, "providers_last_error",
+				health.LastErrorAt.UTC().Format("2006-01-02 15:04:05"), health.LastError))
+		}
+	}
+	return h.reply(msg, strings.Join(lines, "\n"))
+}
+
+// handleCache shows the cache size, or empties it with /cache flush
+func (h *Handler) handleCache(msg *tgbotapi.Message, userLang lang.Language) error {
+	cache := h.amlService.Cache()
+	switch strings.TrimSpace(msg.CommandArguments()) {
+	case "":
+		return h.reply(msg, lang.Get(userLang, "cache_status", cache.Len()))
+	case "flush":
+		n := cache.Flush()
+		h.logger.Info("Cache flushed",
+			zap.Int64("admin_id", msg.From.ID),
+			zap.Int("entries", n),
+		)
+		return h.reply(msg, lang.Get(userLang, "cache_flushed", n))
+	default:
+		return h.reply(msg, lang.Get(userLang, "cache_usage"))
+	}
+}
+
+// handleReload re-reads the config file and translations
+func (h *Handler) handleReload(msg *tgbotapi.Message, userLang lang.Language) error {
+	if err := h.reload(); err != nil {
+		h.logger.Error("Reload failed", zap.Error(err), zap.Int64("admin_id", msg.From.ID))
+		return h.reply(msg, lang.Get(userLang, "reload_failed", err))
+	}
+	h.logger.Info("Configuration reloaded", zap.Int64("admin_id", msg.From.ID))
+	return h.reply(msg, lang.Get(userLang, "reload_done"))
+}
+
+type pendingBroadcast struct {
+	text    string
+	created time.Time
+}
+
+// pendingBroadcasts holds broadcasts waiting for confirmation, one per admin
+type pendingBroadcasts struct {
+	mu      sync.Mutex
+	pending map[int64]pendingBroadcast
+}
+
+func newPendingBroadcasts() *pendingBroadcasts {
+	return &pendingBroadcasts{pending: make(map[int64]pendingBroadcast)}
+}
+
+func (p *pendingBroadcasts) put(adminID int64, text string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.pending[adminID] = pendingBroadcast{text: text, created: time.Now()}
+}
+
+// take removes and returns the admin's pending broadcast if it has not expired
+func (p *pendingBroadcasts) take(adminID int64) (string, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	broadcast, ok := p.pending[adminID]
+	delete(p.pending, adminID)
+	if !ok || time.Since(broadcast.created) > broadcastTTL {
+		return "", false
+	}
+	return broadcast.text, true
+}
+
+// handleBroadcast previews a message to all known chats and asks for
+// confirmation before sending it
+func (h *Handler) handleBroadcast(msg *tgbotapi.Message, userLang lang.Language) error {
+	text := strings.TrimSpace(msg.CommandArguments())
+	if text == "" {
+		return h.reply(msg, lang.Get(userLang, "broadcast_usage"))
+	}
+
+	h.broadcasts.put(msg.From.ID, text)
+
+	preview := tgbotapi.NewMessage(msg.Chat.ID,
+		lang.Format(userLang, "broadcast_confirm", lang.Params{"count": len(broadcastRecipients(h.knownChats.IDs(), h.access))})+"\n\n"+text)
+	preview.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(lang.Get(userLang, "broadcast_send_button"), callbackBroadcastSend),
+		tgbotapi.NewInlineKeyboardButtonData(lang.Get(userLang, "broadcast_cancel_button"), callbackBroadcastCancel),
+	))
+	_, err := h.bot.Send(preview)
+	return err
+}
+
+// handleBroadcastCallback sends or discards the admin's pending broadcast and
+// returns the text to answer the button press with
+func (h *Handler) handleBroadcastCallback(ctx context.Context, query *tgbotapi.CallbackQuery, userLang lang.Language) string {
+	chatID := query.From.ID
+	if query.Message != nil {
+		chatID = query.Message.Chat.ID
+	}
+	if !h.isBotAdmin(query.From, chatID) {
+		return lang.Get(userLang, "admin_only")
+	}
+
+	text, ok := h.broadcasts.take(query.From.ID)
+	if !ok {
+		return lang.Get(userLang, "broadcast_expired")
+	}
+
+	if query.Data == callbackBroadcastCancel {
+		h.editCallbackMessage(query, lang.Get(userLang, "broadcast_cancelled"))
+		return lang.Get(userLang, "broadcast_cancelled")
+	}
+
+	h.logger.Info("Broadcast started", zap.Int64("admin_id", query.From.ID))
+	h.editCallbackMessage(query, lang.Get(userLang, "broadcast_sending"))
+	go func() {
+		sent, failed := h.sendBroadcast(ctx, text)
+		h.logger.Info("Broadcast finished",
+			zap.Int64("admin_id", query.From.ID),
+			zap.Int("sent", sent),
+			zap.Int("failed", failed),
+		)
+		h.editCallbackMessage(query, lang.Get(userLang, "broadcast_done", sent, failed))
+	}()
+	return lang.Get(userLang, "broadcast_sending")
+}
+
+// broadcastRecipients keeps the known chats that may still use the bot.
+// Chats are remembered on any message, including refused ones, so the list
+// alone would reach chats that were never let in or were revoked since.
+func broadcastRecipients(chatIDs []int64, access *services.AccessControl) []int64 {
+	var recipients []int64
+	for _, chatID := range chatIDs {
+		if access.ChatRole(chatID).Allows(services.RoleViewer) {
+			recipients = append(recipients, chatID)
+		}
+	}
+	return recipients
+}
+
+func (h *Handler) sendBroadcast(ctx context.Context, text string) (sent, failed int) {
+	ticker := time.NewTicker(broadcastInterval)
+	defer ticker.Stop()
+
+	for _, chatID := range broadcastRecipients(h.knownChats.IDs(), h.access) {
+		select {
+		case <-ctx.Done():
+			return sent, failed
+		case <-ticker.C:
+		}
+
+		_, err := h.bot.Send(tgbotapi.NewMessage(chatID, text))
+		if err == nil {
+			sent++
+			continue
+		}
+
+		failed++
+		var apiErr *tgbotapi.Error
+		if errors.As(err, &apiErr) && apiErr.Code == http.StatusForbidden {
+			// The bot was blocked or removed, stop messaging this chat
+			if err := h.knownChats.Forget(chatID); err != nil {
+				h.logger.Warn("Failed to forget chat", zap.Error(err), zap.Int64("chat_id", chatID))
+			}
+		}
+		h.logger.Warn("Broadcast delivery failed", zap.Error(err), zap.Int64("chat_id", chatID))
+	}
+	return sent, failed
+}
+
+func (h *Handler) editCallbackMessage(query *tgbotapi.CallbackQuery, text string) {
+	if query.Message == nil {
+		return
+	}
+	edit := tgbotapi.NewEditMessageText(query.Message.Chat.ID, query.Message.MessageID, text)
+	if _, err := h.bot.Send(edit); err != nil {
+		h.logger.Debug("Failed to edit message", zap.Error(err))
+	}
+}
@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/clevertechru/tgbot_aml/internal/services"
+	"github.com/clevertechru/tgbot_aml/internal/storage"
+)
+
+func TestBroadcastRecipients(t *testing.T) {
+	config := services.AccessConfig{
+		Users: map[int64]services.Role{1: services.RoleAdmin, 2: services.RoleViewer},
+		Chats: map[int64]services.Role{-100: services.RoleViewer},
+	}
+	access, err := services.NewAccessControl(config,
+		storage.NewJSONFile[services.AccessGrants](filepath.Join(t.TempDir(), "access.json")))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := access.Revoke(2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// 3 and -200 wrote to the bot without being let in, 2 was revoked
+	known := []int64{-200, -100, 1, 2, 3}
+	if got, want := broadcastRecipients(known, access), []int64{-100, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("broadcastRecipients() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/clevertechru/tgbot_aml/internal/domain"
+	"github.com/clevertechru/tgbot_aml/internal/lang"
+	"github.com/clevertechru/tgbot_aml/internal/services"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+func isGroupChat(chat *tgbotapi.Chat) bool {
+	return chat != nil && (chat.IsGroup() || chat.IsSuperGroup())
+}
+
+// handleGroupMessage screens every address or hash found in an ordinary group
+// message and replies with a compact badge for the ones worth reporting.
+// Groups opt in with /autoscan on unless the config enables it for all.
+func (h *Handler) handleGroupMessage(ctx context.Context, msg *tgbotapi.Message, userLang lang.Language) error {
+	settings := h.chatSettings.Scan(msg.Chat.ID)
+	if !settings.Enabled {
+		return nil
+	}
+
+	text := msg.Text
+	if text == "" {
+		text = msg.Caption
+	}
+	targets := domain.DetectTargets(text)
+	if len(targets) == 0 {
+		return nil
+	}
+
+	// Passive scans never answer with a refusal, they just stay quiet
+	if ok, _ := h.authorize(msg, userLang); !ok {
+		return nil
+	}
+	if refusal := h.rateLimit(msg, userLang); refusal != "" {
+		h.logger.Debug("Autoscan rate limited", zap.Int64("chat_id", msg.Chat.ID))
+		return nil
+	}
+	if refusal := h.chargeChecks(msg, len(targets), userLang); refusal != "" {
+		h.logger.Debug("Autoscan quota exceeded", zap.Int64("chat_id", msg.Chat.ID))
+		return nil
+	}
+
+	var badges []string
+	for _, target := range targets {
+		result, err := h.amlService.Screen(ctx, target)
+		if err != nil {
+			h.logger.Warn("Autoscan check failed",
+				zap.Error(err),
+				zap.Int64("chat_id", msg.Chat.ID),
+				zap.String("target", target.Value),
+			)
+			continue
+		}
+		// Passive hits are not requests of the sender, so they stay out of
+		// the audit log and statistics
+		if !settings.ShouldReport(result.IsSuspicious, result.RiskScore) {
+			continue
+		}
+		badges = append(badges, formatBadge(result, userLang))
+	}
+	if len(badges) == 0 {
+		return nil
+	}
+
+	response := tgbotapi.NewMessage(msg.Chat.ID, strings.Join(badges, "\n"))
+	response.ReplyToMessageID = msg.MessageID
+	response.DisableNotification = true
+	_, err := h.bot.Send(response)
+	return err
+}
+
+func formatBadge(result *domain.ScreeningResult, userLang lang.Language) string {
+	key := "autoscan_badge_clean"
+	if result.IsSuspicious {
+		key = "autoscan_badge_suspicious"
+	}
+	return lang.Get(userLang, key, result.Target.ShortValue(), result.RiskScore)
+}
+
+// handleAutoscan lets group admins inspect and change passive scanning:
+// /autoscan [on|off], /autoscan threshold <0..1>, /autoscan quiet on|off
+func (h *Handler) handleAutoscan(msg *tgbotapi.Message, userLang lang.Language) error {
+	if !isGroupChat(msg.Chat) {
+		return h.reply(msg, lang.Get(userLang, "autoscan_group_only"))
+	}
+
+	args := strings.Fields(strings.ToLower(msg.CommandArguments()))
+	if len(args) == 0 {
+		return h.reply(msg, formatScanSettings(h.chatSettings.Scan(msg.Chat.ID), userLang))
+	}
+
+	if !h.isChatAdmin(msg) {
+		return h.reply(msg, lang.Get(userLang, "autoscan_admin_only"))
+	}
+
+	var update func(*services.ScanSettings)
+	switch {
+	case len(args) == 1 && (args[0] == "on" || args[0] == "off"):
+		enabled := args[0] == "on"
+		update = func(s *services.ScanSettings) { s.Enabled = enabled }
+	case len(args) == 2 && args[0] == "quiet" && (args[1] == "on" || args[1] == "off"):
+		quiet := args[1] == "on"
+		update = func(s *services.ScanSettings) { s.OnlySuspicious = quiet }
+	case len(args) == 2 && args[0] == "threshold":
+		threshold, err := strconv.ParseFloat(args[1], 64)
+		if err != nil || threshold < 0 || threshold > 1 {
+			return h.reply(msg, lang.Get(userLang, "autoscan_usage"))
+		}
+		update = func(s *services.ScanSettings) { s.MinRiskScore = threshold }
+	default:
+		return h.reply(msg, lang.Get(userLang, "autoscan_usage"))
+	}
+
+	settings, err := h.chatSettings.UpdateScan(msg.Chat.ID, update)
+	if err != nil {
+		h.logger.Error("Failed to save autoscan settings",
+			zap.Error(err),
+			zap.Int64("chat_id", msg.Chat.ID),
+		)
+	}
+	return h.reply(msg, formatScanSettings(settings, userLang))
+}
+
+func formatScanSettings(settings services.ScanSettings, userLang lang.Language) string {
+	state := lang.Get(userLang, "state_off")
+	if settings.Enabled {
+		state = lang.Get(userLang, "state_on")
+	}
+	quiet := lang.Get(userLang, "state_off")
+	if settings.OnlySuspicious {
+		quiet = lang.Get(userLang, "state_on")
+	}
+	return lang.Get(userLang, "autoscan_status", state, quiet, settings.MinRiskScore)
+}
+
+// isChatAdmin reports whether the sender administers the chat the message came from
+func (h *Handler) isChatAdmin(msg *tgbotapi.Message) bool {
+	if msg.From == nil {
+		return false
+	}
+	return h.isAdminOf(msg.Chat.ID, msg.From.ID)
+}
+
+// isAdminOf reports whether a user administers a chat
+func (h *Handler) isAdminOf(chatID, userID int64) bool {
+	member, err := h.bot.GetChatMember(tgbotapi.GetChatMemberConfig{
+		ChatConfigWithUser: tgbotapi.ChatConfigWithUser{
+			ChatID: chatID,
+			UserID: userID,
+		},
+	})
+	if err != nil {
+		h.logger.Warn("Failed to get chat member",
+			zap.Error(err),
+			zap.Int64("chat_id", chatID),
+			zap.Int64("user_id", userID),
+		)
+		return false
+	}
+	return member.IsAdministrator() || member.IsCreator()
+}
@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/clevertechru/tgbot_aml/internal/lang"
+	"github.com/clevertechru/tgbot_aml/internal/services"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	callbackBulkCancel = "bulk_cancel"
+
+	// bulkProgressInterval throttles progress edits to stay within Telegram limits
+	bulkProgressInterval = 2 * time.Second
+)
+
+func isBulkDocument(doc *tgbotapi.Document) bool {
+	if doc == nil {
+		return false
+	}
+	switch strings.ToLower(path.Ext(doc.FileName)) {
+	case ".csv", ".txt":
+		return true
+	}
+	return strings.HasPrefix(doc.MimeType, "text/")
+}
+
+// handleDocument screens every entry of an uploaded CSV or text file in the
+// background and replies with a result CSV
+func (h *Handler) handleDocument(ctx context.Context, msg *tgbotapi.Message, userLang lang.Language) error {
+	if !isBulkDocument(msg.Document) || msg.From == nil {
+		return h.reply(msg, lang.Get(userLang, "bulk_unsupported_file"))
+	}
+
+	limits := h.bulkJobs.Limits()
+	if int64(msg.Document.FileSize) > limits.MaxFileSize {
+		return h.reply(msg, lang.Get(userLang, "bulk_file_too_large", limits.MaxFileSize/1024))
+	}
+
+	data, err := h.downloadFile(ctx, msg.Document.FileID, limits.MaxFileSize)
+	if err != nil {
+		h.logger.Error("Failed to download bulk file",
+			zap.Error(err),
+			zap.Int64("chat_id", msg.Chat.ID),
+		)
+		return h.reply(msg, lang.Get(userLang, "bulk_download_failed"))
+	}
+
+	entries, err := h.bulkJobs.Parse(bytes.NewReader(data))
+	switch {
+	case errors.Is(err, services.ErrBulkTooMany):
+		return h.reply(msg, lang.Get(userLang, "bulk_too_many", limits.MaxEntries))
+	case err != nil:
+		return h.reply(msg, lang.Get(userLang, "bulk_empty"))
+	}
+
+	userID := msg.From.ID
+	jobCtx, err := h.bulkJobs.Start(ctx, userID)
+	if errors.Is(err, services.ErrBulkJobRunning) {
+		return h.reply(msg, lang.Get(userLang, "bulk_already_running"))
+	}
+	if refusal := h.chargeChecks(msg, len(entries), userLang); refusal != "" {
+		h.bulkJobs.Finish(userID)
+		return h.reply(msg, refusal)
+	}
+
+	progress := tgbotapi.NewMessage(msg.Chat.ID, lang.Get(userLang, "bulk_progress", 0, len(entries)))
+	progress.ReplyMarkup = bulkCancelKeyboard(userLang)
+	sent, err := h.bot.Send(progress)
+	if err != nil {
+		h.bulkJobs.Finish(userID)
+		return err
+	}
+
+	go h.runBulkJob(jobCtx, msg, sent.MessageID, entries, userLang)
+	return nil
+}
+
+func (h *Handler) runBulkJob(ctx context.Context, msg *tgbotapi.Message, progressID int, entries []string, userLang lang.Language) {
+	defer h.bulkJobs.Finish(msg.From.ID)
+
+	var (
+		mu       sync.Mutex
+		lastEdit time.Time
+		shown    int
+	)
+	rows := h.bulkJobs.Run(ctx, entries, func(done, total int) {
+		// Decide under the lock, but edit outside it so that a slow
+		// Telegram call doesn't hold up the other workers
+		mu.Lock()
+		if done <= shown || (done < total && time.Since(lastEdit) < bulkProgressInterval) {
+			mu.Unlock()
+			return
+		}
+		lastEdit, shown = time.Now(), done
+		mu.Unlock()
+
+		edit := tgbotapi.NewEditMessageTextAndMarkup(msg.Chat.ID, progressID,
+			lang.Get(userLang, "bulk_progress", done, total), bulkCancelKeyboard(userLang))
+		if _, err := h.bot.Send(edit); err != nil {
+			h.logger.Debug("Failed to update bulk progress", zap.Error(err))
+		}
+	})
+
+	var suspicious, failed int
+	for _, row := range rows {
+		if row.Err != nil {
+			failed++
+			continue
+		}
+		h.recordCheck(msg, row.Result)
+		if row.Result.IsSuspicious {
+			suspicious++
+		}
+	}
+
+	key := "bulk_done"
+	if ctx.Err() != nil {
+		key = "bulk_cancelled"
+	}
+	summary := lang.Format(userLang, key, lang.Params{
+		"count":      len(rows),
+		"suspicious": suspicious,
+		"failed":     failed,
+	})
+	if _, err := h.bot.Send(tgbotapi.NewEditMessageText(msg.Chat.ID, progressID, summary)); err != nil {
+		h.logger.Debug("Failed to update bulk progress", zap.Error(err))
+	}
+
+	stamp := time.Now().UTC().Format("20060102_150405")
+	for _, format := range []struct {
+		ext   string
+		write func(io.Writer, []services.BulkRow) error
+	}{
+		{"csv", services.WriteBulkCSV},
+		{"xlsx", services.WriteBulkXLSX},
+	} {
+		var buf bytes.Buffer
+		if err := format.write(&buf, rows); err != nil {
+			h.logger.Error("Failed to write bulk results", zap.Error(err), zap.String("format", format.ext))
+			continue
+		}
+		name := fmt.Sprintf("screening_%s.%s", stamp, format.ext)
+		document := tgbotapi.NewDocument(msg.Chat.ID, tgbotapi.FileBytes{Name: name, Bytes: buf.Bytes()})
+		document.ReplyToMessageID = msg.MessageID
+		if _, err := h.bot.Send(document); err != nil {
+			h.logger.Error("Failed to send bulk results",
+				zap.Error(err),
+				zap.Int64("chat_id", msg.Chat.ID),
+			)
+		}
+	}
+}
+
+func bulkCancelKeyboard(userLang lang.Language) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(lang.Get(userLang, "bulk_cancel_button"), callbackBulkCancel),
+	))
+}
+
+func (h *Handler) handleCancel(msg *tgbotapi.Message, userLang lang.Language) error {
+	if msg.From == nil || !h.bulkJobs.Cancel(msg.From.ID) {
+		return h.reply(msg, lang.Get(userLang, "bulk_nothing_to_cancel"))
+	}
+	return h.reply(msg, lang.Get(userLang, "bulk_cancelling"))
+}
+
+// downloadFile fetches a Telegram file, refusing anything larger than limit
+func (h *Handler) downloadFile(ctx context.Context, fileID string, limit int64) ([]byte, error) {
+	url, err := h.bot.GetFileDirectURL(fileID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file url: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download file: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			h.logger.Error("failed to close response body", zap.Error(err))
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("file exceeds %d bytes", limit)
+	}
+	return data, nil
+}
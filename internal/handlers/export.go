@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"io"
+	"strings"
+	"time"
+
+	"github.com/clevertechru/tgbot_aml/internal/export"
+	"github.com/clevertechru/tgbot_aml/internal/lang"
+	"github.com/clevertechru/tgbot_aml/internal/services"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// exportDefaultDays is the number of days exported when no start date is given
+const exportDefaultDays = 30
+
+// handleExport sends the check history as a JSON Lines or CSV file:
+// /export [from] [to] [jsonl|csv] with dates as YYYY-MM-DD, both inclusive
+func (h *Handler) handleExport(msg *tgbotapi.Message, userLang lang.Language) error {
+	from, to, format, ok := parseExportArgs(strings.Fields(msg.CommandArguments()), time.Now())
+	if !ok {
+		return h.reply(msg, lang.Get(userLang, "export_usage"))
+	}
+	end := to.AddDate(0, 0, 1)
+	keep := exportFilter(h.roleOf(msg), msg.Chat.ID)
+
+	// Count first so an empty range gets an answer instead of an empty file
+	var count int
+	err := h.audit.Scan(from, end, func(entry services.AuditEntry) error {
+		if keep == nil || keep(entry) {
+			count++
+		}
+		return nil
+	})
+	if err != nil {
+		h.logger.Error("Failed to read audit log", zap.Error(err))
+		return h.reply(msg, lang.Get(userLang, "export_failed"))
+	}
+	if count == 0 {
+		return h.reply(msg, lang.Get(userLang, "export_empty"))
+	}
+
+	// The file is written while it is uploaded, so the history never has to
+	// fit in memory
+	reader, writer := io.Pipe()
+	defer reader.Close()
+	go func() {
+		_, err := export.Export(writer, format, h.audit, from, end, keep)
+		writer.CloseWithError(err)
+	}()
+
+	document := tgbotapi.NewDocument(msg.Chat.ID, tgbotapi.FileReader{
+		Name:   "export_" + from.Format(time.DateOnly) + "_" + to.Format(time.DateOnly) + format.Extension(),
+		Reader: reader,
+	})
+	document.Caption = lang.Format(userLang, "export_caption", lang.Params{
+		"count": count,
+		"from":  from.Format(time.DateOnly),
+		"to":    to.Format(time.DateOnly),
+	})
+	document.ReplyToMessageID = msg.MessageID
+	if _, err := h.bot.Send(document); err != nil {
+		h.logger.Error("Failed to send export",
+			zap.Error(err),
+			zap.Int64("chat_id", msg.Chat.ID),
+			zap.Int64("user_id", msg.From.ID),
+		)
+		return h.reply(msg, lang.Get(userLang, "export_failed"))
+	}
+
+	h.logger.Info("History exported",
+		zap.Int64("user_id", msg.From.ID),
+		zap.String("format", string(format)),
+		zap.Int("records", count),
+	)
+	return nil
+}
+
+// exportFilter limits exports to the checks made in the caller's chat, so
+// analysts don't get the checks of other users and groups. Admins export
+// the whole log.
+func exportFilter(role services.Role, chatID int64) func(services.AuditEntry) bool {
+	if role.Allows(services.RoleAdmin) {
+		return nil
+	}
+	return func(entry services.AuditEntry) bool {
+		return entry.ChatID == chatID
+	}
+}
+
+// parseExportArgs reads an optional start date, end date and format. The
+// format may appear anywhere; by default the last exportDefaultDays days up
+// to today are exported as JSON Lines.
+func parseExportArgs(args []string, now time.Time) (from, to time.Time, format export.Format, ok bool) {
+	format = export.FormatJSONLines
+	var dates []time.Time
+	for _, arg := range args {
+		if f, ok := export.ParseFormat(arg); ok {
+			format = f
+			continue
+		}
+		date, err := time.Parse(time.DateOnly, arg)
+		if err != nil || len(dates) == 2 {
+			return from, to, format, false
+		}
+		dates = append(dates, date)
+	}
+
+	to = now.UTC().Truncate(24 * time.Hour)
+	switch len(dates) {
+	case 0:
+		from = to.AddDate(0, 0, 1-exportDefaultDays)
+	case 1:
+		from = dates[0]
+	case 2:
+		from, to = dates[0], dates[1]
+	}
+	return from, to, format, !from.After(to)
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/clevertechru/tgbot_aml/internal/export"
+	"github.com/clevertechru/tgbot_aml/internal/services"
+)
+
+func TestParseExportArgs(t *testing.T) {
+	now := time.Date(2024, 5, 31, 15, 4, 5, 0, time.UTC)
+	day := func(s string) time.Time {
+		d, _ := time.Parse(time.DateOnly, s)
+		return d
+	}
+
+	tests := []struct {
+		name   string
+		args   []string
+		from   string
+		to     string
+		format export.Format
+		ok     bool
+	}{
+		{"defaults", nil, "2024-05-02", "2024-05-31", export.FormatJSONLines, true},
+		{"format only", []string{"csv"}, "2024-05-02", "2024-05-31", export.FormatCSV, true},
+		{"start date", []string{"2024-05-20"}, "2024-05-20", "2024-05-31", export.FormatJSONLines, true},
+		{"range and format", []string{"2024-01-01", "2024-01-31", "csv"}, "2024-01-01", "2024-01-31", export.FormatCSV, true},
+		{"format first", []string{"json", "2024-01-01", "2024-01-02"}, "2024-01-01", "2024-01-02", export.FormatJSONLines, true},
+		{"reversed range", []string{"2024-02-01", "2024-01-01"}, "", "", "", false},
+		{"bad date", []string{"yesterday"}, "", "", "", false},
+		{"too many dates", []string{"2024-01-01", "2024-01-02", "2024-01-03"}, "", "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to, format, ok := parseExportArgs(tt.args, now)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			if !from.Equal(day(tt.from)) || !to.Equal(day(tt.to)) || format != tt.format {
+				t.Errorf("got %s..%s %s, want %s..%s %s",
+					from.Format(time.DateOnly), to.Format(time.DateOnly), format, tt.from, tt.to, tt.format)
+			}
+		})
+	}
+}
+
+func TestExportFilter(t *testing.T) {
+	if keep := exportFilter(services.RoleAdmin, 5); keep != nil {
+		t.Error("expected admins to export the whole log")
+	}
+
+	keep := exportFilter(services.RoleAnalyst, 5)
+	if keep == nil {
+		t.Fatal("expected analysts to get a filter")
+	}
+	if !keep(services.AuditEntry{ChatID: 5}) || keep(services.AuditEntry{ChatID: 6}) {
+		t.Error("expected analysts to export their own chat only")
+	}
+}
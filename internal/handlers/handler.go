@@ -2,24 +2,66 @@ package handlers
 
 import (
 	"context"
+	"errors"
+	"html"
+	"strings"
 
+	"github.com/clevertechru/tgbot_aml/internal/domain"
 	"github.com/clevertechru/tgbot_aml/internal/lang"
 	"github.com/clevertechru/tgbot_aml/internal/services"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"go.uber.org/zap"
 )
 
+// Services bundles the services the handler dispatches to
+type Services struct {
+	AML          *services.AMLService
+	ChatSettings *services.ChatSettings
+	BulkJobs     *services.BulkJobs
+	Limits       *services.Limits
+	Access       *services.AccessControl
+	Stats        *services.Stats
+	KnownChats   *services.KnownChats
+	Languages    *services.Languages
+	Audit        *services.AuditLog
+	Policy       *services.Policy
+	// Reload re-reads the config file and translations
+	Reload func() error
+}
+
 type Handler struct {
-	bot        *tgbotapi.BotAPI
-	amlService *services.AMLService
-	logger     *zap.Logger
+	bot          *tgbotapi.BotAPI
+	amlService   *services.AMLService
+	chatSettings *services.ChatSettings
+	bulkJobs     *services.BulkJobs
+	limits       *services.Limits
+	access       *services.AccessControl
+	stats        *services.Stats
+	knownChats   *services.KnownChats
+	languages    *services.Languages
+	audit        *services.AuditLog
+	policy       *services.Policy
+	reload       func() error
+	broadcasts   *pendingBroadcasts
+	logger       *zap.Logger
 }
 
-func NewHandler(bot *tgbotapi.BotAPI, amlService *services.AMLService, logger *zap.Logger) *Handler {
+func NewHandler(bot *tgbotapi.BotAPI, svc Services, logger *zap.Logger) *Handler {
 	return &Handler{
-		bot:        bot,
-		amlService: amlService,
-		logger:     logger,
+		bot:          bot,
+		amlService:   svc.AML,
+		chatSettings: svc.ChatSettings,
+		bulkJobs:     svc.BulkJobs,
+		limits:       svc.Limits,
+		access:       svc.Access,
+		stats:        svc.Stats,
+		knownChats:   svc.KnownChats,
+		languages:    svc.Languages,
+		audit:        svc.Audit,
+		policy:       svc.Policy,
+		reload:       svc.Reload,
+		broadcasts:   newPendingBroadcasts(),
+		logger:       logger,
 	}
 }
 
@@ -28,9 +70,30 @@ func (h *Handler) HandleMessage(ctx context.Context, msg *tgbotapi.Message) erro
 		return nil
 	}
 
-	userLang := lang.English
-	if msg.From != nil && msg.From.LanguageCode == "ru" {
-		userLang = lang.Russian
+	userLang := h.languageFor(msg.From, msg.Chat)
+
+	if err := h.knownChats.Touch(msg.Chat.ID, msg.Chat.Title, msg.Chat.Type); err != nil {
+		h.logger.Warn("Failed to save known chat", zap.Error(err), zap.Int64("chat_id", msg.Chat.ID))
+	}
+
+	if !msg.IsCommand() && isGroupChat(msg.Chat) {
+		return h.handleGroupMessage(ctx, msg, userLang)
+	}
+
+	if ok, err := h.authorize(msg, userLang); !ok {
+		return err
+	}
+
+	if refusal := h.rateLimit(msg, userLang); refusal != "" {
+		return h.reply(msg, refusal)
+	}
+
+	if msg.Document != nil && msg.Chat.IsPrivate() {
+		return h.handleDocument(ctx, msg, userLang)
+	}
+
+	if len(msg.Photo) > 0 && msg.Chat.IsPrivate() {
+		return h.handlePhoto(ctx, msg, userLang)
 	}
 
 	switch msg.Command() {
@@ -38,11 +101,74 @@ func (h *Handler) HandleMessage(ctx context.Context, msg *tgbotapi.Message) erro
 		return h.handleStart(msg, userLang)
 	case "check":
 		return h.handleCheck(ctx, msg, userLang)
+	case "autoscan":
+		return h.handleAutoscan(msg, userLang)
+	case "cancel":
+		return h.handleCancel(msg, userLang)
+	case "quota":
+		return h.handleQuota(msg, userLang)
+	case "grant":
+		return h.handleGrant(msg, userLang)
+	case "revoke":
+		return h.handleRevoke(msg, userLang)
+	case "access":
+		return h.handleAccess(msg, userLang)
+	case "stats":
+		return h.handleStats(msg, userLang)
+	case "providers":
+		return h.handleProviders(msg, userLang)
+	case "cache":
+		return h.handleCache(msg, userLang)
+	case "reload":
+		return h.handleReload(msg, userLang)
+	case "broadcast":
+		return h.handleBroadcast(msg, userLang)
+	case "language":
+		return h.handleLanguage(msg, userLang)
+	case "report":
+		return h.handleReport(ctx, msg, userLang)
+	case "export":
+		return h.handleExport(msg, userLang)
+	case "rules":
+		return h.handleRules(ctx, msg, userLang)
+	case "why":
+		return h.handleWhy(ctx, msg, userLang)
 	default:
 		return h.handleUnknownCommand(msg, userLang)
 	}
 }
 
+// HandleCallback handles presses on inline keyboard buttons
+func (h *Handler) HandleCallback(ctx context.Context, query *tgbotapi.CallbackQuery) error {
+	if query == nil || query.From == nil {
+		return nil
+	}
+
+	var chat *tgbotapi.Chat
+	if query.Message != nil {
+		chat = query.Message.Chat
+	}
+	userLang := h.languageFor(query.From, chat)
+
+	var answer string
+	switch {
+	case query.Data == callbackBulkCancel:
+		answer = lang.Get(userLang, "bulk_nothing_to_cancel")
+		if h.bulkJobs.Cancel(query.From.ID) {
+			answer = lang.Get(userLang, "bulk_cancelling")
+		}
+	case query.Data == callbackBroadcastSend, query.Data == callbackBroadcastCancel:
+		answer = h.handleBroadcastCallback(ctx, query, userLang)
+	case strings.HasPrefix(query.Data, callbackUserLanguage), strings.HasPrefix(query.Data, callbackChatLanguage):
+		answer = h.handleLanguageCallback(query, userLang)
+	case strings.HasPrefix(query.Data, callbackReport):
+		answer = h.handleReportCallback(query, userLang)
+	}
+
+	_, err := h.bot.Request(tgbotapi.NewCallback(query.ID, answer))
+	return err
+}
+
 func (h *Handler) handleStart(msg *tgbotapi.Message, userLang lang.Language) error {
 	reply := lang.Get(userLang, "welcome")
 	response := tgbotapi.NewMessage(msg.Chat.ID, reply)
@@ -51,34 +177,147 @@ func (h *Handler) handleStart(msg *tgbotapi.Message, userLang lang.Language) err
 }
 
 func (h *Handler) handleCheck(ctx context.Context, msg *tgbotapi.Message, userLang lang.Language) error {
-	if len(msg.CommandArguments()) == 0 {
+	input := strings.TrimSpace(msg.CommandArguments())
+	if input == "" {
 		reply := lang.Get(userLang, "check_usage")
 		response := tgbotapi.NewMessage(msg.Chat.ID, reply)
 		_, err := h.bot.Send(response)
 		return err
 	}
 
-	target := msg.CommandArguments()
-	result, err := h.amlService.CheckAddress(ctx, target)
+	if refusal := h.chargeChecks(msg, 1, userLang); refusal != "" {
+		return h.reply(msg, refusal)
+	}
+
+	return h.replyRich(msg, h.checkInput(ctx, msg, input, userLang))
+}
+
+// checkInput screens free-form user input: a payment URI, an address or a
+// transaction hash. Input that is none of these is checked as an address.
+func (h *Handler) checkInput(ctx context.Context, msg *tgbotapi.Message, input string, userLang lang.Language) richText {
+	request, err := domain.ParsePaymentURI(input)
+	switch {
+	case err == nil:
+		return h.checkPayment(ctx, msg, request, userLang)
+	case errors.Is(err, domain.ErrInvalidURI):
+		return plainText(lang.Get(userLang, "payment_invalid", err))
+	}
+
+	target, ok := domain.ParseTarget(input)
+	if !ok {
+		target = domain.Target{Value: input, Kind: domain.KindAddress}
+	}
+	result, err := h.amlService.Screen(ctx, target)
 	if err != nil {
 		h.logger.Error("Failed to check address",
 			zap.Error(err),
-			zap.String("address", target),
+			zap.String("address", target.Value),
 		)
-		reply := lang.Get(userLang, "error_checking", err)
-		response := tgbotapi.NewMessage(msg.Chat.ID, reply)
-		_, err := h.bot.Send(response)
-		return err
+		return plainText(lang.Get(userLang, "error_checking", err))
+	}
+	text := renderResult(result, userLang)
+	text.actions = reportActions(h.recordCheck(msg, result), result, userLang)
+	return text
+}
+
+func (h *Handler) checkPayment(ctx context.Context, msg *tgbotapi.Message, request *domain.PaymentRequest, userLang lang.Language) richText {
+	lines := []richText{
+		plainText(lang.Get(userLang, "payment_header", request.Chain)),
+		codeLine(userLang, "payment_recipient", request.Address),
+	}
+	if request.TokenContract != "" {
+		lines = append(lines, codeLine(userLang, "payment_token", request.TokenContract))
+	}
+	if request.Amount != "" {
+		amount := request.Amount
+		if request.Unit != "" {
+			amount += " " + request.Unit
+		}
+		lines = append(lines, plainText(lang.Get(userLang, "payment_amount", amount)))
+	}
+	if request.Memo != "" {
+		lines = append(lines, plainText(lang.Get(userLang, "payment_memo", request.Memo)))
+	}
+	lines[0].html = "<b>" + lines[0].html + "</b>"
+
+	result, err := h.amlService.CheckPayment(ctx, request)
+	if err != nil {
+		h.logger.Error("Failed to check payment request",
+			zap.Error(err),
+			zap.String("address", request.Address),
+			zap.String("token", request.TokenContract),
+		)
+		return joinRich("\n", append(lines, plainText(""), plainText(lang.Get(userLang, "error_checking", err)))...)
+	}
+
+	recipient := renderResult(result.Recipient, userLang)
+	recipient.actions = reportActions(h.recordCheck(msg, result.Recipient), result.Recipient, userLang)
+	lines = append(lines, plainText(""), plainText(lang.Get(userLang, "payment_recipient_result")), recipient)
+	if result.Token != nil {
+		token := renderResult(result.Token, userLang)
+		token.actions = reportActions(h.recordCheck(msg, result.Token), result.Token, userLang)
+		lines = append(lines, plainText(""), plainText(lang.Get(userLang, "payment_token_result")), token)
+	}
+	return joinRich("\n", lines...)
+}
+
+// codeLine renders a translation whose only argument is shown in monospace
+// in the HTML version, so it can be copied with a tap
+func codeLine(userLang lang.Language, key, value string) richText {
+	return richText{
+		html:  lang.Get(userLang, key, "<code>"+html.EscapeString(value)+"</code>"),
+		plain: lang.Get(userLang, key, value),
+	}
+}
+
+// recordCheck counts a finished check in the daily statistics and writes it
+// to the audit log. It returns the audit ID, or "" if it could not be saved.
+func (h *Handler) recordCheck(msg *tgbotapi.Message, result *domain.ScreeningResult) string {
+	analyst := analystOf(msg.From)
+	if err := h.stats.Record(analyst.UserID, result.IsSuspicious); err != nil {
+		h.logger.Warn("Failed to save statistics", zap.Error(err))
+	}
+
+	entry, err := h.audit.Record(analyst, msg.Chat.ID, result)
+	if err != nil {
+		h.logger.Error("Failed to write audit log",
+			zap.Error(err),
+			zap.Int64("chat_id", msg.Chat.ID),
+			zap.Int64("user_id", analyst.UserID),
+		)
+		return ""
 	}
+	return entry.ID
+}
+
+// analystOf identifies the user who requested a check
+func analystOf(user *tgbotapi.User) services.Analyst {
+	if user == nil {
+		return services.Analyst{}
+	}
+	return services.Analyst{
+		UserID:   user.ID,
+		Username: user.UserName,
+		Name:     strings.TrimSpace(user.FirstName + " " + user.LastName),
+	}
+}
 
+func resultText(result *domain.ScreeningResult, userLang lang.Language) string {
 	key := "result_clean"
 	if result.IsSuspicious {
 		key = "result_suspicious"
 	}
-	reply := lang.Get(userLang, key, result.RiskScore, result.Details)
-	response := tgbotapi.NewMessage(msg.Chat.ID, reply)
-	_, err = h.bot.Send(response)
-	return err
+	text := lang.Get(userLang, key, result.RiskScore, strings.Join(result.Details, "; "))
+	if result.Verdict != "" {
+		text += "\n" + verdictText(result, userLang)
+	}
+	if lines := graphLines(result.Graph, userLang); len(lines) > 0 {
+		text += "\n\n" + lang.Get(userLang, "result_graph") + "\n" + strings.Join(lines, "\n")
+	}
+	if lines := transferLines(result.Transfers, userLang); len(lines) > 0 {
+		text += "\n\n" + lang.Get(userLang, "result_transfers") + "\n" + strings.Join(lines, "\n")
+	}
+	return text
 }
 
 func (h *Handler) handleUnknownCommand(msg *tgbotapi.Message, userLang lang.Language) error {
@@ -87,3 +326,9 @@ func (h *Handler) handleUnknownCommand(msg *tgbotapi.Message, userLang lang.Lang
 	_, err := h.bot.Send(response)
 	return err
 }
+
+func (h *Handler) reply(msg *tgbotapi.Message, text string) error {
+	response := tgbotapi.NewMessage(msg.Chat.ID, text)
+	_, err := h.bot.Send(response)
+	return err
+}
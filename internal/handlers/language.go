@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/clevertechru/tgbot_aml/internal/lang"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	callbackUserLanguage = "language:"
+	callbackChatLanguage = "chat_language:"
+
+	languageButtonsPerRow = 2
+)
+
+// languageFor resolves the language to answer a user in: their stored
+// preference, then their Telegram language, then the chat default, then English
+func (h *Handler) languageFor(user *tgbotapi.User, chat *tgbotapi.Chat) lang.Language {
+	if user != nil {
+		if code, ok := h.languages.User(user.ID); ok {
+			if language, ok := lang.Match(code); ok {
+				return language
+			}
+		}
+		if language, ok := lang.Match(user.LanguageCode); ok {
+			return language
+		}
+	}
+	if chat != nil {
+		if code, ok := h.languages.Chat(chat.ID); ok {
+			if language, ok := lang.Match(code); ok {
+				return language
+			}
+		}
+	}
+	return lang.English
+}
+
+// handleLanguage shows the language picker. In a group, /language chat lets
+// administrators pick the chat's default language.
+func (h *Handler) handleLanguage(msg *tgbotapi.Message, userLang lang.Language) error {
+	prompt, prefix := lang.Get(userLang, "language_selection"), callbackUserLanguage
+	switch strings.TrimSpace(msg.CommandArguments()) {
+	case "":
+	case "chat":
+		if !isGroupChat(msg.Chat) {
+			return h.reply(msg, lang.Get(userLang, "language_group_only"))
+		}
+		if !h.isChatAdmin(msg) {
+			return h.reply(msg, lang.Get(userLang, "language_admin_only"))
+		}
+		prompt, prefix = lang.Get(userLang, "language_chat_selection"), callbackChatLanguage
+	default:
+		return h.reply(msg, lang.Get(userLang, "language_usage"))
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for i, language := range lang.Available() {
+		if i%languageButtonsPerRow == 0 {
+			rows = append(rows, nil)
+		}
+		rows[len(rows)-1] = append(rows[len(rows)-1], tgbotapi.NewInlineKeyboardButtonData(
+			lang.Get(language, "language_name"), prefix+string(language)))
+	}
+
+	response := tgbotapi.NewMessage(msg.Chat.ID, prompt)
+	response.ReplyToMessageID = msg.MessageID
+	response.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	_, err := h.bot.Send(response)
+	return err
+}
+
+// handleLanguageCallback stores the language picked on the keyboard and
+// returns the text to answer the button press with
+func (h *Handler) handleLanguageCallback(query *tgbotapi.CallbackQuery, userLang lang.Language) string {
+	code, isChat := strings.CutPrefix(query.Data, callbackChatLanguage)
+	if !isChat {
+		code = strings.TrimPrefix(query.Data, callbackUserLanguage)
+	}
+	language, ok := lang.Match(code)
+	if !ok {
+		return lang.Get(userLang, "language_unknown")
+	}
+
+	if !isChat {
+		if err := h.languages.SetUser(query.From.ID, string(language)); err != nil {
+			h.logger.Error("Failed to save language", zap.Error(err), zap.Int64("user_id", query.From.ID))
+			return lang.Get(userLang, "language_save_failed")
+		}
+		answer := lang.Get(language, "language_set", lang.Get(language, "language_name"))
+		h.editCallbackMessage(query, answer)
+		return answer
+	}
+
+	if query.Message == nil || !isGroupChat(query.Message.Chat) {
+		return lang.Get(userLang, "language_group_only")
+	}
+	chatID := query.Message.Chat.ID
+	if !h.isAdminOf(chatID, query.From.ID) {
+		return lang.Get(userLang, "language_admin_only")
+	}
+	if err := h.languages.SetChat(chatID, string(language)); err != nil {
+		h.logger.Error("Failed to save chat language", zap.Error(err), zap.Int64("chat_id", chatID))
+		return lang.Get(userLang, "language_save_failed")
+	}
+	h.logger.Info("Chat language changed",
+		zap.Int64("chat_id", chatID),
+		zap.Int64("admin_id", query.From.ID),
+		zap.String("language", string(language)),
+	)
+	answer := lang.Get(language, "language_chat_set", lang.Get(language, "language_name"))
+	h.editCallbackMessage(query, answer)
+	return answer
+}
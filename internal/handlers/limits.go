@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/clevertechru/tgbot_aml/internal/lang"
+	"github.com/clevertechru/tgbot_aml/internal/services"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// rateLimit applies the per-user and per-chat rate limits to a message. It
+// returns a localized refusal, or "" when the message may be handled.
+func (h *Handler) rateLimit(msg *tgbotapi.Message, userLang lang.Language) string {
+	var userID int64
+	if msg.From != nil {
+		userID = msg.From.ID
+	}
+	return h.limitRefusal(h.limits.Allow(userID, msg.Chat.ID), userLang)
+}
+
+// chargeChecks counts n checks against the sender's daily quota. It returns
+// a localized refusal, or "" when the checks may run.
+func (h *Handler) chargeChecks(msg *tgbotapi.Message, n int, userLang lang.Language) string {
+	if msg.From == nil {
+		return ""
+	}
+	return h.limitRefusal(h.limits.Consume(msg.From.ID, n), userLang)
+}
+
+func (h *Handler) limitRefusal(err error, userLang lang.Language) string {
+	if err == nil {
+		return ""
+	}
+
+	var limitErr *services.LimitError
+	if !errors.As(err, &limitErr) {
+		h.logger.Warn("Failed to persist quota usage", zap.Error(err))
+		return ""
+	}
+
+	wait := formatWait(limitErr.RetryAfter, userLang)
+	if errors.Is(err, services.ErrQuotaExceeded) {
+		return lang.Get(userLang, "quota_exceeded", wait)
+	}
+	return lang.Get(userLang, "rate_limited", wait)
+}
+
+func formatWait(d time.Duration, userLang lang.Language) string {
+	switch {
+	case d >= time.Hour:
+		d = d.Round(time.Minute)
+		return lang.Get(userLang, "duration_hours", int(d.Hours()), int(d.Minutes())%60)
+	case d >= time.Minute:
+		return lang.Get(userLang, "duration_minutes", int(d.Round(time.Minute).Minutes()))
+	default:
+		seconds := int(d.Round(time.Second).Seconds())
+		if seconds < 1 {
+			seconds = 1
+		}
+		return lang.Get(userLang, "duration_seconds", seconds)
+	}
+}
+
+// handleQuota shows the sender's quota. Bot admins may inspect or reset any
+// user's quota with /quota <user_id> [reset] or by replying to their message.
+func (h *Handler) handleQuota(msg *tgbotapi.Message, userLang lang.Language) error {
+	if msg.From == nil {
+		return nil
+	}
+
+	args := strings.Fields(msg.CommandArguments())
+	userID := msg.From.ID
+	if msg.ReplyToMessage != nil && msg.ReplyToMessage.From != nil {
+		userID = msg.ReplyToMessage.From.ID
+	}
+	if len(args) > 0 && args[0] != "reset" {
+		id, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			return h.reply(msg, lang.Get(userLang, "quota_usage"))
+		}
+		userID = id
+		args = args[1:]
+	}
+
+	reset := len(args) == 1 && args[0] == "reset"
+	if len(args) > 1 || (len(args) == 1 && !reset) {
+		return h.reply(msg, lang.Get(userLang, "quota_usage"))
+	}
+	if (userID != msg.From.ID || reset) && !h.isBotAdmin(msg.From, msg.Chat.ID) {
+		return h.reply(msg, lang.Get(userLang, "admin_only"))
+	}
+
+	quotas := h.limits.Quotas()
+	status := quotas.Status(userID)
+	if reset {
+		var err error
+		if status, err = quotas.Reset(userID); err != nil {
+			h.logger.Error("Failed to reset quota",
+				zap.Error(err),
+				zap.Int64("user_id", userID),
+			)
+		}
+		h.logger.Info("Quota reset",
+			zap.Int64("admin_id", msg.From.ID),
+			zap.Int64("user_id", userID),
+		)
+	}
+
+	limit := lang.Get(userLang, "quota_unlimited")
+	if !status.Unlimited() {
+		limit = strconv.Itoa(status.Limit)
+	}
+	return h.reply(msg, lang.Get(userLang, "quota_status",
+		userID, status.Tier, status.Used, limit, status.ResetAt.Format("2006-01-02 15:04")))
+}
+
+// isBotAdmin resolves the role the same way authorize does, so a button
+// press is allowed exactly when the command that showed it was
+func (h *Handler) isBotAdmin(user *tgbotapi.User, chatID int64) bool {
+	return user != nil && h.access.RoleIn(user.ID, chatID).Allows(services.RoleAdmin)
+}
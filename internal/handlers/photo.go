@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/clevertechru/tgbot_aml/internal/domain"
+	"github.com/clevertechru/tgbot_aml/internal/lang"
+	"github.com/clevertechru/tgbot_aml/internal/services"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	// maxPhotoSize bounds the image downloaded for QR decoding
+	maxPhotoSize = 10 << 20
+	// maxPhotoCodes bounds the QR codes screened per image, since each one
+	// is a check
+	maxPhotoCodes = 5
+)
+
+// handlePhoto decodes QR codes in a photo and screens the addresses or
+// payment URIs in the first maxPhotoCodes of them
+func (h *Handler) handlePhoto(ctx context.Context, msg *tgbotapi.Message, userLang lang.Language) error {
+	// Telegram lists sizes from smallest to largest
+	photo := msg.Photo[len(msg.Photo)-1]
+
+	data, err := h.downloadFile(ctx, photo.FileID, maxPhotoSize)
+	if err != nil {
+		h.logger.Error("Failed to download photo",
+			zap.Error(err),
+			zap.Int64("chat_id", msg.Chat.ID),
+		)
+		return h.reply(msg, lang.Get(userLang, "qr_download_failed"))
+	}
+
+	codes, err := services.DecodeQRCodes(data)
+	if err != nil {
+		h.logger.Warn("Failed to decode QR codes", zap.Error(err))
+	}
+	if len(codes) == 0 {
+		return h.reply(msg, lang.Get(userLang, "qr_not_found"))
+	}
+	found := len(codes)
+	if found > maxPhotoCodes {
+		codes = codes[:maxPhotoCodes]
+	}
+	if refusal := h.chargeChecks(msg, len(codes), userLang); refusal != "" {
+		return h.reply(msg, refusal)
+	}
+
+	sections := []richText{plainText(lang.Get(userLang, "qr_found", found))}
+	for i, code := range codes {
+		sections = append(sections, h.screenQRCode(ctx, msg, i+1, code, userLang))
+	}
+	if skipped := found - len(codes); skipped > 0 {
+		sections = append(sections, plainText(lang.Get(userLang, "qr_skipped", len(codes), skipped)))
+	}
+
+	return h.replyRich(msg, joinRich("\n\n", sections...))
+}
+
+func (h *Handler) screenQRCode(ctx context.Context, msg *tgbotapi.Message, n int, code string, userLang lang.Language) richText {
+	number := plainText(fmt.Sprintf("%d.", n))
+	_, err := domain.ParsePaymentURI(code)
+	if _, ok := domain.ParseTarget(code); !ok && errors.Is(err, domain.ErrNotPaymentURI) {
+		return joinRich(" ", number, plainText(lang.Get(userLang, "qr_unrecognized", code)))
+	}
+	return joinRich(" ", number, h.checkInput(ctx, msg, code, userLang))
+}
@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+
+	"github.com/clevertechru/tgbot_aml/internal/domain"
+	"github.com/clevertechru/tgbot_aml/internal/lang"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// riskBarWidth is the number of segments in a rendered risk bar
+const riskBarWidth = 10
+
+// richText is a reply rendered as Telegram HTML, with a plain-text version
+// to fall back on when Telegram cannot parse the HTML, and the buttons to
+// attach to it
+type richText struct {
+	html    string
+	plain   string
+	actions []tgbotapi.InlineKeyboardButton
+}
+
+// plainText wraps unformatted text, escaping it for the HTML version
+func plainText(text string) richText {
+	return richText{html: html.EscapeString(text), plain: text}
+}
+
+// joinRich joins both versions of the texts with sep and collects their
+// buttons
+func joinRich(sep string, texts ...richText) richText {
+	htmlParts := make([]string, len(texts))
+	plainParts := make([]string, len(texts))
+	var actions []tgbotapi.InlineKeyboardButton
+	for i, text := range texts {
+		htmlParts[i] = text.html
+		plainParts[i] = text.plain
+		actions = append(actions, text.actions...)
+	}
+	return richText{
+		html:    strings.Join(htmlParts, sep),
+		plain:   strings.Join(plainParts, sep),
+		actions: actions,
+	}
+}
+
+// replyRich sends text as HTML and resends it as plain text if Telegram
+// rejects the markup
+func (h *Handler) replyRich(msg *tgbotapi.Message, text richText) error {
+	response := tgbotapi.NewMessage(msg.Chat.ID, text.html)
+	response.ParseMode = tgbotapi.ModeHTML
+	response.DisableWebPagePreview = true
+	response.ReplyToMessageID = msg.MessageID
+	if len(text.actions) > 0 {
+		rows := make([][]tgbotapi.InlineKeyboardButton, len(text.actions))
+		for i, action := range text.actions {
+			rows[i] = tgbotapi.NewInlineKeyboardRow(action)
+		}
+		response.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	}
+
+	_, err := h.bot.Send(response)
+	if !isParseError(err) {
+		return err
+	}
+
+	h.logger.Warn("Telegram rejected formatted reply, sending plain text",
+		zap.Error(err),
+		zap.Int64("chat_id", msg.Chat.ID),
+	)
+	response.Text = text.plain
+	response.ParseMode = ""
+	_, err = h.bot.Send(response)
+	return err
+}
+
+// isParseError reports whether Telegram refused a message because of its
+// formatting
+func isParseError(err error) bool {
+	var apiErr *tgbotapi.Error
+	return errors.As(err, &apiErr) &&
+		apiErr.Code == http.StatusBadRequest &&
+		strings.Contains(apiErr.Message, "can't parse entities")
+}
+
+// renderResult renders a screening result with a verdict header, risk bar,
+// category table, details and an explorer link
+func renderResult(result *domain.ScreeningResult, userLang lang.Language) richText {
+	var b strings.Builder
+
+	header := "result_header_clean"
+	if result.IsSuspicious {
+		header = "result_header_suspicious"
+	}
+	fmt.Fprintf(&b, "<b>%s</b>\n", html.EscapeString(lang.Get(userLang, header)))
+
+	fmt.Fprintf(&b, "<code>%s</code>", html.EscapeString(result.Target.Value))
+	if result.Target.Chain != domain.ChainUnknown {
+		fmt.Fprintf(&b, " · %s", html.EscapeString(string(result.Target.Chain)))
+	}
+	b.WriteString("\n\n")
+
+	fmt.Fprintf(&b, "%s: %s <b>%s</b>\n",
+		html.EscapeString(lang.Get(userLang, "result_risk")),
+		riskBar(result.RiskScore),
+		lang.FormatNumber(userLang, result.RiskScore, 2))
+	if result.Verdict != "" {
+		fmt.Fprintf(&b, "%s\n", html.EscapeString(verdictText(result, userLang)))
+	}
+
+	if len(result.Categories) > 0 {
+		fmt.Fprintf(&b, "\n<pre>%s</pre>\n", html.EscapeString(categoryTable(result.Categories, userLang)))
+	}
+
+	if lines := graphLines(result.Graph, userLang); len(lines) > 0 {
+		fmt.Fprintf(&b, "\n<b>%s</b>\n", html.EscapeString(lang.Get(userLang, "result_graph")))
+		for _, line := range lines {
+			fmt.Fprintf(&b, "%s\n", html.EscapeString(line))
+		}
+	}
+
+	if lines := transferLines(result.Transfers, userLang); len(lines) > 0 {
+		fmt.Fprintf(&b, "\n<b>%s</b>\n", html.EscapeString(lang.Get(userLang, "result_transfers")))
+		for _, line := range lines {
+			fmt.Fprintf(&b, "%s\n", html.EscapeString(line))
+		}
+	}
+
+	if len(result.Details) > 0 {
+		fmt.Fprintf(&b, "\n<b>%s</b>\n", html.EscapeString(lang.Get(userLang, "result_details")))
+		for _, detail := range result.Details {
+			fmt.Fprintf(&b, "• %s\n", html.EscapeString(detail))
+		}
+	}
+
+	b.WriteString("\n")
+	if name, link, ok := result.Target.Explorer(); ok {
+		fmt.Fprintf(&b, "<a href=\"%s\">%s</a>\n",
+			html.EscapeString(link), html.EscapeString(lang.Get(userLang, "result_explorer", name)))
+	}
+	fmt.Fprintf(&b, "<i>%s</i>", html.EscapeString(lang.Get(userLang, "result_copy_hint")))
+
+	return richText{html: b.String(), plain: resultText(result, userLang)}
+}
+
+// verdictText describes the policy verdict and the rules that led to it
+func verdictText(result *domain.ScreeningResult, userLang lang.Language) string {
+	text := lang.Get(userLang, "result_verdict", lang.Get(userLang, "verdict_"+string(result.Verdict)))
+	if len(result.FiredRules) > 0 {
+		text += " · " + lang.Get(userLang, "result_rules", strings.Join(result.FiredRules, ", "))
+	}
+	return text
+}
+
+// graphLines describes where traced funds came from and went, one category
+// per line
+func graphLines(graph *domain.GraphExposure, userLang lang.Language) []string {
+	if graph == nil || len(graph.Incoming)+len(graph.Outgoing) == 0 {
+		return nil
+	}
+
+	var lines []string
+	for _, exposure := range graph.Incoming {
+		lines = append(lines, lang.Format(userLang, "graph_incoming", lang.Params{
+			"share":    percent(exposure.Share, userLang),
+			"category": exposure.Category,
+			"count":    exposure.Hops,
+		}))
+	}
+	for _, exposure := range graph.Outgoing {
+		lines = append(lines, lang.Format(userLang, "graph_outgoing", lang.Params{
+			"share":    percent(exposure.Share, userLang),
+			"category": exposure.Category,
+			"count":    exposure.Hops,
+		}))
+	}
+	if graph.Truncated {
+		lines = append(lines, lang.Format(userLang, "graph_truncated", lang.Params{"count": graph.Addresses}))
+	}
+	return lines
+}
+
+// transferLines gives each screened token transfer a risk row, followed by
+// the categories of the parties that were flagged
+func transferLines(transfers []domain.TransferRisk, userLang lang.Language) []string {
+	var lines []string
+	for _, row := range transfers {
+		token := row.Transfer.Symbol
+		if token == "" {
+			token = shortAddress(row.Transfer.Token)
+		}
+		lines = append(lines, lang.Format(userLang, "transfer_row", lang.Params{
+			"amount":   lang.FormatNumber(userLang, row.Transfer.Value, 2),
+			"token":    token,
+			"sender":   shortAddress(row.Transfer.From),
+			"receiver": shortAddress(row.Transfer.To),
+			"risk":     lang.FormatNumber(userLang, row.RiskScore, 2),
+		}))
+
+		parties := []struct {
+			key    string
+			result *domain.ScreeningResult
+		}{
+			{"party_sender", row.Sender},
+			{"party_receiver", row.Receiver},
+			{"party_token", row.Token},
+		}
+		for _, party := range parties {
+			if party.result == nil || !party.result.IsSuspicious {
+				continue
+			}
+			lines = append(lines, lang.Format(userLang, "transfer_flagged", lang.Params{
+				"party":      lang.Get(userLang, party.key),
+				"categories": strings.Join(party.result.Categories, ", "),
+			}))
+		}
+	}
+	return lines
+}
+
+// shortAddress keeps the start and end of a long address or hash, which is
+// enough to tell parties apart within one message
+func shortAddress(address string) string {
+	if len(address) <= 14 {
+		return address
+	}
+	return address[:6] + "…" + address[len(address)-4:]
+}
+
+// percent writes a share between 0 and 1 as a whole percentage
+func percent(share float64, userLang lang.Language) string {
+	return lang.FormatNumber(userLang, share*100, 0) + "%"
+}
+
+// riskBar draws a score between 0 and 1 as a bar of filled segments
+func riskBar(score float64) string {
+	filled := int(score*riskBarWidth + 0.5)
+	if filled < 0 {
+		filled = 0
+	}
+	if filled > riskBarWidth {
+		filled = riskBarWidth
+	}
+	return strings.Repeat("▰", filled) + strings.Repeat("▱", riskBarWidth-filled)
+}
+
+// categoryTable lays out categories and their severity in aligned columns
+func categoryTable(categories []string, userLang lang.Language) string {
+	header := lang.Get(userLang, "result_category")
+	width := len([]rune(header))
+	for _, category := range categories {
+		if n := len([]rune(category)); n > width {
+			width = n
+		}
+	}
+
+	rows := []string{fmt.Sprintf("%-*s  %s", width, header, lang.Get(userLang, "result_severity"))}
+	for _, category := range categories {
+		severity := lang.Get(userLang, "severity_"+domain.CategorySeverity(category).String())
+		rows = append(rows, fmt.Sprintf("%-*s  %s", width, category, severity))
+	}
+	return strings.Join(rows, "\n")
+}
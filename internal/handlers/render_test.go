@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/clevertechru/tgbot_aml/internal/domain"
+	"github.com/clevertechru/tgbot_aml/internal/lang"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestRenderResultEscapesInput(t *testing.T) {
+	result := &domain.ScreeningResult{
+		Target: domain.Target{
+			Value: "0x742d35Cc6634C0532925a3b844Bc454e4438f44e",
+			Kind:  domain.KindAddress,
+			Chain: domain.ChainEthereum,
+		},
+		IsSuspicious: true,
+		RiskScore:    0.72,
+		Details:      []string{"linked to <script>alert(1)</script> & co"},
+		Categories:   []string{"mixer", "sanctions"},
+	}
+
+	text := renderResult(result, lang.English)
+
+	for _, want := range []string{
+		"<b>⚠️ Suspicious activity detected</b>",
+		"<code>0x742d35Cc6634C0532925a3b844Bc454e4438f44e</code> · ETH",
+		"Risk: ▰▰▰▰▰▰▰▱▱▱ <b>0.72</b>",
+		"mixer      medium",
+		"sanctions  high",
+		"• linked to &lt;script&gt;alert(1)&lt;/script&gt; &amp; co",
+		`<a href="https://etherscan.io/address/0x742d35Cc6634C0532925a3b844Bc454e4438f44e">View on Etherscan</a>`,
+	} {
+		if !strings.Contains(text.html, want) {
+			t.Errorf("expected HTML to contain %q, got:\n%s", want, text.html)
+		}
+	}
+	if strings.Contains(text.html, "<script>") {
+		t.Error("details were not escaped")
+	}
+	if strings.Contains(text.plain, "<b>") || !strings.Contains(text.plain, "<script>") {
+		t.Errorf("expected unformatted plain text, got:\n%s", text.plain)
+	}
+}
+
+func TestRiskBar(t *testing.T) {
+	cases := map[float64]string{
+		0:    "▱▱▱▱▱▱▱▱▱▱",
+		0.26: "▰▰▰▱▱▱▱▱▱▱",
+		1:    "▰▰▰▰▰▰▰▰▰▰",
+		1.7:  "▰▰▰▰▰▰▰▰▰▰",
+	}
+	for score, want := range cases {
+		if got := riskBar(score); got != want {
+			t.Errorf("riskBar(%v) = %s, want %s", score, got, want)
+		}
+	}
+}
+
+func TestIsParseError(t *testing.T) {
+	parseErr := &tgbotapi.Error{Code: 400, Message: "Bad Request: can't parse entities: unexpected end tag"}
+	if !isParseError(fmt.Errorf("send: %w", parseErr)) {
+		t.Error("expected a parse error")
+	}
+	if isParseError(&tgbotapi.Error{Code: 403, Message: "Forbidden: bot was blocked by the user"}) {
+		t.Error("expected other API errors not to count")
+	}
+	if isParseError(errors.New("network down")) || isParseError(nil) {
+		t.Error("expected non-API errors not to count")
+	}
+}
+
+func TestGraphLines(t *testing.T) {
+	graph := &domain.GraphExposure{
+		Hops:      2,
+		Incoming:  []domain.CategoryExposure{{Category: "mixer", Share: 0.4, Hops: 2}},
+		Outgoing:  []domain.CategoryExposure{{Category: "gambling", Share: 0.25, Hops: 1}},
+		Addresses: 200,
+		Truncated: true,
+	}
+
+	got := strings.Join(graphLines(graph, lang.English), "\n")
+	want := "← 40% came from mixer, 2 hops away\n" +
+		"→ 25% went to gambling, 1 hop away\n" +
+		"Tracing stopped after 200 addresses, exposure may be higher."
+	if got != want {
+		t.Errorf("graphLines() =\n%s\nwant\n%s", got, want)
+	}
+
+	if lines := graphLines(&domain.GraphExposure{Hops: 2}, lang.English); lines != nil {
+		t.Errorf("expected no lines without exposure, got %v", lines)
+	}
+}
+
+func TestTransferLines(t *testing.T) {
+	clean := &domain.ScreeningResult{}
+	frozen := &domain.ScreeningResult{IsSuspicious: true, Categories: []string{domain.CategoryIssuerFrozen}}
+	transfers := []domain.TransferRisk{{
+		Transfer: domain.TokenTransfer{
+			Token:  "0xdac17f958d2ee523a2206206994597c13d831ec7",
+			Symbol: "USDT",
+			From:   "0xbad0000000000000000000000000000000000001",
+			To:     "0x00000000000000000000000000000000000000c1",
+			Value:  1250,
+		},
+		Sender:       clean,
+		Receiver:     frozen,
+		Token:        clean,
+		IsSuspicious: true,
+		RiskScore:    1,
+	}}
+
+	got := strings.Join(transferLines(transfers, lang.English), "\n")
+	want := "• 1,250.00 USDT: 0xbad0…0001 → 0x0000…00c1, risk 1.00\n" +
+		"  ⚠ receiver: issuer_frozen"
+	if got != want {
+		t.Errorf("transferLines() =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"time"
+
+	"github.com/clevertechru/tgbot_aml/internal/domain"
+	"github.com/clevertechru/tgbot_aml/internal/lang"
+	"github.com/clevertechru/tgbot_aml/internal/report"
+	"github.com/clevertechru/tgbot_aml/internal/services"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+const callbackReport = "report:"
+
+// reportActions returns the "Full report" button for an audited check, or
+// nothing if the check could not be written to the audit log
+func reportActions(auditID string, result *domain.ScreeningResult, userLang lang.Language) []tgbotapi.InlineKeyboardButton {
+	if auditID == "" {
+		return nil
+	}
+	return []tgbotapi.InlineKeyboardButton{tgbotapi.NewInlineKeyboardButtonData(
+		lang.Get(userLang, "report_button", result.Target.ShortValue()), callbackReport+auditID)}
+}
+
+// handleReport screens an address or transaction and sends the result as a
+// PDF report: /report <address|tx>
+func (h *Handler) handleReport(ctx context.Context, msg *tgbotapi.Message, userLang lang.Language) error {
+	input := strings.TrimSpace(msg.CommandArguments())
+	if input == "" || strings.ContainsAny(input, " \n") {
+		return h.reply(msg, lang.Get(userLang, "report_usage"))
+	}
+
+	if refusal := h.chargeChecks(msg, 1, userLang); refusal != "" {
+		return h.reply(msg, refusal)
+	}
+
+	target, ok := domain.ParseTarget(input)
+	if !ok {
+		target = domain.Target{Value: input, Kind: domain.KindAddress}
+	}
+	result, err := h.amlService.Screen(ctx, target)
+	if err != nil {
+		h.logger.Error("Failed to check address",
+			zap.Error(err),
+			zap.String("address", target.Value),
+		)
+		return h.reply(msg, lang.Get(userLang, "error_checking", err))
+	}
+
+	auditID := h.recordCheck(msg, result)
+	if auditID == "" {
+		return h.reply(msg, lang.Get(userLang, "report_failed"))
+	}
+	entry, found, err := h.audit.Find(auditID)
+	if err != nil || !found {
+		h.logger.Error("Failed to read audit log", zap.Error(err), zap.String("audit_id", auditID))
+		return h.reply(msg, lang.Get(userLang, "report_failed"))
+	}
+	return h.sendReport(msg.Chat.ID, msg.MessageID, entry, userLang)
+}
+
+// handleReportCallback sends the report of the check behind a "Full report"
+// button and returns the text to answer the button press with
+func (h *Handler) handleReportCallback(query *tgbotapi.CallbackQuery, userLang lang.Language) string {
+	if query.Message == nil {
+		return ""
+	}
+	chatID := query.Message.Chat.ID
+	if !h.access.RoleIn(query.From.ID, chatID).Allows(services.RoleViewer) {
+		return lang.Get(userLang, "access_insufficient", services.RoleViewer)
+	}
+
+	auditID := strings.TrimPrefix(query.Data, callbackReport)
+	entry, found, err := h.audit.Find(auditID)
+	if err != nil {
+		h.logger.Error("Failed to read audit log", zap.Error(err), zap.String("audit_id", auditID))
+		return lang.Get(userLang, "report_failed")
+	}
+	// Reports are only handed out in the chat the check was made in
+	if !found || entry.ChatID != chatID {
+		return lang.Get(userLang, "report_not_found")
+	}
+
+	if err := h.sendReport(chatID, query.Message.MessageID, entry, userLang); err != nil {
+		h.logger.Error("Failed to send report",
+			zap.Error(err),
+			zap.Int64("chat_id", chatID),
+			zap.Int64("user_id", query.From.ID),
+		)
+		return lang.Get(userLang, "report_failed")
+	}
+	return ""
+}
+
+// sendReport renders an audit log entry as a PDF and sends it as a document
+func (h *Handler) sendReport(chatID int64, replyTo int, entry services.AuditEntry, userLang lang.Language) error {
+	var buf bytes.Buffer
+	if err := report.WritePDF(&buf, report.FromAudit(entry, time.Now())); err != nil {
+		h.logger.Error("Failed to render report", zap.Error(err), zap.String("audit_id", entry.ID))
+		_, err := h.bot.Send(tgbotapi.NewMessage(chatID, lang.Get(userLang, "report_failed")))
+		return err
+	}
+
+	document := tgbotapi.NewDocument(chatID, tgbotapi.FileBytes{
+		Name:  "report_" + entry.ID + ".pdf",
+		Bytes: buf.Bytes(),
+	})
+	document.Caption = lang.Get(userLang, "report_caption", entry.ID)
+	document.ReplyToMessageID = replyTo
+	_, err := h.bot.Send(document)
+	return err
+}
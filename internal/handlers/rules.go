@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/clevertechru/tgbot_aml/internal/domain"
+	"github.com/clevertechru/tgbot_aml/internal/lang"
+	"github.com/clevertechru/tgbot_aml/internal/rules"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// handleRules shows the active risk policy, or dry-runs a rule expression
+// against a live check: /rules test <expression> <address>
+func (h *Handler) handleRules(ctx context.Context, msg *tgbotapi.Message, userLang lang.Language) error {
+	args := strings.TrimSpace(msg.CommandArguments())
+	if args == "" {
+		return h.reply(msg, h.describePolicy(userLang))
+	}
+
+	rest, ok := strings.CutPrefix(args, "test ")
+	if !ok {
+		return h.reply(msg, rulesUsage(userLang))
+	}
+	rest = strings.TrimSpace(rest)
+	split := strings.LastIndexAny(rest, " \t\n")
+	if split < 0 {
+		return h.reply(msg, rulesUsage(userLang))
+	}
+	source, input := strings.TrimSpace(rest[:split]), rest[split+1:]
+
+	expr, err := rules.Compile(source)
+	if err != nil {
+		return h.reply(msg, lang.Get(userLang, "rules_syntax_error", err))
+	}
+
+	// The dry run is a live check like /check, so it counts and is audited
+	if refusal := h.chargeChecks(msg, 1, userLang); refusal != "" {
+		return h.reply(msg, refusal)
+	}
+
+	target, ok := domain.ParseTarget(input)
+	if !ok {
+		target = domain.Target{Value: input, Kind: domain.KindAddress}
+	}
+	result, err := h.amlService.Screen(ctx, target)
+	if err != nil {
+		h.logger.Error("Failed to check address",
+			zap.Error(err),
+			zap.String("address", target.Value),
+		)
+		return h.reply(msg, lang.Get(userLang, "error_checking", err))
+	}
+	h.recordCheck(msg, result)
+
+	env := rules.EnvFor(result, time.Now())
+	key := "rules_test_no_match"
+	switch holds, known := expr.Evaluate(env); {
+	case !known:
+		key = "rules_test_unknown"
+	case holds:
+		key = "rules_test_match"
+	}
+	lines := []string{lang.Get(userLang, key, target.ShortValue()), "", lang.Get(userLang, "rules_test_values")}
+	for _, binding := range env.Bindings() {
+		lines = append(lines, fmt.Sprintf("%s = %s", binding.Name, binding.Value))
+	}
+	return h.reply(msg, strings.Join(lines, "\n"))
+}
+
+// describePolicy lists the rules of the active policy
+func (h *Handler) describePolicy(userLang lang.Language) string {
+	doc := h.policy.Document()
+	source := h.policy.Path()
+	if source == "" {
+		source = lang.Get(userLang, "rules_builtin")
+	}
+
+	lines := []string{
+		lang.Get(userLang, "rules_header", source),
+		lang.Get(userLang, "rules_default", lang.Get(userLang, "verdict_"+string(doc.Default))),
+	}
+	for _, rule := range doc.Rules {
+		lines = append(lines, fmt.Sprintf("• %s → %s\n  %s",
+			rule.Name, lang.Get(userLang, "verdict_"+string(rule.Verdict)), rule.Conditions()))
+	}
+	return strings.Join(append(lines, "", rulesUsage(userLang)), "\n")
+}
+
+// rulesUsage explains /rules test and lists the variables expressions can use
+func rulesUsage(userLang lang.Language) string {
+	variables := make([]string, 0, len(rules.Variables()))
+	for _, variable := range rules.Variables() {
+		variables = append(variables, fmt.Sprintf("%s (%s)", variable.Name, variable.Type))
+	}
+	return lang.Get(userLang, "rules_usage", strings.Join(variables, ", "))
+}
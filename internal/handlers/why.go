@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+
+	"github.com/clevertechru/tgbot_aml/internal/domain"
+	"github.com/clevertechru/tgbot_aml/internal/lang"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// handleWhy checks an address and explains its risk score factor by factor:
+// /why <address|tx>
+func (h *Handler) handleWhy(ctx context.Context, msg *tgbotapi.Message, userLang lang.Language) error {
+	input := strings.TrimSpace(msg.CommandArguments())
+	if input == "" || strings.ContainsAny(input, " \n") {
+		return h.reply(msg, lang.Get(userLang, "why_usage"))
+	}
+
+	if refusal := h.chargeChecks(msg, 1, userLang); refusal != "" {
+		return h.reply(msg, refusal)
+	}
+
+	target, ok := domain.ParseTarget(input)
+	if !ok {
+		target = domain.Target{Value: input, Kind: domain.KindAddress}
+	}
+	result, err := h.amlService.Screen(ctx, target)
+	if err != nil {
+		h.logger.Error("Failed to check address",
+			zap.Error(err),
+			zap.String("address", target.Value),
+		)
+		return h.reply(msg, lang.Get(userLang, "error_checking", err))
+	}
+	h.recordCheck(msg, result)
+
+	return h.reply(msg, explainResult(result, userLang))
+}
+
+// explainResult ranks the factors of a risk score by contribution and lists
+// the sources of each
+func explainResult(result *domain.ScreeningResult, userLang lang.Language) string {
+	lines := []string{lang.Format(userLang, "why_header", lang.Params{
+		"target": result.Target.ShortValue(),
+		"score":  result.RiskScore,
+	})}
+
+	var absent, unknown []string
+	rank := 0
+	for _, factor := range result.Factors {
+		name := lang.Get(userLang, "factor_"+string(factor.Kind))
+		switch {
+		case factor.Unknown:
+			unknown = append(unknown, name)
+		case factor.Contribution <= 0:
+			absent = append(absent, name)
+		default:
+			rank++
+			lines = append(lines, "", lang.Format(userLang, "why_factor", lang.Params{
+				"rank":         rank,
+				"factor":       name,
+				"contribution": factor.Contribution,
+				"weight":       factor.Weight,
+				"signal":       factor.Signal,
+			}))
+			if len(factor.Sources) > 0 {
+				lines = append(lines, lang.Get(userLang, "why_sources", factorSources(factor, userLang)))
+			}
+		}
+	}
+
+	if rank == 0 {
+		lines = append(lines, "", lang.Get(userLang, "why_no_factors"))
+	}
+	if len(absent) > 0 || len(unknown) > 0 {
+		lines = append(lines, "")
+	}
+	if len(absent) > 0 {
+		lines = append(lines, lang.Get(userLang, "why_absent", strings.Join(absent, ", ")))
+	}
+	if len(unknown) > 0 {
+		lines = append(lines, lang.Get(userLang, "why_unknown", strings.Join(unknown, ", ")))
+	}
+	if traced := graphLines(result.Graph, userLang); len(traced) > 0 {
+		lines = append(lines, "", lang.Get(userLang, "result_graph"))
+		lines = append(lines, traced...)
+	}
+	if transfers := transferLines(result.Transfers, userLang); len(transfers) > 0 {
+		lines = append(lines, "", lang.Get(userLang, "result_transfers"))
+		lines = append(lines, transfers...)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func factorSources(factor domain.RiskFactor, userLang lang.Language) string {
+	if factor.Kind == domain.FactorAge {
+		return lang.Get(userLang, "why_first_seen", strings.Join(factor.Sources, ", "))
+	}
+	return strings.Join(factor.Sources, "; ")
+}
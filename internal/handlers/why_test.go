@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/clevertechru/tgbot_aml/internal/domain"
+	"github.com/clevertechru/tgbot_aml/internal/lang"
+)
+
+func TestExplainResult(t *testing.T) {
+	result := &domain.ScreeningResult{
+		Target:    domain.Target{Value: "0xbad0", Kind: domain.KindAddress},
+		RiskScore: 1,
+		Factors: []domain.RiskFactor{
+			{Kind: domain.FactorSanctions, Weight: 1, Signal: 1, Contribution: 1, Sources: []string{"mock: sanctions"}},
+			{Kind: domain.FactorDirect, Weight: 1, Signal: 0.35, Contribution: 0.35, Sources: []string{"mock: sanctions"}},
+			{Kind: domain.FactorExposure, Weight: 0.5},
+			{Kind: domain.FactorBlocklist, Weight: 1},
+			{Kind: domain.FactorAge, Weight: 0.2, Unknown: true},
+		},
+	}
+
+	got := explainResult(result, lang.English)
+	want := strings.Join([]string{
+		"Why 0xbad0 scores 1.00:",
+		"",
+		"1. Sanctions list: +1.00 (weight 1.00 × signal 1.00)",
+		"   Sources: mock: sanctions",
+		"",
+		"2. Provider assessment: +0.35 (weight 1.00 × signal 0.35)",
+		"   Sources: mock: sanctions",
+		"",
+		"Not present: Exposure to risky counterparties, Our blocklist",
+		"No data: Address age",
+	}, "\n")
+	if got != want {
+		t.Errorf("explainResult() =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,17 @@
+package lang_test
+
+import (
+	"testing"
+
+	"github.com/clevertechru/tgbot_aml/internal/lang"
+	"github.com/clevertechru/tgbot_aml/internal/lang/langtest"
+)
+
+// TestBundledTranslationsAreConsistent checks that every bundled language
+// covers every key with matching format verbs and placeholders
+func TestBundledTranslationsAreConsistent(t *testing.T) {
+	if languages := lang.Available(); len(languages) != 8 {
+		t.Fatalf("expected 8 bundled languages, got %v", languages)
+	}
+	langtest.RequireConsistent(t)
+}
@@ -0,0 +1,123 @@
+package lang
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Params are the values of named placeholders such as {count} or {score}
+type Params map[string]interface{}
+
+// placeholder matches {name}, or {name:.2} to show a number with two decimals
+var placeholder = regexp.MustCompile(`\{(\w+)(?::\.(\d+))?\}`)
+
+// locale holds how a language writes numbers, dates and plurals
+type locale struct {
+	decimal  string
+	group    string
+	dateTime string
+	plural   pluralRule
+}
+
+var locales = map[Language]locale{
+	English:           {decimal: ".", group: ",", dateTime: "Jan 2, 2006 15:04", plural: pluralOneOther},
+	Russian:           {decimal: ",", group: "\u00a0", dateTime: "02.01.2006 15:04", plural: pluralEastSlavic},
+	Ukrainian:         {decimal: ",", group: "\u00a0", dateTime: "02.01.2006 15:04", plural: pluralEastSlavic},
+	German:            {decimal: ",", group: ".", dateTime: "02.01.2006 15:04", plural: pluralOneOther},
+	Spanish:           {decimal: ",", group: ".", dateTime: "02/01/2006 15:04", plural: pluralOneOther},
+	Turkish:           {decimal: ",", group: ".", dateTime: "02.01.2006 15:04", plural: pluralOneOther},
+	PortugueseBrazil:  {decimal: ",", group: ".", dateTime: "02/01/2006 15:04", plural: pluralPortuguese},
+	ChineseSimplified: {decimal: ".", group: ",", dateTime: "2006-01-02 15:04", plural: pluralNone},
+}
+
+// localeOf returns the locale of the first language in the fallback chain
+// that has one, or English
+func localeOf(lang Language) locale {
+	for _, language := range chain(lang) {
+		if l, ok := locales[language]; ok {
+			return l
+		}
+	}
+	return locales[English]
+}
+
+// Format returns the translation of key with its {name} placeholders replaced
+// by params, formatted for the language. A "count" param picks the plural form.
+func Format(lang Language, key string, params Params) string {
+	if lang == "" {
+		lang = English
+	}
+
+	var form PluralCategory
+	if n, ok := pluralOperand(params["count"]); ok {
+		form = Plural(lang, n)
+	}
+	msg := lookup(lang, key, form)
+
+	return placeholder.ReplaceAllStringFunc(msg, func(match string) string {
+		parts := placeholder.FindStringSubmatch(match)
+		value, ok := params[parts[1]]
+		if !ok {
+			return match
+		}
+		decimals := -1
+		if parts[2] != "" {
+			decimals, _ = strconv.Atoi(parts[2])
+		}
+		return formatValue(lang, value, decimals)
+	})
+}
+
+func formatValue(lang Language, value interface{}, decimals int) string {
+	switch v := value.(type) {
+	case time.Time:
+		return FormatDateTime(lang, v)
+	case float32, float64:
+		n, _ := pluralOperand(v)
+		return FormatNumber(lang, n, decimals)
+	}
+	if n, ok := pluralOperand(value); ok {
+		if decimals >= 0 {
+			return FormatNumber(lang, n, decimals)
+		}
+		return FormatNumber(lang, n, 0)
+	}
+	return fmt.Sprint(value)
+}
+
+// FormatNumber writes n with the language's decimal and group separators.
+// A negative decimals uses as many digits as needed.
+func FormatNumber(lang Language, n float64, decimals int) string {
+	l := localeOf(lang)
+	if math.IsNaN(n) || math.IsInf(n, 0) {
+		return strconv.FormatFloat(n, 'f', -1, 64)
+	}
+
+	s := strconv.FormatFloat(math.Abs(n), 'f', decimals, 64)
+	integer, fraction, _ := strings.Cut(s, ".")
+
+	var b strings.Builder
+	if n < 0 && strings.Trim(s, "0.") != "" {
+		b.WriteByte('-')
+	}
+	for i, digit := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			b.WriteString(l.group)
+		}
+		b.WriteRune(digit)
+	}
+	if fraction != "" {
+		b.WriteString(l.decimal)
+		b.WriteString(fraction)
+	}
+	return b.String()
+}
+
+// FormatDateTime writes a date and time the way the language usually does
+func FormatDateTime(lang Language, t time.Time) string {
+	return t.Format(localeOf(lang).dateTime)
+}
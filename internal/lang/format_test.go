@@ -0,0 +1,108 @@
+package lang
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlural(t *testing.T) {
+	cases := []struct {
+		lang     Language
+		n        float64
+		expected PluralCategory
+	}{
+		{English, 0, PluralOther},
+		{English, 1, PluralOne},
+		{English, 1.5, PluralOther},
+		{English, 21, PluralOther},
+		{Russian, 1, PluralOne},
+		{Russian, 3, PluralFew},
+		{Russian, 5, PluralMany},
+		{Russian, 11, PluralMany},
+		{Russian, 12, PluralMany},
+		{Russian, 21, PluralOne},
+		{Russian, 22, PluralFew},
+		{Russian, 111, PluralMany},
+		{Russian, 1.5, PluralOther},
+	}
+
+	for _, tc := range cases {
+		assert.Equal(t, tc.expected, Plural(tc.lang, tc.n), "%s %v", tc.lang, tc.n)
+	}
+}
+
+func TestFormatNumber(t *testing.T) {
+	assert.Equal(t, "1,234,567.89", FormatNumber(English, 1234567.891, 2))
+	assert.Equal(t, "1 234 567,89", FormatNumber(Russian, 1234567.891, 2))
+	assert.Equal(t, "-1,000", FormatNumber(English, -1000, 0))
+	assert.Equal(t, "0,5", FormatNumber(Russian, 0.5, -1))
+	assert.Equal(t, "0", FormatNumber(English, -0.001, 0))
+}
+
+func TestFormat(t *testing.T) {
+	dir := t.TempDir()
+	enContent := `reports:
+  one: "{count} report for {address}"
+  other: "{count} reports for {address}"
+risk: "Risk {score:.2} at {at}"
+legacy: "Risk: %.2f"
+chats:
+  one: "%d chat"
+  other: "%d chats"`
+	ruContent := `reports:
+  one: "{count} отчёт для {address}"
+  few: "{count} отчёта для {address}"
+  many: "{count} отчётов для {address}"
+  other: "{count} отчёта для {address}"
+risk: "{at}: риск {score:.2}"`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "en.yml"), []byte(enContent), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ru.yml"), []byte(ruContent), 0644))
+
+	loaded, err := readTranslations(os.DirFS(dir))
+	require.NoError(t, err)
+	mu.Lock()
+	setTranslations(loaded)
+	mu.Unlock()
+	defer func() { require.NoError(t, Reload()) }()
+
+	at := time.Date(2024, 3, 9, 14, 5, 0, 0, time.UTC)
+	cases := []struct {
+		lang     Language
+		key      string
+		params   Params
+		expected string
+	}{
+		{English, "reports", Params{"count": 1, "address": "0xabc"}, "1 report for 0xabc"},
+		{English, "reports", Params{"count": 1500, "address": "0xabc"}, "1,500 reports for 0xabc"},
+		{Russian, "reports", Params{"count": 1, "address": "0xabc"}, "1 отчёт для 0xabc"},
+		{Russian, "reports", Params{"count": 3, "address": "0xabc"}, "3 отчёта для 0xabc"},
+		{Russian, "reports", Params{"count": 5, "address": "0xabc"}, "5 отчётов для 0xabc"},
+		{English, "risk", Params{"score": 0.756, "at": at}, "Risk 0.76 at Mar 9, 2024 14:05"},
+		{Russian, "risk", Params{"score": 0.756, "at": at}, "09.03.2024 14:05: риск 0,76"},
+		{English, "risk", Params{"score": 0.5}, "Risk 0.50 at {at}"},
+	}
+
+	for _, tc := range cases {
+		assert.Equal(t, tc.expected, Format(tc.lang, tc.key, tc.params))
+	}
+
+	// Positional templates keep working, and plural forms follow the first number
+	assert.Equal(t, "Risk: 0.75", Get(Russian, "legacy", 0.75))
+	assert.Equal(t, "1 chat", Get(English, "chats", 1))
+	assert.Equal(t, "2 chats", Get(English, "chats", 2))
+}
+
+func TestPluralFormsNeedOther(t *testing.T) {
+	dir := t.TempDir()
+	content := `reports:
+  one: "{count} report"`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "en.yml"), []byte(content), 0644))
+
+	_, err := readTranslations(os.DirFS(dir))
+	assert.ErrorContains(t, err, `plural forms need "other"`)
+}
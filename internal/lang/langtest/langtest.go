@@ -0,0 +1,22 @@
+// Package langtest provides test helpers for translations
+package langtest
+
+import (
+	"testing"
+
+	"github.com/clevertechru/tgbot_aml/internal/lang"
+)
+
+// RequireConsistent fails the test if any language is missing keys, has keys
+// English does not have, or uses other format verbs than English
+func RequireConsistent(t testing.TB) {
+	t.Helper()
+
+	issues := lang.Validate()
+	for _, issue := range issues {
+		t.Errorf("translation issue: %s", issue)
+	}
+	if len(issues) > 0 {
+		t.FailNow()
+	}
+}
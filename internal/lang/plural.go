@@ -0,0 +1,113 @@
+package lang
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// PluralCategory is a CLDR plural category
+type PluralCategory string
+
+const (
+	PluralZero  PluralCategory = "zero"
+	PluralOne   PluralCategory = "one"
+	PluralTwo   PluralCategory = "two"
+	PluralFew   PluralCategory = "few"
+	PluralMany  PluralCategory = "many"
+	PluralOther PluralCategory = "other"
+)
+
+var pluralCategories = map[PluralCategory]bool{
+	PluralZero:  true,
+	PluralOne:   true,
+	PluralTwo:   true,
+	PluralFew:   true,
+	PluralMany:  true,
+	PluralOther: true,
+}
+
+// pluralRule picks the category from CLDR operands: i is the integer part
+// and v the number of visible fraction digits
+type pluralRule func(i int64, v int) PluralCategory
+
+// pluralOneOther is the rule of English and other Germanic languages
+func pluralOneOther(i int64, v int) PluralCategory {
+	if i == 1 && v == 0 {
+		return PluralOne
+	}
+	return PluralOther
+}
+
+// pluralPortuguese is the rule of Portuguese, where 0 and 1 are singular
+func pluralPortuguese(i int64, v int) PluralCategory {
+	if i <= 1 {
+		return PluralOne
+	}
+	return PluralOther
+}
+
+// pluralNone is the rule of languages without plural forms, such as Chinese
+func pluralNone(i int64, v int) PluralCategory {
+	return PluralOther
+}
+
+// pluralEastSlavic is the rule of Russian and Ukrainian
+func pluralEastSlavic(i int64, v int) PluralCategory {
+	if v != 0 {
+		return PluralOther
+	}
+	mod10, mod100 := i%10, i%100
+	switch {
+	case mod10 == 1 && mod100 != 11:
+		return PluralOne
+	case mod10 >= 2 && mod10 <= 4 && (mod100 < 12 || mod100 > 14):
+		return PluralFew
+	default:
+		return PluralMany
+	}
+}
+
+// Plural returns the plural category of n in a language
+func Plural(lang Language, n float64) PluralCategory {
+	n = math.Abs(n)
+	i := int64(n)
+	v := 0
+	if float64(i) != n {
+		s := strconv.FormatFloat(n, 'f', -1, 64)
+		v = len(s) - strings.IndexByte(s, '.') - 1
+	}
+	return localeOf(lang).plural(i, v)
+}
+
+// pluralOperand converts a message argument to a number, if it is one
+func pluralOperand(arg interface{}) (float64, bool) {
+	switch n := arg.(type) {
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	default:
+		return 0, false
+	}
+}
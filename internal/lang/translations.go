@@ -1,57 +1,239 @@
 package lang
 
 import (
+	"embed"
 	"fmt"
+	"io/fs"
+	"log"
 	"os"
-	"path/filepath"
-	"runtime"
+	"path"
+	"sort"
+	"strings"
+	"sync"
 
+	"go.uber.org/zap"
 	"gopkg.in/yaml.v3"
 )
 
 type Language string
 
+// Languages are lowercase BCP-47 tags, named after their translation file
 const (
-	English Language = "en"
-	Russian Language = "ru"
+	English           Language = "en"
+	Russian           Language = "ru"
+	Ukrainian         Language = "uk"
+	German            Language = "de"
+	Spanish           Language = "es"
+	Turkish           Language = "tr"
+	PortugueseBrazil  Language = "pt-br"
+	ChineseSimplified Language = "zh-cn"
 )
 
+// fallbacks lists where to look next when a language has no translation
+// for a key, after its own parent tags (pt-br → pt) and before English
+var fallbacks = map[Language][]Language{
+	Ukrainian: {Russian},
+	"be":      {Russian},
+	"kk":      {Russian},
+	"pt":      {PortugueseBrazil},
+	"zh":      {ChineseSimplified},
+	"zh-hans": {ChineseSimplified},
+}
+
+// chain returns the languages to try for lang in order, without English:
+// the tag itself, its parents with subtags removed from the end, and their
+// fallbacks
+func chain(lang Language) []Language {
+	var languages []Language
+	seen := make(map[Language]bool)
+
+	var add func(Language)
+	add = func(tag Language) {
+		for tag != "" && !seen[tag] {
+			seen[tag] = true
+			languages = append(languages, tag)
+			for _, fallback := range fallbacks[tag] {
+				add(fallback)
+			}
+			i := strings.LastIndexByte(string(tag), '-')
+			if i < 0 {
+				break
+			}
+			tag = tag[:i]
+		}
+	}
+	add(Language(strings.ToLower(string(lang))))
+	return languages
+}
+
+// message is a translation: a single template, or one template per plural
+// category written as a YAML mapping
+type message struct {
+	text   string
+	plural map[PluralCategory]string
+}
+
+func (m *message) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&m.text)
+	}
+
+	var forms map[PluralCategory]string
+	if err := node.Decode(&forms); err != nil {
+		return err
+	}
+	for category := range forms {
+		if !pluralCategories[category] {
+			return fmt.Errorf("line %d: unknown plural category %q", node.Line, category)
+		}
+	}
+	if _, ok := forms[PluralOther]; !ok {
+		return fmt.Errorf("line %d: plural forms need %q", node.Line, PluralOther)
+	}
+	m.plural = forms
+	return nil
+}
+
+// form returns the template for a plural category, falling back to "other"
+func (m message) form(category PluralCategory) string {
+	if m.plural == nil {
+		return m.text
+	}
+	if text, ok := m.plural[category]; ok {
+		return text
+	}
+	return m.plural[PluralOther]
+}
+
+// forms returns every template of the message
+func (m message) forms() []string {
+	if m.plural == nil {
+		return []string{m.text}
+	}
+	forms := make([]string, 0, len(m.plural))
+	for _, text := range m.plural {
+		forms = append(forms, text)
+	}
+	return forms
+}
+
+// embedded holds the bundled translations, one <language>.yml per language
+//
+//go:embed translations/*.yml
+var embedded embed.FS
+
 var (
-	translations    = make(map[Language]map[string]string)
-	translationsDir string
+	mu           sync.RWMutex
+	translations = make(map[Language]map[string]message)
+	overrideDir  string
+
+	logger      = zap.NewNop()
+	missingKeys sync.Map
 )
 
 func init() {
-	_, filename, _, _ := runtime.Caller(0)
-	translationsDir = filepath.Join(filepath.Dir(filename), "translations")
-	loadTranslations()
+	if err := Reload(); err != nil {
+		log.Printf("Failed to load translations: %v", err)
+	}
 }
 
-func loadTranslations() {
-	// Load English translations
-	enPath := filepath.Join(translationsDir, "en.yml")
-	enTranslations, err := loadYAMLFile(enPath)
+// SetLogger sets where missing translations are reported
+func SetLogger(l *zap.Logger) {
+	mu.Lock()
+	defer mu.Unlock()
+	logger = l
+}
+
+// SetOverrideDir loads *.yml files from dir on top of the bundled
+// translations. Keys in the override files replace the bundled ones, and
+// new files add languages. An empty dir removes the override.
+func SetOverrideDir(dir string) error {
+	loaded, err := load(dir)
 	if err != nil {
-		panic(fmt.Sprintf("Failed to load English translations: %v", err))
+		return err
 	}
-	translations[English] = enTranslations
 
-	// Load Russian translations
-	ruPath := filepath.Join(translationsDir, "ru.yml")
-	ruTranslations, err := loadYAMLFile(ruPath)
+	mu.Lock()
+	defer mu.Unlock()
+	overrideDir = dir
+	setTranslations(loaded)
+	return nil
+}
+
+// setTranslations must be called with mu held
+func setTranslations(loaded map[Language]map[string]message) {
+	translations = loaded
+	// Report missing keys of the new translations again
+	missingKeys = sync.Map{}
+}
+
+// Reload re-reads the bundled translations and the override directory. The
+// current translations stay in place if any file fails to load.
+func Reload() error {
+	mu.RLock()
+	dir := overrideDir
+	mu.RUnlock()
+
+	return SetOverrideDir(dir)
+}
+
+func load(dir string) (map[Language]map[string]message, error) {
+	bundled, err := fs.Sub(embedded, "translations")
 	if err != nil {
-		panic(fmt.Sprintf("Failed to load Russian translations: %v", err))
+		return nil, err
 	}
-	translations[Russian] = ruTranslations
+	sources := []fs.FS{bundled}
+
+	if dir != "" {
+		info, err := os.Stat(dir)
+		if err != nil {
+			return nil, fmt.Errorf("translations override: %w", err)
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("translations override: %s is not a directory", dir)
+		}
+		sources = append(sources, os.DirFS(dir))
+	}
+
+	return readTranslations(sources...)
 }
 
-func loadYAMLFile(path string) (map[string]string, error) {
-	data, err := os.ReadFile(path)
+// readTranslations reads every *.yml file of the sources, named after its
+// language. Later sources override keys of earlier ones.
+func readTranslations(sources ...fs.FS) (map[Language]map[string]message, error) {
+	loaded := make(map[Language]map[string]message)
+
+	for _, source := range sources {
+		files, err := fs.Glob(source, "*.yml")
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			language := Language(strings.ToLower(strings.TrimSuffix(path.Base(file), ".yml")))
+			fileTranslations, err := loadYAMLFile(source, file)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load %s translations: %w", language, err)
+			}
+
+			if loaded[language] == nil {
+				loaded[language] = make(map[string]message, len(fileTranslations))
+			}
+			for key, msg := range fileTranslations {
+				loaded[language][key] = msg
+			}
+		}
+	}
+
+	return loaded, nil
+}
+
+func loadYAMLFile(source fs.FS, name string) (map[string]message, error) {
+	data, err := fs.ReadFile(source, name)
 	if err != nil {
 		return nil, err
 	}
 
-	var translations map[string]string
+	var translations map[string]message
 	if err := yaml.Unmarshal(data, &translations); err != nil {
 		return nil, err
 	}
@@ -59,18 +241,91 @@ func loadYAMLFile(path string) (map[string]string, error) {
 	return translations, nil
 }
 
+// Available returns the loaded languages in alphabetical order
+func Available() []Language {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	languages := make([]Language, 0, len(translations))
+	for lang := range translations {
+		languages = append(languages, lang)
+	}
+	sort.Slice(languages, func(i, j int) bool { return languages[i] < languages[j] })
+	return languages
+}
+
+// Match returns the loaded language for a BCP-47 code such as Telegram's
+// "ru" or "pt-br", following the fallback chain: "pt-pt" matches pt-br and
+// "be" matches ru
+func Match(code string) (Language, bool) {
+	code = strings.ReplaceAll(strings.TrimSpace(code), "_", "-")
+	if code == "" {
+		return "", false
+	}
+
+	mu.RLock()
+	defer mu.RUnlock()
+
+	for _, language := range chain(Language(code)) {
+		if _, ok := translations[language]; ok {
+			return language, true
+		}
+	}
+	return "", false
+}
+
+// Get returns the translation of key formatted with fmt.Sprintf. If the
+// translation has plural forms, the first numeric argument picks the form.
 func Get(lang Language, key string, args ...interface{}) string {
 	if lang == "" {
 		lang = English // default to English
 	}
 
-	msg, ok := translations[lang][key]
-	if !ok {
-		msg = translations[English][key] // fallback to English
+	var form PluralCategory
+	for _, arg := range args {
+		if n, ok := pluralOperand(arg); ok {
+			form = Plural(lang, n)
+			break
+		}
 	}
+	msg := lookup(lang, key, form)
 
 	if len(args) > 0 {
 		return fmt.Sprintf(msg, args...)
 	}
 	return msg
 }
+
+// lookup returns the template of a key in the given plural form, following
+// the language's fallback chain and then English
+func lookup(lang Language, key string, form PluralCategory) string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	if messages, known := translations[lang]; known {
+		if msg, ok := messages[key]; ok {
+			return msg.form(form)
+		}
+		reportMissing(lang, key)
+	}
+	for _, language := range chain(lang)[1:] {
+		if msg, ok := translations[language][key]; ok {
+			return msg.form(form)
+		}
+	}
+
+	msg, ok := translations[English][key] // fallback to English
+	if !ok {
+		reportMissing(English, key)
+	}
+	return msg.form(form)
+}
+
+// reportMissing logs a missing translation the first time it is requested.
+// It must be called with mu held.
+func reportMissing(lang Language, key string) {
+	if _, seen := missingKeys.LoadOrStore(string(lang)+":"+key, struct{}{}); seen {
+		return
+	}
+	logger.Warn("Missing translation", zap.String("language", string(lang)), zap.String("key", key))
+}
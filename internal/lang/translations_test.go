@@ -7,23 +7,31 @@ import (
 
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 type TranslationsTestSuite struct {
 	suite.Suite
-	tempDir     string
-	originalDir string
+	tempDir string
 }
 
 func (s *TranslationsTestSuite) SetupTest() {
 	s.tempDir = s.T().TempDir()
-	s.originalDir = translationsDir
-	translationsDir = s.tempDir
 }
 
 func (s *TranslationsTestSuite) TearDownTest() {
-	translationsDir = s.originalDir
-	translations = make(map[Language]map[string]string)
+	require.NoError(s.T(), SetOverrideDir(""))
+}
+
+// loadTranslations replaces the translations with the files in tempDir only
+func (s *TranslationsTestSuite) loadTranslations() {
+	loaded, err := readTranslations(os.DirFS(s.tempDir))
+	require.NoError(s.T(), err)
+
+	mu.Lock()
+	defer mu.Unlock()
+	setTranslations(loaded)
 }
 
 func TestTranslationsSuite(t *testing.T) {
@@ -44,7 +52,7 @@ unknown_command: "Тестовая неизвестная команда"`
 	require.NoError(s.T(), os.WriteFile(filepath.Join(s.tempDir, "en.yml"), []byte(enContent), 0644))
 	require.NoError(s.T(), os.WriteFile(filepath.Join(s.tempDir, "ru.yml"), []byte(ruContent), 0644))
 
-	loadTranslations()
+	s.loadTranslations()
 
 	// Test English translations
 	s.Equal("Test welcome", Get(English, "welcome"))
@@ -66,7 +74,7 @@ result_suspicious: "Риск: %.2f, Детали: %s"`
 	require.NoError(s.T(), os.WriteFile(filepath.Join(s.tempDir, "en.yml"), []byte(enContent), 0644))
 	require.NoError(s.T(), os.WriteFile(filepath.Join(s.tempDir, "ru.yml"), []byte(ruContent), 0644))
 
-	loadTranslations()
+	s.loadTranslations()
 
 	// Test cases for error messages
 	errorCases := []struct {
@@ -110,7 +118,7 @@ check_usage: "Test check usage"`
 	require.NoError(s.T(), os.WriteFile(filepath.Join(s.tempDir, "en.yml"), []byte(enContent), 0644))
 	require.NoError(s.T(), os.WriteFile(filepath.Join(s.tempDir, "ru.yml"), []byte(ruContent), 0644))
 
-	loadTranslations()
+	s.loadTranslations()
 
 	// Test cases for fallback behavior
 	fallbackCases := []struct {
@@ -138,7 +146,78 @@ func (s *TranslationsTestSuite) TestLoadTranslationsError() {
 	require.NoError(s.T(), os.WriteFile(filepath.Join(s.tempDir, "en.yml"), []byte(content), 0644))
 	require.NoError(s.T(), os.WriteFile(filepath.Join(s.tempDir, "ru.yml"), []byte(content), 0644))
 
-	s.Panics(loadTranslations)
+	_, err := readTranslations(os.DirFS(s.tempDir))
+	s.Error(err)
+
+	// A broken override keeps the current translations
+	s.Error(SetOverrideDir(s.tempDir))
+	s.NotEmpty(Get(English, "check_usage"))
+}
+
+func (s *TranslationsTestSuite) TestBundledTranslations() {
+	require.NoError(s.T(), Reload())
+
+	s.Equal([]Language{German, English, Spanish, PortugueseBrazil, Russian, Turkish, Ukrainian, ChineseSimplified}, Available())
+	s.Equal("Русский", Get(Russian, "language_name"))
+	s.Equal("Português (Brasil)", Get(PortugueseBrazil, "language_name"))
+}
+
+func (s *TranslationsTestSuite) TestFallbackChain() {
+	files := map[string]string{
+		"en.yml":    "welcome: \"Welcome\"\ncheck_usage: \"Usage\"\nhelp: \"Help\"",
+		"ru.yml":    "welcome: \"Добро пожаловать\"\ncheck_usage: \"Использование\"",
+		"uk.yml":    "welcome: \"Ласкаво просимо\"",
+		"pt.yml":    "welcome: \"Bem-vindo\"\ncheck_usage: \"Uso\"",
+		"pt-br.yml": "welcome: \"Bem-vindo ao Brasil\"",
+	}
+	for name, content := range files {
+		require.NoError(s.T(), os.WriteFile(filepath.Join(s.tempDir, name), []byte(content), 0644))
+	}
+
+	s.loadTranslations()
+
+	s.Equal("Ласкаво просимо", Get(Ukrainian, "welcome"))
+	s.Equal("Использование", Get(Ukrainian, "check_usage"))
+	s.Equal("Help", Get(Ukrainian, "help"))
+	s.Equal("Uso", Get(PortugueseBrazil, "check_usage"))
+	s.Equal("Help", Get(PortugueseBrazil, "help"))
+
+	matchCases := []struct {
+		code     string
+		expected Language
+		ok       bool
+	}{
+		{"pt-BR", PortugueseBrazil, true},
+		{"pt-PT", "pt", true},
+		{"uk", Ukrainian, true},
+		{"be", Russian, true},
+		{"en_GB", English, true},
+		{"fr", "", false},
+	}
+	for _, tc := range matchCases {
+		s.Run(tc.code, func() {
+			language, ok := Match(tc.code)
+			s.Equal(tc.ok, ok)
+			s.Equal(tc.expected, language)
+		})
+	}
+}
+
+func (s *TranslationsTestSuite) TestOverrideDir() {
+	enContent := `check_usage: "Overridden usage"`
+	deContent := `language_name: "Deutsch"`
+	require.NoError(s.T(), os.WriteFile(filepath.Join(s.tempDir, "en.yml"), []byte(enContent), 0644))
+	require.NoError(s.T(), os.WriteFile(filepath.Join(s.tempDir, "de.yml"), []byte(deContent), 0644))
+
+	require.NoError(s.T(), SetOverrideDir(s.tempDir))
+
+	s.Equal("Overridden usage", Get(English, "check_usage"))
+	s.Equal("English", Get(English, "language_name"))
+	s.Equal("Deutsch", Get("de", "language_name"))
+	s.Contains(Available(), Language("de"))
+
+	s.Error(SetOverrideDir(filepath.Join(s.tempDir, "missing")))
+	s.Equal("Overridden usage", Get(English, "check_usage"))
 }
 
 func (s *TranslationsTestSuite) TestGetWithEmptyLanguage() {
@@ -147,7 +226,100 @@ func (s *TranslationsTestSuite) TestGetWithEmptyLanguage() {
 	require.NoError(s.T(), os.WriteFile(filepath.Join(s.tempDir, "en.yml"), []byte(content), 0644))
 	require.NoError(s.T(), os.WriteFile(filepath.Join(s.tempDir, "ru.yml"), []byte(content), 0644))
 
-	loadTranslations()
+	s.loadTranslations()
 
 	s.Equal("Test welcome", Get("", "welcome"))
 }
+
+func (s *TranslationsTestSuite) TestMatch() {
+	content := `language_name: "Test"`
+	require.NoError(s.T(), os.WriteFile(filepath.Join(s.tempDir, "en.yml"), []byte(content), 0644))
+	require.NoError(s.T(), os.WriteFile(filepath.Join(s.tempDir, "ru.yml"), []byte(content), 0644))
+
+	s.loadTranslations()
+
+	s.Equal([]Language{English, Russian}, Available())
+
+	matchCases := []struct {
+		code     string
+		expected Language
+		ok       bool
+	}{
+		{"ru", Russian, true},
+		{"EN", English, true},
+		{"en-US", English, true},
+		{"fr", "", false},
+		{"", "", false},
+	}
+
+	for _, tc := range matchCases {
+		s.Run(tc.code, func() {
+			language, ok := Match(tc.code)
+			s.Equal(tc.ok, ok)
+			s.Equal(tc.expected, language)
+		})
+	}
+}
+
+func (s *TranslationsTestSuite) TestValidate() {
+	enContent := `welcome: "Welcome"
+risk: "Risk %.2f for %s"
+reordered: "%d of %s"
+percent: "%d%% done"
+named: "{count} of {total}"`
+
+	ruContent := `welcome: "Добро пожаловать"
+risk: "Риск %d для %s"
+reordered: "%[2]s: %[1]d"
+percent: "Готово на %d%%"
+named:
+  one: "{count} запись"
+  other: "{count} записей"
+extra: "Лишний ключ"`
+
+	require.NoError(s.T(), os.WriteFile(filepath.Join(s.tempDir, "en.yml"), []byte(enContent), 0644))
+	require.NoError(s.T(), os.WriteFile(filepath.Join(s.tempDir, "ru.yml"), []byte(ruContent+"\nwelcome_message: \"\""), 0644))
+
+	s.loadTranslations()
+
+	var problems []string
+	for _, issue := range Validate() {
+		problems = append(problems, issue.String())
+	}
+	s.Equal([]string{
+		"ru: extra: key is not in English",
+		"ru: named: placeholders [{count}], want [{count} {total}]",
+		"ru: risk: format verbs map[1:d 2:s], want map[1:f 2:s]",
+		"ru: welcome_message: key is not in English",
+	}, problems)
+
+	require.NoError(s.T(), os.WriteFile(filepath.Join(s.tempDir, "ru.yml"), []byte(`welcome: "Добро пожаловать"`), 0644))
+	s.loadTranslations()
+
+	s.Len(Validate(), 4)
+	s.Equal("ru: named: missing translation", Validate()[0].String())
+}
+
+func (s *TranslationsTestSuite) TestGetReportsMissingKeysOnce() {
+	enContent := `welcome: "Test welcome"
+check_usage: "Test check usage"`
+	ruContent := `welcome: "Тестовое приветствие"`
+	require.NoError(s.T(), os.WriteFile(filepath.Join(s.tempDir, "en.yml"), []byte(enContent), 0644))
+	require.NoError(s.T(), os.WriteFile(filepath.Join(s.tempDir, "ru.yml"), []byte(ruContent), 0644))
+
+	s.loadTranslations()
+
+	core, logs := observer.New(zap.WarnLevel)
+	SetLogger(zap.New(core))
+	defer SetLogger(zap.NewNop())
+
+	for i := 0; i < 3; i++ {
+		Get(Russian, "check_usage")
+		Get(English, "missing_everywhere")
+		Get("fr", "welcome")
+	}
+
+	s.Equal(2, logs.Len())
+	s.Equal("ru", logs.All()[0].ContextMap()["language"])
+	s.Equal("missing_everywhere", logs.All()[1].ContextMap()["key"])
+}
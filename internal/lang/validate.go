@@ -0,0 +1,105 @@
+package lang
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// formatVerb matches a fmt verb with its optional flags, argument index,
+// width and precision
+var formatVerb = regexp.MustCompile(`%[-+# 0]*(?:\[(\d+)\])?(?:\d+|\*)?(?:\.(?:\d+|\*)?)?([a-zA-Z%])`)
+
+// Issue is a problem found in the translations of one language
+type Issue struct {
+	Language Language
+	Key      string
+	Problem  string
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%s: %s: %s", i.Language, i.Key, i.Problem)
+}
+
+// Validate compares every language with English: it reports keys that are
+// missing or unknown, and translations whose format verbs or named
+// placeholders differ from the English ones
+func Validate() []Issue {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	return validate(translations)
+}
+
+func validate(loaded map[Language]map[string]message) []Issue {
+	reference := loaded[English]
+
+	var issues []Issue
+	for language, messages := range loaded {
+		if language == English {
+			continue
+		}
+		for key, msg := range reference {
+			translated, ok := messages[key]
+			if !ok {
+				issues = append(issues, Issue{language, key, "missing translation"})
+				continue
+			}
+			if want, got := formatVerbs(msg.form(PluralOther)), formatVerbs(translated.form(PluralOther)); want != got {
+				issues = append(issues, Issue{language, key, fmt.Sprintf("format verbs %s, want %s", got, want)})
+			}
+			if want, got := placeholders(msg), placeholders(translated); want != got {
+				issues = append(issues, Issue{language, key, fmt.Sprintf("placeholders %s, want %s", got, want)})
+			}
+		}
+		for key := range messages {
+			if _, ok := reference[key]; !ok {
+				issues = append(issues, Issue{language, key, "key is not in English"})
+			}
+		}
+	}
+
+	sort.Slice(issues, func(i, j int) bool {
+		if issues[i].Language != issues[j].Language {
+			return issues[i].Language < issues[j].Language
+		}
+		return issues[i].Key < issues[j].Key
+	})
+	return issues
+}
+
+// formatVerbs describes which verb formats each argument of msg, e.g.
+// "[1:d 2:s]", so translations may reorder arguments with %[n]v
+func formatVerbs(msg string) string {
+	verbs := make(map[int]string)
+	arg := 1
+	for _, match := range formatVerb.FindAllStringSubmatch(msg, -1) {
+		if match[2] == "%" {
+			continue
+		}
+		if match[1] != "" {
+			arg, _ = strconv.Atoi(match[1])
+		}
+		verbs[arg] += match[2]
+		arg++
+	}
+	return fmt.Sprint(verbs)
+}
+
+// placeholders lists the named placeholders used in any form of msg
+func placeholders(msg message) string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, text := range msg.forms() {
+		for _, match := range placeholder.FindAllStringSubmatch(text, -1) {
+			if !seen[match[1]] {
+				seen[match[1]] = true
+				names = append(names, "{"+match[1]+"}")
+			}
+		}
+	}
+	sort.Strings(names)
+	return "[" + strings.Join(names, " ") + "]"
+}
@@ -0,0 +1,185 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Page geometry in PDF points (A4)
+const (
+	pageWidth    = 595
+	pageHeight   = 842
+	marginLeft   = 56
+	marginTop    = 64
+	marginBottom = 64
+
+	// charWidth approximates the average Helvetica glyph width per point of
+	// font size, to wrap lines without font metrics
+	charWidth = 0.52
+)
+
+// line is a line of text on a page, y is its baseline from the page bottom
+type line struct {
+	text string
+	size float64
+	bold bool
+	y    float64
+}
+
+// pdfWriter lays out lines of text on A4 pages and writes them as a minimal
+// PDF 1.4 file with the standard Helvetica fonts. Text outside Latin-1
+// cannot be shown by the standard fonts and is replaced with "?".
+type pdfWriter struct {
+	title string
+	pages [][]line
+	y     float64
+}
+
+func newPDFWriter(title string) *pdfWriter {
+	return &pdfWriter{title: title}
+}
+
+// add writes text, wrapping it to the page width and starting new pages
+func (p *pdfWriter) add(text string, size float64, bold bool) {
+	maxChars := int((pageWidth - 2*marginLeft) / (size * charWidth))
+	for _, wrapped := range wrap(text, maxChars) {
+		p.addLine(line{text: wrapped, size: size, bold: bold})
+	}
+}
+
+// space adds vertical space
+func (p *pdfWriter) space(points float64) {
+	p.y += points
+}
+
+func (p *pdfWriter) addLine(l line) {
+	height := l.size * 1.4
+	if len(p.pages) == 0 || p.y+height > pageHeight-marginTop-marginBottom {
+		p.pages = append(p.pages, nil)
+		p.y = 0
+	}
+	p.y += height
+	l.y = pageHeight - marginTop - p.y
+	p.pages[len(p.pages)-1] = append(p.pages[len(p.pages)-1], l)
+}
+
+// wrap splits text into lines of at most width characters, breaking at spaces
+// where possible
+func wrap(text string, width int) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		current := ""
+		for _, word := range strings.Fields(paragraph) {
+			for utf8.RuneCountInString(word) > width {
+				if current != "" {
+					lines = append(lines, current)
+					current = ""
+				}
+				runes := []rune(word)
+				lines = append(lines, string(runes[:width]))
+				word = string(runes[width:])
+			}
+			switch {
+			case current == "":
+				current = word
+			case utf8.RuneCountInString(current)+1+utf8.RuneCountInString(word) <= width:
+				current += " " + word
+			default:
+				lines = append(lines, current)
+				current = word
+			}
+		}
+		lines = append(lines, current)
+	}
+	return lines
+}
+
+// WriteTo writes the PDF file
+func (p *pdfWriter) WriteTo(w io.Writer, created time.Time) error {
+	var buf bytes.Buffer
+	var offsets []int
+
+	object := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Objects 1-5 are fixed, then every page has a page object and a
+	// content stream
+	const firstPage = 6
+	kids := make([]string, len(p.pages))
+	for i := range p.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i)
+	}
+
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(p.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	object(fmt.Sprintf("<< /Title %s /Producer (tgbot_aml) /CreationDate (D:%s) >>",
+		pdfString(p.title), created.UTC().Format("20060102150405Z")))
+
+	for i, page := range p.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] "+
+			"/Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, firstPage+2*i+1))
+
+		content := pageContent(page, i+1, len(p.pages))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R /Info 5 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		len(offsets)+1, xref)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func pageContent(lines []line, page, pages int) string {
+	var b strings.Builder
+	for _, l := range lines {
+		font := "F1"
+		if l.bold {
+			font = "F2"
+		}
+		fmt.Fprintf(&b, "BT /%s %.1f Tf %d %.1f Td %s Tj ET\n", font, l.size, marginLeft, l.y, pdfString(l.text))
+	}
+	fmt.Fprintf(&b, "BT /F1 8.0 Tf %d %d Td %s Tj ET", marginLeft, marginBottom/2, pdfString(fmt.Sprintf("Page %d of %d", page, pages)))
+	return b.String()
+}
+
+// pdfString encodes text as a PDF literal string in WinAnsiEncoding
+func pdfString(text string) string {
+	var b strings.Builder
+	b.WriteByte('(')
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 0x20 && r < 0x7f:
+			b.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			fmt.Fprintf(&b, "\\%03o", r)
+		case r == '—' || r == '–':
+			b.WriteByte('-')
+		case r == '…':
+			b.WriteString("...")
+		default:
+			b.WriteByte('?')
+		}
+	}
+	b.WriteByte(')')
+	return b.String()
+}
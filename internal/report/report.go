@@ -0,0 +1,206 @@
+// Package report renders screening results as documents for auditors
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/clevertechru/tgbot_aml/internal/domain"
+	"github.com/clevertechru/tgbot_aml/internal/services"
+)
+
+const (
+	titleSize   = 18
+	headingSize = 12
+	textSize    = 10
+)
+
+// Report is a screening result as it appears in a PDF report. Its ID is the
+// audit log entry the report was generated from.
+type Report struct {
+	ID          string
+	GeneratedAt time.Time
+	CheckedAt   time.Time
+	Analyst     services.Analyst
+	Query       string
+	Kind        string
+	Chain       string
+	Suspicious  bool
+	RiskScore   float64
+	Verdict     string
+	FiredRules  []string
+	Categories  []string
+	Factors     []domain.RiskFactor
+	Graph       *domain.GraphExposure
+	Responses   []domain.ProviderResponse
+}
+
+// FromAudit builds the report of an audit log entry
+func FromAudit(entry services.AuditEntry, generatedAt time.Time) Report {
+	return Report{
+		ID:          entry.ID,
+		GeneratedAt: generatedAt,
+		CheckedAt:   entry.Time,
+		Analyst:     entry.Analyst,
+		Query:       entry.Query,
+		Kind:        entry.Kind,
+		Chain:       entry.Chain,
+		Suspicious:  entry.IsSuspicious,
+		RiskScore:   entry.RiskScore,
+		Verdict:     string(entry.Verdict),
+		FiredRules:  entry.FiredRules,
+		Categories:  entry.Categories,
+		Factors:     entry.Factors,
+		Graph:       entry.Graph,
+		Responses:   entry.Responses,
+	}
+}
+
+func graphShare(exposure domain.CategoryExposure) string {
+	return fmt.Sprintf("%.1f%%, closest %d hops away", exposure.Share*100, exposure.Hops)
+}
+
+// SanctionsHits returns the sanctions categories reported by any provider
+func (r Report) SanctionsHits() []string {
+	seen := make(map[string]bool)
+	var hits []string
+	add := func(categories []string) {
+		for _, category := range categories {
+			if strings.Contains(strings.ToLower(category), "sanction") && !seen[category] {
+				seen[category] = true
+				hits = append(hits, category)
+			}
+		}
+	}
+
+	add(r.Categories)
+	for _, response := range r.Responses {
+		add(response.Categories)
+	}
+	sort.Strings(hits)
+	return hits
+}
+
+// WritePDF writes the report as a PDF document
+func WritePDF(w io.Writer, r Report) error {
+	pdf := newPDFWriter("AML screening report " + r.ID)
+
+	pdf.add("AML screening report", titleSize, true)
+	pdf.add("Report ID: "+r.ID, textSize, false)
+	pdf.space(textSize)
+
+	verdict := "CLEAN"
+	if r.Suspicious {
+		verdict = "SUSPICIOUS"
+	}
+	section(pdf, "Query")
+	field(pdf, "Value", r.Query)
+	field(pdf, "Type", r.Kind)
+	field(pdf, "Chain", valueOr(r.Chain, "unknown"))
+	field(pdf, "Checked at", r.CheckedAt.UTC().Format(time.RFC3339))
+	field(pdf, "Verdict", verdict)
+	field(pdf, "Risk score", fmt.Sprintf("%.1f%%", r.RiskScore*100))
+	if r.Verdict != "" {
+		field(pdf, "Policy decision", strings.ToUpper(r.Verdict))
+		field(pdf, "Rules fired", valueOr(strings.Join(r.FiredRules, ", "), "none"))
+	}
+
+	if len(r.Factors) > 0 {
+		section(pdf, "Risk factors")
+		for _, factor := range r.Factors {
+			if factor.Unknown {
+				field(pdf, string(factor.Kind), "unknown")
+				continue
+			}
+			line := fmt.Sprintf("%.2f (weight %.2f x signal %.2f)", factor.Contribution, factor.Weight, factor.Signal)
+			if len(factor.Sources) > 0 {
+				line += " - " + strings.Join(factor.Sources, "; ")
+			}
+			field(pdf, string(factor.Kind), line)
+		}
+	}
+
+	if r.Graph != nil {
+		section(pdf, fmt.Sprintf("Transaction graph (%d hops)", r.Graph.Hops))
+		for _, exposure := range r.Graph.Incoming {
+			field(pdf, "From "+exposure.Category, graphShare(exposure))
+		}
+		for _, exposure := range r.Graph.Outgoing {
+			field(pdf, "To "+exposure.Category, graphShare(exposure))
+		}
+		if len(r.Graph.Incoming)+len(r.Graph.Outgoing) == 0 {
+			pdf.add("No labelled addresses within reach", textSize, false)
+		}
+		if r.Graph.Truncated {
+			pdf.add(fmt.Sprintf("Stopped after %d addresses, exposure may be higher", r.Graph.Addresses), textSize, false)
+		}
+	}
+
+	section(pdf, "Risk categories")
+	list(pdf, r.Categories, "none")
+
+	section(pdf, "Sanctions list hits")
+	list(pdf, r.SanctionsHits(), "none")
+
+	section(pdf, "Provider responses")
+	if len(r.Responses) == 0 {
+		pdf.add("none", textSize, false)
+	}
+	for _, response := range r.Responses {
+		pdf.add(response.Provider, textSize, true)
+		field(pdf, "Suspicious", fmt.Sprintf("%t", response.IsSuspicious))
+		field(pdf, "Risk score", fmt.Sprintf("%.1f%%", response.RiskScore*100))
+		field(pdf, "Categories", valueOr(strings.Join(response.Categories, ", "), "none"))
+		for _, detail := range response.Details {
+			pdf.add("- "+detail, textSize, false)
+		}
+		pdf.space(textSize / 2)
+	}
+
+	section(pdf, "Analyst")
+	field(pdf, "Telegram ID", fmt.Sprintf("%d", r.Analyst.UserID))
+	if r.Analyst.Username != "" {
+		field(pdf, "Username", "@"+r.Analyst.Username)
+	}
+	if r.Analyst.Name != "" {
+		field(pdf, "Name", r.Analyst.Name)
+	}
+
+	section(pdf, "Sign-off")
+	field(pdf, "Reviewed by", "______________________________")
+	field(pdf, "Date", "______________________________")
+
+	pdf.space(textSize)
+	pdf.add(fmt.Sprintf("Generated at %s. The report ID refers to the entry in the bot's audit log.",
+		r.GeneratedAt.UTC().Format(time.RFC3339)), textSize-2, false)
+
+	return pdf.WriteTo(w, r.GeneratedAt)
+}
+
+func section(pdf *pdfWriter, title string) {
+	pdf.space(textSize)
+	pdf.add(title, headingSize, true)
+}
+
+func field(pdf *pdfWriter, name, value string) {
+	pdf.add(name+": "+value, textSize, false)
+}
+
+func list(pdf *pdfWriter, items []string, empty string) {
+	if len(items) == 0 {
+		pdf.add(empty, textSize, false)
+	}
+	for _, item := range items {
+		pdf.add("- "+item, textSize, false)
+	}
+}
+
+func valueOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
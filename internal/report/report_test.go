@@ -0,0 +1,179 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clevertechru/tgbot_aml/internal/domain"
+	"github.com/clevertechru/tgbot_aml/internal/services"
+)
+
+func testReport() Report {
+	return Report{
+		ID:          "20240501-1f3a9c0e2b7d",
+		GeneratedAt: time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC),
+		CheckedAt:   time.Date(2024, 5, 1, 12, 29, 0, 0, time.UTC),
+		Analyst:     services.Analyst{UserID: 42, Username: "alice", Name: "Alice (AML)"},
+		Query:       "TXYZ1234567890abcdefghijklmnopqrstu",
+		Kind:        "address",
+		Chain:       "TRON",
+		Suspicious:  true,
+		RiskScore:   0.87,
+		Verdict:     "block",
+		FiredRules:  []string{"sanctions"},
+		Categories:  []string{"mixer", "sanctions"},
+		Factors: []domain.RiskFactor{
+			{Kind: domain.FactorSanctions, Weight: 1, Signal: 1, Contribution: 1, Sources: []string{"mock: sanctions"}},
+			{Kind: domain.FactorAge, Unknown: true},
+		},
+		Graph: &domain.GraphExposure{
+			Hops:     2,
+			Incoming: []domain.CategoryExposure{{Category: "mixer", Share: 0.4, Hops: 2}},
+		},
+		Responses: []domain.ProviderResponse{
+			{Provider: "mock", IsSuspicious: true, RiskScore: 0.87, Categories: []string{"sanctions"}, Details: []string{"OFAC SDN list"}},
+			{Provider: "other", RiskScore: 0.2, Categories: []string{"OFAC sanctioned entity"}},
+		},
+	}
+}
+
+// checkStructure verifies the header, trailer and that every xref entry
+// points at its object, and returns the number of pages
+func checkStructure(t *testing.T, pdf []byte) int {
+	t.Helper()
+
+	if !bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")) {
+		t.Fatalf("missing PDF header: %q", pdf[:16])
+	}
+	if !bytes.HasSuffix(pdf, []byte("%%EOF\n")) {
+		t.Fatal("missing end-of-file marker")
+	}
+
+	start := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(pdf)
+	if start == nil {
+		t.Fatal("missing startxref")
+	}
+	xref, _ := strconv.Atoi(string(start[1]))
+	if !bytes.HasPrefix(pdf[xref:], []byte("xref\n")) {
+		t.Fatalf("startxref %d does not point at the xref table", xref)
+	}
+
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(pdf[xref:], -1)
+	if len(entries) == 0 {
+		t.Fatal("empty xref table")
+	}
+	for i, entry := range entries {
+		offset, _ := strconv.Atoi(string(entry[1]))
+		want := fmt.Sprintf("%d 0 obj\n", i+1)
+		if !bytes.HasPrefix(pdf[offset:], []byte(want)) {
+			t.Errorf("xref entry %d points at %q, want %q", i+1, pdf[offset:offset+len(want)], want)
+		}
+	}
+	if !bytes.Contains(pdf, []byte(fmt.Sprintf("/Size %d ", len(entries)+1))) {
+		t.Errorf("trailer size does not match %d objects", len(entries))
+	}
+
+	for _, stream := range regexp.MustCompile(`(?s)<< /Length (\d+) >>\nstream\n(.*?)\nendstream`).FindAllSubmatch(pdf, -1) {
+		length, _ := strconv.Atoi(string(stream[1]))
+		if length != len(stream[2]) {
+			t.Errorf("stream length %d, want %d", length, len(stream[2]))
+		}
+	}
+
+	count := regexp.MustCompile(`/Type /Pages /Kids \[.*\] /Count (\d+)`).FindSubmatch(pdf)
+	if count == nil {
+		t.Fatal("missing page tree")
+	}
+	pages, _ := strconv.Atoi(string(count[1]))
+	if got := bytes.Count(pdf, []byte("/Type /Page /Parent")); got != pages {
+		t.Errorf("page tree counts %d pages, found %d", pages, got)
+	}
+	return pages
+}
+
+func TestWritePDF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WritePDF(&buf, testReport()); err != nil {
+		t.Fatalf("WritePDF() error = %v", err)
+	}
+
+	pdf := buf.Bytes()
+	if pages := checkStructure(t, pdf); pages != 1 {
+		t.Errorf("pages = %d, want 1", pages)
+	}
+
+	for _, want := range []string{
+		"(Report ID: 20240501-1f3a9c0e2b7d)",
+		"(Value: TXYZ1234567890abcdefghijklmnopqrstu)",
+		"(Chain: TRON)",
+		"(Checked at: 2024-05-01T12:29:00Z)",
+		"(Verdict: SUSPICIOUS)",
+		"(Risk score: 87.0%)",
+		"(Policy decision: BLOCK)",
+		"(Rules fired: sanctions)",
+		`(sanctions: 1.00 \(weight 1.00 x signal 1.00\) - mock: sanctions)`,
+		"(age: unknown)",
+		`(Transaction graph \(2 hops\))`,
+		"(From mixer: 40.0%, closest 2 hops away)",
+		"(- OFAC SDN list)",
+		"(- OFAC sanctioned entity)",
+		"(other)",
+		"(Username: @alice)",
+		`(Name: Alice \(AML\))`,
+	} {
+		if !bytes.Contains(pdf, []byte(want)) {
+			t.Errorf("PDF does not contain %s", want)
+		}
+	}
+}
+
+func TestWritePDFMultiplePages(t *testing.T) {
+	r := testReport()
+	for i := 0; i < 40; i++ {
+		r.Responses = append(r.Responses, domain.ProviderResponse{
+			Provider: fmt.Sprintf("provider-%d", i),
+			Details:  []string{strings.Repeat("long detail ", 20)},
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := WritePDF(&buf, r); err != nil {
+		t.Fatalf("WritePDF() error = %v", err)
+	}
+	if pages := checkStructure(t, buf.Bytes()); pages < 2 {
+		t.Errorf("pages = %d, want several", pages)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("(provider-39)")) {
+		t.Error("last provider is missing")
+	}
+}
+
+func TestSanctionsHits(t *testing.T) {
+	got := testReport().SanctionsHits()
+	want := []string{"OFAC sanctioned entity", "sanctions"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("SanctionsHits() = %v, want %v", got, want)
+	}
+}
+
+func TestPDFString(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"plain", "(plain)"},
+		{`a (b) \c`, `(a \(b\) \\c)`},
+		{"café", `(caf\351)`},
+		{"адрес — ok", "(????? - ok)"},
+	}
+	for _, tt := range tests {
+		if got := pdfString(tt.in); got != tt.want {
+			t.Errorf("pdfString(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package rules
+
+import (
+	"sort"
+	"time"
+
+	"github.com/clevertechru/tgbot_aml/internal/domain"
+)
+
+// Env holds the values of the variables an expression is evaluated with.
+// Amount and Age are nil when unknown. Amount is in whole coins or tokens
+// (2.5 ETH is 2.5, not 2.5e18 wei), in the unit of the payment link.
+type Env struct {
+	Score      float64
+	Categories []string
+	Chain      string
+	Kind       string
+	Amount     *float64
+	Labels     []string
+	// Age is the number of days since the address was first active
+	Age *float64
+}
+
+// EnvFor returns the variables of a screening result
+func EnvFor(result *domain.ScreeningResult, now time.Time) Env {
+	env := Env{
+		Score: result.RiskScore,
+		Chain: string(result.Target.Chain),
+		Kind:  result.Target.Kind.String(),
+	}
+
+	seen := make(map[string]bool)
+	for _, categories := range append([][]string{result.Categories}, responseCategories(result)...) {
+		for _, category := range categories {
+			if !seen[category] {
+				seen[category] = true
+				env.Categories = append(env.Categories, category)
+			}
+		}
+	}
+
+	// Counterparties are labelled by the categories funds came from
+	for label, share := range result.Exposure {
+		if share > 0 {
+			env.Labels = append(env.Labels, label)
+		}
+	}
+	sort.Strings(env.Labels)
+
+	if result.Amount > 0 {
+		amount := result.Amount
+		env.Amount = &amount
+	}
+	if !result.FirstSeen.IsZero() {
+		age := now.Sub(result.FirstSeen).Hours() / 24
+		env.Age = &age
+	}
+	return env
+}
+
+func responseCategories(result *domain.ScreeningResult) [][]string {
+	categories := make([][]string, len(result.Responses))
+	for i, response := range result.Responses {
+		categories[i] = response.Categories
+	}
+	return categories
+}
+
+// Variable is a value expressions can refer to
+type Variable struct {
+	Name string
+	Type Type
+	get  func(Env) value
+}
+
+func optional(n *float64) value {
+	if n == nil {
+		return value{unknown: true}
+	}
+	return value{num: *n}
+}
+
+// variables are the names expressions can read. category and categories
+// are the same list, so that category == "gambling" reads naturally.
+// amount is in whole coins or tokens, whatever the payment link paid in.
+var variables = []Variable{
+	{"score", TypeNumber, func(e Env) value { return value{num: e.Score} }},
+	{"category", TypeList, func(e Env) value { return value{list: e.Categories} }},
+	{"categories", TypeList, func(e Env) value { return value{list: e.Categories} }},
+	{"chain", TypeString, func(e Env) value { return value{str: e.Chain} }},
+	{"kind", TypeString, func(e Env) value { return value{str: e.Kind} }},
+	{"amount", TypeNumber, func(e Env) value { return optional(e.Amount) }},
+	{"labels", TypeList, func(e Env) value { return value{list: e.Labels} }},
+	{"age", TypeNumber, func(e Env) value { return optional(e.Age) }},
+}
+
+// Variables lists the variables expressions can use
+func Variables() []Variable {
+	return append([]Variable(nil), variables...)
+}
+
+func lookupVariable(name string) (Variable, bool) {
+	for _, variable := range variables {
+		if variable.Name == name {
+			return variable, true
+		}
+	}
+	return Variable{}, false
+}
+
+// Binding is a variable and its value in an Env, formatted for display
+type Binding struct {
+	Name  string
+	Value string
+}
+
+// Bindings returns the value of every variable in env
+func (e Env) Bindings() []Binding {
+	bindings := make([]Binding, 0, len(variables))
+	for _, variable := range variables {
+		if variable.Name == "categories" {
+			continue
+		}
+		bindings = append(bindings, Binding{Name: variable.Name, Value: variable.get(e).format(variable.Type)})
+	}
+	return bindings
+}
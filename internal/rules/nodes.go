@@ -0,0 +1,136 @@
+package rules
+
+import (
+	"fmt"
+)
+
+type node interface {
+	typ() Type
+	eval(env Env) value
+}
+
+type literalNode struct {
+	t Type
+	v value
+}
+
+func (n literalNode) typ() Type      { return n.t }
+func (n literalNode) eval(Env) value { return n.v }
+
+type variableNode struct {
+	variable Variable
+}
+
+func (n variableNode) typ() Type          { return n.variable.Type }
+func (n variableNode) eval(env Env) value { return n.variable.get(env) }
+
+type notNode struct {
+	operand node
+}
+
+func (n notNode) typ() Type { return TypeBool }
+
+func (n notNode) eval(env Env) value {
+	operand := n.operand.eval(env)
+	if operand.unknown {
+		return operand
+	}
+	return value{b: !operand.b}
+}
+
+// logicalNode combines conditions in three-valued logic: an unknown operand
+// leaves the result unknown unless the other operand decides it, as false
+// does for and and true does for or
+type logicalNode struct {
+	and         bool
+	left, right node
+}
+
+func (n logicalNode) typ() Type { return TypeBool }
+
+func (n logicalNode) eval(env Env) value {
+	left := n.left.eval(env)
+	if !left.unknown && n.and != left.b {
+		// false and ..., true or ...
+		return left
+	}
+	right := n.right.eval(env)
+	if !right.unknown && n.and != right.b {
+		return right
+	}
+	if left.unknown || right.unknown {
+		return value{unknown: true}
+	}
+	return right
+}
+
+// compareNode compares two operands. Any comparison with an unknown number
+// is unknown.
+type compareNode struct {
+	op          string
+	left, right node
+}
+
+// newCompare type checks a comparison:
+//
+//	number  ==, !=, <, <=, >, >=  number
+//	string  ==, !=                string
+//	bool    ==, !=                bool
+//	list    ==, !=                string  (the list holds / lacks the string)
+//	string  in                    list
+func newCompare(op token, left, right node) (node, error) {
+	lt, rt := left.typ(), right.typ()
+	ok := false
+	switch op.text {
+	case "==", "!=":
+		ok = lt == rt && lt != TypeList || lt == TypeList && rt == TypeString
+	case "<", "<=", ">", ">=":
+		ok = lt == TypeNumber && rt == TypeNumber
+	case "in":
+		ok = lt == TypeString && rt == TypeList
+	}
+	if !ok {
+		return nil, &SyntaxError{Pos: op.pos, Msg: fmt.Sprintf("cannot compare %s %s %s", lt, op.text, rt)}
+	}
+	return compareNode{op: op.text, left: left, right: right}, nil
+}
+
+func (n compareNode) typ() Type { return TypeBool }
+
+func (n compareNode) eval(env Env) value {
+	left, right := n.left.eval(env), n.right.eval(env)
+	if left.unknown || right.unknown {
+		return value{unknown: true}
+	}
+
+	switch n.left.typ() {
+	case TypeNumber:
+		return value{b: compareNumbers(n.op, left.num, right.num)}
+	case TypeList:
+		return value{b: contains(left.list, right.str) == (n.op == "==")}
+	case TypeString:
+		if n.op == "in" {
+			return value{b: contains(right.list, left.str)}
+		}
+		return value{b: sameText(left.str, right.str) == (n.op == "==")}
+	default:
+		return value{b: (left.b == right.b) == (n.op == "==")}
+	}
+}
+
+func compareNumbers(op string, a, b float64) bool {
+	switch op {
+	case "==":
+		return a == b
+	case "!=":
+		return a != b
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	default:
+		return a >= b
+	}
+}
@@ -0,0 +1,181 @@
+package rules
+
+import (
+	"fmt"
+)
+
+// keywords cannot be used as variable names
+var keywords = map[string]bool{
+	"and": true, "or": true, "not": true, "in": true, "true": true, "false": true,
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	depth  int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) isKeyword(word string) bool {
+	t := p.peek()
+	return t.kind == tokenIdent && t.text == word
+}
+
+// enter guards against deeply nested expressions
+func (p *parser) enter(pos int) error {
+	p.depth++
+	if p.depth > maxDepth {
+		return &SyntaxError{Pos: pos, Msg: fmt.Sprintf("expression is nested deeper than %d levels", maxDepth)}
+	}
+	return nil
+}
+
+func (p *parser) parseOr() (node, error) {
+	return p.parseLogical("or", p.parseAnd)
+}
+
+func (p *parser) parseAnd() (node, error) {
+	return p.parseLogical("and", p.parseNot)
+}
+
+func (p *parser) parseLogical(op string, operand func() (node, error)) (node, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword(op) {
+		opToken := p.next()
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		if err := requireType(opToken, TypeBool, left, right); err != nil {
+			return nil, err
+		}
+		left = logicalNode{and: op == "and", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (node, error) {
+	if !p.isKeyword("not") {
+		return p.parseComparison()
+	}
+	opToken := p.next()
+	if err := p.enter(opToken.pos); err != nil {
+		return nil, err
+	}
+	defer func() { p.depth-- }()
+
+	operand, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	if err := requireType(opToken, TypeBool, operand); err != nil {
+		return nil, err
+	}
+	return notNode{operand: operand}, nil
+}
+
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	opToken := p.peek()
+	if opToken.kind != tokenOperator && !p.isKeyword("in") {
+		return left, nil
+	}
+	p.next()
+	right, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	return newCompare(opToken, left, right)
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenNumber:
+		return literalNode{t: TypeNumber, v: value{num: t.num}}, nil
+	case tokenString:
+		return literalNode{t: TypeString, v: value{str: t.text}}, nil
+	case tokenIdent:
+		switch t.text {
+		case "true", "false":
+			return literalNode{t: TypeBool, v: value{b: t.text == "true"}}, nil
+		}
+		if keywords[t.text] {
+			return nil, &SyntaxError{Pos: t.pos, Msg: fmt.Sprintf("unexpected %s", t)}
+		}
+		variable, ok := lookupVariable(t.text)
+		if !ok {
+			return nil, &SyntaxError{Pos: t.pos, Msg: fmt.Sprintf("unknown variable %s", t)}
+		}
+		return variableNode{variable: variable}, nil
+	case tokenLParen:
+		if err := p.enter(t.pos); err != nil {
+			return nil, err
+		}
+		defer func() { p.depth-- }()
+
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, &SyntaxError{Pos: closing.pos, Msg: fmt.Sprintf("expected \")\", got %s", closing)}
+		}
+		return inner, nil
+	case tokenLBracket:
+		return p.parseList(t)
+	}
+	return nil, &SyntaxError{Pos: t.pos, Msg: fmt.Sprintf("unexpected %s", t)}
+}
+
+// parseList parses a list of strings such as ["TRON", "ETH"]
+func (p *parser) parseList(open token) (node, error) {
+	var items []string
+	for {
+		t := p.next()
+		if t.kind == tokenRBracket && len(items) == 0 {
+			break
+		}
+		if t.kind != tokenString {
+			return nil, &SyntaxError{Pos: t.pos, Msg: fmt.Sprintf("lists can only hold strings, got %s", t)}
+		}
+		items = append(items, t.text)
+
+		t = p.next()
+		if t.kind == tokenRBracket {
+			break
+		}
+		if t.kind != tokenComma {
+			return nil, &SyntaxError{Pos: t.pos, Msg: fmt.Sprintf("expected \",\" or \"]\", got %s", t)}
+		}
+	}
+	return literalNode{t: TypeList, v: value{list: items}}, nil
+}
+
+// requireType checks that every operand of op has type t
+func requireType(op token, t Type, operands ...node) error {
+	for _, operand := range operands {
+		if operand.typ() != t {
+			return &SyntaxError{Pos: op.pos, Msg: fmt.Sprintf("%s needs %s operands, got %s", op, t, operand.typ())}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+// Package rules implements a small expression language for screening
+// conditions such as
+//
+//	category == "gambling" and chain == "TRON" and amount > 10000
+//
+// Expressions are type checked when compiled and can only read the
+// variables of an Env, so rules written by users cannot do anything but
+// compute a boolean.
+package rules
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// maxLength and maxDepth bound the work a single expression can cause
+	maxLength = 1000
+	maxDepth  = 32
+)
+
+// Type is the type of a value in an expression
+type Type int
+
+const (
+	TypeBool Type = iota
+	TypeNumber
+	TypeString
+	TypeList
+)
+
+func (t Type) String() string {
+	switch t {
+	case TypeNumber:
+		return "number"
+	case TypeString:
+		return "string"
+	case TypeList:
+		return "list"
+	default:
+		return "bool"
+	}
+}
+
+// SyntaxError is a problem found while compiling an expression. Pos is the
+// offset in characters where it was found.
+type SyntaxError struct {
+	Pos int
+	Msg string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("at position %d: %s", e.Pos+1, e.Msg)
+}
+
+// Expr is a compiled, type checked boolean expression
+type Expr struct {
+	src  string
+	root node
+}
+
+// Compile parses and type checks an expression
+func Compile(src string) (*Expr, error) {
+	if len(src) > maxLength {
+		return nil, &SyntaxError{Pos: maxLength, Msg: fmt.Sprintf("expression is longer than %d characters", maxLength)}
+	}
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if next := p.peek(); next.kind != tokenEOF {
+		return nil, &SyntaxError{Pos: next.pos, Msg: fmt.Sprintf("unexpected %s", next)}
+	}
+	if root.typ() != TypeBool {
+		return nil, &SyntaxError{Pos: 0, Msg: fmt.Sprintf("expression is a %s, not a condition", root.typ())}
+	}
+	return &Expr{src: src, root: root}, nil
+}
+
+// String returns the source of the expression
+func (e *Expr) String() string {
+	return e.src
+}
+
+// Eval tells whether the expression holds in env. An expression that
+// depends on an unknown value does not hold.
+func (e *Expr) Eval(env Env) bool {
+	holds, known := e.Evaluate(env)
+	return holds && known
+}
+
+// Evaluate evaluates the expression in env. known is false when the result
+// depends on an unknown value, in which case holds is meaningless.
+func (e *Expr) Evaluate(env Env) (holds, known bool) {
+	v := e.root.eval(env)
+	return v.b, !v.unknown
+}
+
+// value is the result of evaluating a node. Numbers can be unknown, for
+// example the age of an address no data source knows, and so can the
+// conditions that depend on them.
+type value struct {
+	b       bool
+	num     float64
+	str     string
+	list    []string
+	unknown bool
+}
+
+func (v value) format(t Type) string {
+	if v.unknown {
+		return "unknown"
+	}
+	switch t {
+	case TypeNumber:
+		return strconv.FormatFloat(v.num, 'f', -1, 64)
+	case TypeString:
+		return strconv.Quote(v.str)
+	case TypeList:
+		quoted := make([]string, len(v.list))
+		for i, s := range v.list {
+			quoted[i] = strconv.Quote(s)
+		}
+		return "[" + strings.Join(quoted, ", ") + "]"
+	default:
+		return strconv.FormatBool(v.b)
+	}
+}
+
+// sameText compares strings the way categories are compared elsewhere:
+// case-insensitively, with spaces and underscores equivalent
+func sameText(a, b string) bool {
+	normalize := func(s string) string {
+		return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(s)), " ", "_")
+	}
+	return normalize(a) == normalize(b)
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if sameText(item, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,189 @@
+package rules
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clevertechru/tgbot_aml/internal/domain"
+)
+
+func testEnv() Env {
+	amount := 25000.0
+	return Env{
+		Score:      0.72,
+		Categories: []string{"Gambling", "mixer"},
+		Chain:      "TRON",
+		Kind:       "address",
+		Amount:     &amount,
+		Labels:     []string{"exchange"},
+	}
+}
+
+func TestEval(t *testing.T) {
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{`category == "gambling" and chain == "TRON" and amount > 10000`, true},
+		{`category == "gambling" and amount > 30_000`, false},
+		{`category != "sanctions"`, true},
+		{`"mixer" in categories`, true},
+		{`chain in ["ETH", "BTC"]`, false},
+		{`chain in ["eth", "tron"]`, true},
+		{`score >= 70%`, true},
+		{`score > 0.8 or labels == "exchange"`, true},
+		{`not (score < 0.5) and kind == "address"`, true},
+		{`category == "stolen funds" or category == "Stolen_Funds"`, false},
+		{`category == “gambling”`, true},
+		{`true`, true},
+		{`not not false`, false},
+		// age is unknown, so every comparison with it is unknown and
+		// conditions that depend on it do not hold
+		{`age < 30`, false},
+		{`age >= 30`, false},
+		{`not (age < 30)`, false},
+		{`not (age < 30) or score > 0.5`, true},
+		{`not (age < 30) and score > 0.5`, false},
+		{`age < 30 and score > 0.8`, false},
+	}
+	for _, tt := range tests {
+		expr, err := Compile(tt.expr)
+		if err != nil {
+			t.Errorf("Compile(%s) error = %v", tt.expr, err)
+			continue
+		}
+		if got := expr.Eval(testEnv()); got != tt.want {
+			t.Errorf("Eval(%s) = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestEvaluateUnknown(t *testing.T) {
+	tests := []struct {
+		expr  string
+		holds bool
+		known bool
+	}{
+		{`age < 7`, false, false},
+		{`not (age < 7)`, false, false},
+		{`age < 7 and chain == "ETH"`, false, true},
+		{`age < 7 or chain == "TRON"`, true, true},
+		{`age < 7 or chain == "ETH"`, false, false},
+		{`not (amount > 1 and age < 7)`, false, false},
+	}
+	for _, tt := range tests {
+		expr, err := Compile(tt.expr)
+		if err != nil {
+			t.Errorf("Compile(%s) error = %v", tt.expr, err)
+			continue
+		}
+		holds, known := expr.Evaluate(testEnv())
+		if known != tt.known || known && holds != tt.holds {
+			t.Errorf("Evaluate(%s) = %v, %v, want %v, %v", tt.expr, holds, known, tt.holds, tt.known)
+		}
+	}
+}
+
+func TestAmountFromPaymentLink(t *testing.T) {
+	request, err := domain.ParsePaymentURI("ethereum:0x742d35Cc6634C0532925a3b844Bc454e4438f44e@1?value=2.014e18")
+	if err != nil {
+		t.Fatalf("ParsePaymentURI() error = %v", err)
+	}
+	// The same conversion CheckPayment applies
+	amount, _ := strconv.ParseFloat(request.Amount, 64)
+	env := EnvFor(&domain.ScreeningResult{Target: request.Target(), Amount: amount}, time.Now())
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{`amount > 2 and amount < 3`, true},
+		{`amount > 1000`, false},
+	}
+	for _, tt := range tests {
+		expr, err := Compile(tt.expr)
+		if err != nil {
+			t.Fatalf("Compile(%s) error = %v", tt.expr, err)
+		}
+		if holds, known := expr.Evaluate(env); !known || holds != tt.want {
+			t.Errorf("Evaluate(%s) = %v, %v, want %v", tt.expr, holds, known, tt.want)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		pos  int
+		msg  string
+	}{
+		{`score >`, 7, "unexpected end of expression"},
+		{`score > "high"`, 6, "cannot compare number > string"},
+		{`chain > 1`, 6, "cannot compare string > number"},
+		{`balance > 1`, 0, "unknown variable"},
+		{`score`, 0, "not a condition"},
+		{`score > 1 and 2`, 10, `"and" needs bool operands`},
+		{`(score > 1`, 10, `expected ")"`},
+		{`chain == "TRON`, 9, "unterminated string"},
+		{`score > 1 ; drop`, 10, "unexpected character"},
+		{`chain in ["TRON", 1]`, 18, "lists can only hold strings"},
+		{`categories == ["a"]`, 11, "cannot compare list == list"},
+		{`score > 1 score`, 10, "unexpected"},
+		{strings.Repeat("(", maxDepth+1) + "true" + strings.Repeat(")", maxDepth+1), maxDepth, "nested deeper"},
+	}
+	for _, tt := range tests {
+		_, err := Compile(tt.expr)
+		var syntaxErr *SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Errorf("Compile(%s) error = %v, want a SyntaxError", tt.expr, err)
+			continue
+		}
+		if syntaxErr.Pos != tt.pos || !strings.Contains(syntaxErr.Msg, tt.msg) {
+			t.Errorf("Compile(%s) error = %d %q, want %d %q", tt.expr, syntaxErr.Pos, syntaxErr.Msg, tt.pos, tt.msg)
+		}
+	}
+
+	if _, err := Compile(strings.Repeat("true and ", maxLength)); err == nil {
+		t.Error("expected overly long expressions to be refused")
+	}
+}
+
+func TestEnvFor(t *testing.T) {
+	now := time.Date(2024, 5, 31, 0, 0, 0, 0, time.UTC)
+	env := EnvFor(&domain.ScreeningResult{
+		Target:     domain.Target{Value: "T1", Kind: domain.KindAddress, Chain: domain.ChainTron},
+		RiskScore:  0.5,
+		Categories: []string{"mixer"},
+		Responses:  []domain.ProviderResponse{{Categories: []string{"mixer", "scam"}}},
+		Exposure:   map[string]float64{"exchange": 0.6, "mixer": 0.4, "unknown": 0},
+		Amount:     12.5,
+		FirstSeen:  now.AddDate(0, 0, -10),
+	}, now)
+
+	got := make(map[string]string)
+	for _, binding := range env.Bindings() {
+		got[binding.Name] = binding.Value
+	}
+	want := map[string]string{
+		"score":    "0.5",
+		"category": `["mixer", "scam"]`,
+		"chain":    `"TRON"`,
+		"kind":     `"address"`,
+		"amount":   "12.5",
+		"labels":   `["exchange", "mixer"]`,
+		"age":      "10",
+	}
+	for name, value := range want {
+		if got[name] != value {
+			t.Errorf("%s = %s, want %s", name, got[name], value)
+		}
+	}
+
+	env = EnvFor(&domain.ScreeningResult{}, now)
+	if env.Amount != nil || env.Age != nil {
+		t.Errorf("expected unknown amount and age, got %+v", env)
+	}
+}
@@ -0,0 +1,251 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/clevertechru/tgbot_aml/internal/storage"
+)
+
+// Role is what a user or group chat is allowed to do with the bot
+type Role string
+
+const (
+	RoleNone    Role = "none"
+	RoleViewer  Role = "viewer"
+	RoleAnalyst Role = "analyst"
+	RoleAdmin   Role = "admin"
+)
+
+// MaxChatRole is the highest role a group chat can hold. A chat role lifts
+// every member of the chat, so admin stays with individual users.
+const MaxChatRole = RoleAnalyst
+
+// ErrChatRoleTooHigh is returned when a group chat is granted more than
+// MaxChatRole
+var ErrChatRoleTooHigh = errors.New("group chats can hold at most the " + string(MaxChatRole) + " role")
+
+var roleRanks = map[Role]int{
+	RoleNone:    0,
+	RoleViewer:  1,
+	RoleAnalyst: 2,
+	RoleAdmin:   3,
+}
+
+// ParseRole parses a role name as written in config and commands
+func ParseRole(s string) (Role, error) {
+	role := Role(strings.ToLower(strings.TrimSpace(s)))
+	if role == "" {
+		return RoleNone, nil
+	}
+	if _, ok := roleRanks[role]; !ok {
+		return RoleNone, fmt.Errorf("unknown role %q", s)
+	}
+	return role, nil
+}
+
+// Allows reports whether the role grants at least the required one
+func (r Role) Allows(required Role) bool {
+	return roleRanks[r] >= roleRanks[required]
+}
+
+// AccessConfig seeds roles for Telegram users and group chats. Users and
+// chats that are not listed get DefaultRole.
+type AccessConfig struct {
+	DefaultRole Role
+	Users       map[int64]Role
+	Chats       map[int64]Role
+}
+
+// AccessGrants holds changes made at runtime with /grant and /revoke.
+// A RoleNone entry revokes a role seeded from config, and keeps doing so if
+// the config later seeds that ID again.
+type AccessGrants struct {
+	Users map[int64]Role `json:"users"`
+	Chats map[int64]Role `json:"chats"`
+}
+
+// AccessControl resolves roles from config seeds and persisted grants
+type AccessControl struct {
+	mu     sync.RWMutex
+	config AccessConfig
+	grants AccessGrants
+	store  *storage.JSONFile[AccessGrants]
+}
+
+func NewAccessControl(config AccessConfig, store *storage.JSONFile[AccessGrants]) (*AccessControl, error) {
+	grants := AccessGrants{
+		Users: make(map[int64]Role),
+		Chats: make(map[int64]Role),
+	}
+	if err := store.Load(&grants); err != nil {
+		return nil, err
+	}
+	if grants.Users == nil {
+		grants.Users = make(map[int64]Role)
+	}
+	if grants.Chats == nil {
+		grants.Chats = make(map[int64]Role)
+	}
+	if config.DefaultRole == "" {
+		config.DefaultRole = RoleNone
+	}
+
+	return &AccessControl{
+		config: config,
+		grants: grants,
+		store:  store,
+	}, nil
+}
+
+// SetConfig replaces the roles seeded from config. Runtime grants are kept.
+func (a *AccessControl) SetConfig(config AccessConfig) {
+	if config.DefaultRole == "" {
+		config.DefaultRole = RoleNone
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.config = config
+}
+
+func lookupRole(grants, seeds map[int64]Role, id int64) (Role, bool) {
+	if role, ok := grants[id]; ok {
+		return role, true
+	}
+	role, ok := seeds[id]
+	return role, ok
+}
+
+// UserRole returns the role of a user on their own
+func (a *AccessControl) UserRole(userID int64) Role {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if role, ok := lookupRole(a.grants.Users, a.config.Users, userID); ok {
+		return role
+	}
+	return a.config.DefaultRole
+}
+
+// ChatRole returns the role of a chat as a whole: the user's role for a
+// private chat, and the capped chat role or the default for a group
+func (a *AccessControl) ChatRole(chatID int64) Role {
+	if chatID > 0 {
+		return a.UserRole(chatID)
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	role, ok := lookupRole(a.grants.Chats, a.config.Chats, chatID)
+	if !ok {
+		return a.config.DefaultRole
+	}
+	if role.Allows(MaxChatRole) {
+		return MaxChatRole
+	}
+	return role
+}
+
+// RoleIn returns the effective role of a user in a chat: the higher of the
+// user's own role and the role granted to the group chat, if any. The chat
+// role never counts for more than MaxChatRole.
+func (a *AccessControl) RoleIn(userID, chatID int64) Role {
+	role := a.UserRole(userID)
+	if chatID == userID {
+		return role
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if chatRole, ok := lookupRole(a.grants.Chats, a.config.Chats, chatID); ok {
+		if chatRole.Allows(MaxChatRole) {
+			chatRole = MaxChatRole
+		}
+		if chatRole.Allows(role) {
+			return chatRole
+		}
+	}
+	return role
+}
+
+// Grant gives a role to a user, or to a group chat when id is negative as
+// Telegram group IDs are. Chats are refused roles above MaxChatRole.
+func (a *AccessControl) Grant(id int64, role Role) error {
+	if id < 0 && role != MaxChatRole && role.Allows(MaxChatRole) {
+		return ErrChatRoleTooHigh
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if id < 0 {
+		a.grants.Chats[id] = role
+	} else {
+		a.grants.Users[id] = role
+	}
+	return a.store.Save(a.grants)
+}
+
+// Revoke removes any role of a user or group chat. A runtime grant is simply
+// dropped; a role seeded from config is overridden with RoleNone, which
+// List reports as revoked.
+func (a *AccessControl) Revoke(id int64) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	grants, seeds := a.grants.Users, a.config.Users
+	if id < 0 {
+		grants, seeds = a.grants.Chats, a.config.Chats
+	}
+	if role, ok := seeds[id]; ok && role != RoleNone {
+		grants[id] = RoleNone
+	} else {
+		delete(grants, id)
+	}
+	return a.store.Save(a.grants)
+}
+
+// AccessEntry is a user or chat with an explicit role. Revoked entries have
+// RoleNone and override the config.
+type AccessEntry struct {
+	ID      int64
+	Role    Role
+	Revoked bool
+}
+
+// List returns every user and chat with an explicit role or a revocation,
+// sorted by ID
+func (a *AccessControl) List() []AccessEntry {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	merged := make(map[int64]Role)
+	for _, m := range []map[int64]Role{a.config.Users, a.config.Chats} {
+		for id, role := range m {
+			merged[id] = role
+		}
+	}
+	revoked := make(map[int64]bool)
+	for _, m := range []map[int64]Role{a.grants.Users, a.grants.Chats} {
+		for id, role := range m {
+			revoked[id] = role == RoleNone
+			merged[id] = role
+		}
+	}
+
+	var entries []AccessEntry
+	for id, role := range merged {
+		if role != RoleNone || revoked[id] {
+			entries = append(entries, AccessEntry{ID: id, Role: role, Revoked: revoked[id]})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+	return entries
+}
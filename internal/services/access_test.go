@@ -0,0 +1,104 @@
+package services
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/clevertechru/tgbot_aml/internal/storage"
+)
+
+func TestAccessControl(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.json")
+	config := AccessConfig{
+		Users: map[int64]Role{1: RoleAdmin, 2: RoleViewer},
+		Chats: map[int64]Role{-100: RoleAnalyst},
+	}
+
+	access, err := NewAccessControl(config, storage.NewJSONFile[AccessGrants](path))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if role := access.UserRole(3); role != RoleNone {
+		t.Errorf("expected unknown users to get no role, got %q", role)
+	}
+	if role := access.RoleIn(2, -100); role != RoleAnalyst {
+		t.Errorf("expected the chat role to lift a viewer, got %q", role)
+	}
+	if role := access.RoleIn(1, -100); role != RoleAdmin {
+		t.Errorf("expected the chat role not to lower an admin, got %q", role)
+	}
+	if role := access.RoleIn(2, 2); role != RoleViewer {
+		t.Errorf("expected the user role in a private chat, got %q", role)
+	}
+	if err := access.Grant(-200, RoleAdmin); !errors.Is(err, ErrChatRoleTooHigh) {
+		t.Errorf("expected admin to be refused for a chat, got %v", err)
+	}
+
+	if err := access.Grant(3, RoleAnalyst); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := access.Revoke(2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Grants and revocations of seeded roles survive a restart
+	reloaded, err := NewAccessControl(config, storage.NewJSONFile[AccessGrants](path))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if role := reloaded.UserRole(3); role != RoleAnalyst {
+		t.Errorf("expected granted role to persist, got %q", role)
+	}
+	if role := reloaded.UserRole(2); role != RoleNone {
+		t.Errorf("expected revoked seed role to stay revoked, got %q", role)
+	}
+	entries := reloaded.List()
+	if len(entries) != 4 {
+		t.Fatalf("expected 4 entries, got %v", entries)
+	}
+	if entries[2].ID != 2 || !entries[2].Revoked {
+		t.Errorf("expected the revoked seed role to be listed, got %+v", entries[2])
+	}
+
+	// Revoking a runtime grant drops it instead of masking the config
+	if err := reloaded.Revoke(3); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	reloaded.SetConfig(AccessConfig{Users: map[int64]Role{3: RoleViewer}})
+	if role := reloaded.UserRole(3); role != RoleViewer {
+		t.Errorf("expected a later config role to apply after revoking a grant, got %q", role)
+	}
+}
+
+func TestChatRoleIsCapped(t *testing.T) {
+	// A chat seeded as admin, e.g. by an older config, lifts members to
+	// analyst at most
+	config := AccessConfig{
+		Users: map[int64]Role{2: RoleViewer},
+		Chats: map[int64]Role{-100: RoleAdmin},
+	}
+	access, err := NewAccessControl(config, storage.NewJSONFile[AccessGrants](filepath.Join(t.TempDir(), "access.json")))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if role := access.RoleIn(2, -100); role != RoleAnalyst {
+		t.Errorf("expected the chat role to be capped at analyst, got %q", role)
+	}
+	if role := access.RoleIn(3, -100); role != RoleAnalyst {
+		t.Errorf("expected the chat role to be capped for unknown users, got %q", role)
+	}
+}
+
+func TestRoleAllows(t *testing.T) {
+	if !RoleAdmin.Allows(RoleAnalyst) || !RoleViewer.Allows(RoleViewer) {
+		t.Error("higher or equal roles must be allowed")
+	}
+	if RoleViewer.Allows(RoleAnalyst) || RoleNone.Allows(RoleViewer) {
+		t.Error("lower roles must be refused")
+	}
+	if _, err := ParseRole("superuser"); err == nil {
+		t.Error("expected unknown role to fail")
+	}
+}
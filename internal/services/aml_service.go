@@ -2,50 +2,294 @@ package services
 
 import (
 	"context"
+	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/clevertechru/tgbot_aml/internal/domain"
+	"github.com/clevertechru/tgbot_aml/internal/graph"
 )
 
-type AMLService struct {
-	provider interface {
-		CheckAddress(ctx context.Context, address string) (*domain.CheckResult, error)
-		CheckTransaction(ctx context.Context, txHash string) (*domain.CheckResult, error)
-	}
-}
+// graphHealthName is what graph analysis is listed as in provider health
+const graphHealthName = "graph"
 
-func NewAMLService(provider interface {
+// Provider is an AML data source addresses and transactions are checked against
+type Provider interface {
+	Name() string
 	CheckAddress(ctx context.Context, address string) (*domain.CheckResult, error)
 	CheckTransaction(ctx context.Context, txHash string) (*domain.CheckResult, error)
-}) *AMLService {
+}
+
+type AMLService struct {
+	provider Provider
+	cache    *ResultCache
+	model    *RiskModel
+	policy   *Policy
+	graph    *graph.Analyzer
+	health   *healthMonitor
+
+	chainData domain.ChainDataSources
+	firstSeen *firstSeenCache
+	freezes   TokenFreezes
+}
+
+func NewAMLService(provider Provider) *AMLService {
 	return &AMLService{
-		provider: provider,
+		provider:  provider,
+		health:    newHealthMonitor(),
+		firstSeen: newFirstSeenCache(),
+	}
+}
+
+// SetCache enables caching of provider results
+func (s *AMLService) SetCache(cache *ResultCache) {
+	s.cache = cache
+}
+
+// SetRiskModel sets the model that turns provider answers into risk scores.
+// Without one, DefaultRiskWeights apply.
+func (s *AMLService) SetRiskModel(model *RiskModel) {
+	s.model = model
+}
+
+// SetPolicy sets the risk policy that decides screening verdicts. Without
+// one, DefaultPolicy applies.
+func (s *AMLService) SetPolicy(policy *Policy) {
+	s.policy = policy
+}
+
+// SetGraph enables tracing exposure through the transaction graph around
+// screened addresses
+func (s *AMLService) SetGraph(analyzer *graph.Analyzer) {
+	s.graph = analyzer
+}
+
+// SetChainData sets the chain data sources addresses are looked up in, for
+// the date they were first used
+func (s *AMLService) SetChainData(sources domain.ChainDataSources) {
+	s.chainData = sources
+}
+
+// Cache returns the result cache, or nil when caching is disabled
+func (s *AMLService) Cache() *ResultCache {
+	return s.cache
+}
+
+// ProviderHealth returns call statistics for every provider
+func (s *AMLService) ProviderHealth() []ProviderHealth {
+	names := []string{s.provider.Name()}
+	names = append(names, chainDataHealthNames(s.chainData)...)
+	if s.freezes != nil {
+		names = append(names, s.freezes.Name())
+	}
+	if s.graph != nil {
+		names = append(names, graphHealthName)
+	}
+	return s.health.snapshot(names)
+}
+
+// lookup serves a provider call from the cache, or makes it and records its
+// latency and outcome
+func (s *AMLService) lookup(key string, call func() (*domain.CheckResult, error)) (*domain.CheckResult, error) {
+	if result, ok := s.cache.Get(key); ok {
+		return result, nil
+	}
+
+	start := time.Now()
+	result, err := call()
+	s.health.observe(s.provider.Name(), time.Since(start), err)
+	if err != nil {
+		return nil, err
 	}
+
+	s.cache.Put(key, result)
+	return result, nil
 }
 
 func (s *AMLService) CheckAddress(ctx context.Context, address string) (*domain.AMLResult, error) {
-	result, err := s.provider.CheckAddress(ctx, address)
+	result, err := s.lookup("address:"+address, func() (*domain.CheckResult, error) {
+		return s.provider.CheckAddress(ctx, address)
+	})
 	if err != nil {
 		return nil, err
 	}
 
 	return &domain.AMLResult{
 		Address:      address,
+		Provider:     s.provider.Name(),
 		IsSuspicious: result.IsSuspicious,
 		RiskScore:    result.RiskScore,
 		Details:      []string{result.Details},
+		Categories:   result.Categories,
+		Exposure:     result.Exposure,
 	}, nil
 }
 
 func (s *AMLService) CheckTransaction(ctx context.Context, txHash string) (*domain.TransactionResult, error) {
-	result, err := s.provider.CheckTransaction(ctx, txHash)
+	result, err := s.lookup("transaction:"+txHash, func() (*domain.CheckResult, error) {
+		return s.provider.CheckTransaction(ctx, txHash)
+	})
 	if err != nil {
 		return nil, err
 	}
 
 	return &domain.TransactionResult{
 		TransactionID: txHash,
+		Provider:      s.provider.Name(),
 		IsSuspicious:  result.IsSuspicious,
 		RiskScore:     result.RiskScore,
 		Details:       []string{result.Details},
+		Categories:    result.Categories,
+		Exposure:      result.Exposure,
+	}, nil
+}
+
+// Screen checks a detected target with the provider call matching its kind,
+// scores the answer with the risk model and applies the risk policy
+func (s *AMLService) Screen(ctx context.Context, target domain.Target) (*domain.ScreeningResult, error) {
+	return s.screen(ctx, target, 0, "")
+}
+
+// screen is Screen for a payment of amount, 0 when unknown, in the token
+// contract, empty for the native coin
+func (s *AMLService) screen(ctx context.Context, target domain.Target, amount float64, token string) (*domain.ScreeningResult, error) {
+	result, err := s.ask(ctx, target, amount)
+	if err != nil {
+		return nil, err
+	}
+	if target.Kind == domain.KindAddress {
+		s.checkIssuerFreeze(ctx, result, token)
+		s.lookupFirstSeen(ctx, result)
+		s.traceExposure(ctx, result)
+	} else if err := s.screenTransfers(ctx, result); err != nil {
+		return nil, err
+	}
+	s.model.Score(result)
+	s.policy.Apply(result)
+	return result, nil
+}
+
+// screenParty screens a party of a token transfer like screen does an
+// address, except for the graph walk, which would multiply the cost of a
+// transaction check by its number of parties
+func (s *AMLService) screenParty(ctx context.Context, target domain.Target, token string) (*domain.ScreeningResult, error) {
+	result, err := s.ask(ctx, target, 0)
+	if err != nil {
+		return nil, err
+	}
+	s.checkIssuerFreeze(ctx, result, token)
+	s.lookupFirstSeen(ctx, result)
+	s.model.Score(result)
+	s.policy.Apply(result)
+	return result, nil
+}
+
+// ask turns the provider's answer for a target into an unscored result
+func (s *AMLService) ask(ctx context.Context, target domain.Target, amount float64) (*domain.ScreeningResult, error) {
+	var response domain.ProviderResponse
+	if target.Kind == domain.KindTransaction {
+		result, err := s.CheckTransaction(ctx, target.Value)
+		if err != nil {
+			return nil, err
+		}
+		response = domain.ProviderResponse{
+			Provider:     result.Provider,
+			IsSuspicious: result.IsSuspicious,
+			RiskScore:    result.RiskScore,
+			Details:      result.Details,
+			Categories:   result.Categories,
+			Exposure:     result.Exposure,
+		}
+	} else {
+		result, err := s.CheckAddress(ctx, target.Value)
+		if err != nil {
+			return nil, err
+		}
+		response = domain.ProviderResponse{
+			Provider:     result.Provider,
+			IsSuspicious: result.IsSuspicious,
+			RiskScore:    result.RiskScore,
+			Details:      result.Details,
+			Categories:   result.Categories,
+			Exposure:     result.Exposure,
+		}
+	}
+
+	return &domain.ScreeningResult{
+		Target:       target,
+		IsSuspicious: response.IsSuspicious,
+		RiskScore:    response.RiskScore,
+		Details:      response.Details,
+		Categories:   response.Categories,
+		Exposure:     response.Exposure,
+		Responses:    []domain.ProviderResponse{response},
+		Amount:       amount,
 	}, nil
 }
+
+// traceExposure adds the exposure found in the transaction graph to the
+// result, keeping the higher of the provider's and the graph's share for
+// each category. Graph failures only show up in provider health, since the
+// provider's answer is still worth reporting.
+func (s *AMLService) traceExposure(ctx context.Context, result *domain.ScreeningResult) {
+	if s.graph == nil {
+		return
+	}
+
+	start := time.Now()
+	exposure, err := s.graph.Analyze(ctx, result.Target)
+	s.health.observe(graphHealthName, time.Since(start), err)
+	if err != nil {
+		return
+	}
+
+	result.Graph = exposure
+	if len(exposure.Incoming) == 0 {
+		return
+	}
+	merged := make(map[string]float64, len(result.Exposure)+len(exposure.Incoming))
+	for category, share := range result.Exposure {
+		merged[category] = share
+	}
+	for _, traced := range exposure.Incoming {
+		if traced.Share > merged[traced.Category] {
+			merged[traced.Category] = traced.Share
+		}
+	}
+	result.Exposure = merged
+}
+
+// CheckPayment screens the recipient of a payment request and the token
+// contract it pays with. The combined verdict is the worse of the two.
+func (s *AMLService) CheckPayment(ctx context.Context, request *domain.PaymentRequest) (*domain.PaymentCheckResult, error) {
+	// Amount is in whole coins or tokens; an empty or unreadable one, such as
+	// that of an unknown token, is treated as unknown
+	amount, _ := strconv.ParseFloat(request.Amount, 64)
+	recipient, err := s.screen(ctx, request.Target(), amount, request.TokenContract)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &domain.PaymentCheckResult{
+		Request:      request,
+		Recipient:    recipient,
+		IsSuspicious: recipient.IsSuspicious,
+		RiskScore:    recipient.RiskScore,
+		Verdict:      recipient.Verdict,
+	}
+
+	if target, ok := request.TokenTarget(); ok {
+		token, err := s.Screen(ctx, target)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check token contract: %w", err)
+		}
+		result.Token = token
+		result.IsSuspicious = result.IsSuspicious || token.IsSuspicious
+		result.Verdict = result.Verdict.Worse(token.Verdict)
+		if token.RiskScore > result.RiskScore {
+			result.RiskScore = token.RiskScore
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/clevertechru/tgbot_aml/internal/domain"
+	"github.com/clevertechru/tgbot_aml/internal/graph"
+)
+
+func TestAMLServiceCheckPayment(t *testing.T) {
+	service := NewAMLService(stubProvider{})
+
+	request := &domain.PaymentRequest{
+		Chain:         domain.ChainEthereum,
+		Address:       "0x742d35Cc6634C0532925a3b844Bc454e4438f44e",
+		TokenContract: "0xbad0000000000000000000000000000000000000",
+	}
+	result, err := service.CheckPayment(context.Background(), request)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Recipient.IsSuspicious {
+		t.Error("expected recipient to be clean")
+	}
+	if result.Token == nil || !result.Token.IsSuspicious {
+		t.Fatalf("expected suspicious token result, got %+v", result.Token)
+	}
+	if !result.IsSuspicious || result.RiskScore != 0.9 {
+		t.Errorf("expected combined verdict to follow the token, got %v/%v", result.IsSuspicious, result.RiskScore)
+	}
+
+	request.TokenContract = ""
+	result, err = service.CheckPayment(context.Background(), request)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Token != nil || result.IsSuspicious {
+		t.Errorf("expected native payment to be clean, got %+v", result)
+	}
+}
+
+type failingGraph struct{}
+
+func (failingGraph) Transfers(context.Context, domain.Chain, string) ([]graph.Transfer, error) {
+	return nil, errors.New("explorer unavailable")
+}
+
+func TestAMLServiceGraphExposure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "labels.txt")
+	if err := os.WriteFile(path, []byte("0xmixer mixer\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	labels, err := graph.NewLabelSet(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fixture := graph.Fixture{
+		{From: "0xmixer", To: "0xhop", Value: 10},
+		{From: "0xhop", To: "0xclean", Value: 10},
+	}
+
+	service := NewAMLService(stubProvider{})
+	service.SetGraph(graph.NewAnalyzer(fixture, labels, graph.Options{Hops: 2, MaxAddresses: 10}))
+
+	target := domain.Target{Value: "0xclean", Kind: domain.KindAddress, Chain: domain.ChainEthereum}
+	result, err := service.Screen(context.Background(), target)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Graph == nil || result.Exposure["mixer"] != 1 {
+		t.Fatalf("expected the mixer exposure to be traced, got %+v", result.Exposure)
+	}
+	// 0.1 from the provider plus half of the mixer's medium severity signal
+	if result.RiskScore != 0.35 {
+		t.Errorf("RiskScore = %v, want 0.35", result.RiskScore)
+	}
+
+	service.SetGraph(graph.NewAnalyzer(failingGraph{}, labels, graph.Options{Hops: 2, MaxAddresses: 10}))
+	result, err = service.Screen(context.Background(), target)
+	if err != nil {
+		t.Fatalf("expected graph failures not to fail the check, got %v", err)
+	}
+	if result.Graph != nil || result.RiskScore != 0.1 {
+		t.Errorf("expected the provider's answer alone, got %+v", result)
+	}
+	health := service.ProviderHealth()
+	if len(health) != 2 || health[1].Name != "graph" || health[1].Healthy() {
+		t.Errorf("expected graph failures in provider health, got %+v", health)
+	}
+}
+
+func TestProviderExposureTriggersPolicy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"is_suspicious": false, "risk_score": 0.1, "exposure": {"mixer": 0.35}}`)
+	}))
+	defer server.Close()
+
+	provider := domain.NewChainabuseProvider()
+	provider.SetBaseURL(server.URL)
+	service := NewAMLService(provider)
+
+	result, err := service.Screen(context.Background(), domain.Target{Value: "0xclean", Kind: domain.KindAddress, Chain: domain.ChainEthereum})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Exposure["mixer"] != 0.35 {
+		t.Fatalf("expected the provider's exposure, got %+v", result.Exposure)
+	}
+
+	policy, err := NewPolicy(filepath.Join("..", "..", "config", "policy.yml"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	policy.Apply(result)
+	if result.Verdict != domain.VerdictReview || !slices.Contains(result.FiredRules, "mixer exposure") {
+		t.Errorf("expected the mixer exposure rule to send the address to review, got %s %v", result.Verdict, result.FiredRules)
+	}
+}
+
+func TestAMLServiceFirstSeen(t *testing.T) {
+	firstSeen := time.Now().Add(-24 * time.Hour).UTC().Truncate(time.Second)
+	fixture := domain.NewChainFixture(domain.ChainEthereum, []domain.ChainTransaction{{
+		Hash:    "0xa1",
+		Block:   domain.Block{Number: 100, Time: firstSeen},
+		Inputs:  []domain.TxLeg{{Address: "0xfunder", Value: 1}},
+		Outputs: []domain.TxLeg{{Address: "0xnew", Value: 1}},
+	}}, nil)
+
+	service := NewAMLService(stubProvider{})
+	service.SetChainData(domain.ChainDataSources{domain.ChainEthereum: fixture})
+
+	result, err := service.Screen(context.Background(), domain.Target{Value: "0xNEW", Kind: domain.KindAddress, Chain: domain.ChainEthereum})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.FirstSeen.Equal(firstSeen) {
+		t.Errorf("FirstSeen = %v, want %v", result.FirstSeen, firstSeen)
+	}
+	// A day-old address adds the full age weight to the provider's 0.1
+	if math.Abs(result.RiskScore-0.3) > 1e-9 {
+		t.Errorf("RiskScore = %v, want 0.3", result.RiskScore)
+	}
+
+	result, err = service.Screen(context.Background(), domain.Target{Value: "0xunused", Kind: domain.KindAddress, Chain: domain.ChainEthereum})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.FirstSeen.IsZero() {
+		t.Errorf("expected an unused address to have no age, got %v", result.FirstSeen)
+	}
+	health := service.ProviderHealth()
+	if len(health) != 2 || health[1].Name != "fixture (ETH)" || health[1].Calls != 2 || health[1].Errors != 0 {
+		t.Errorf("unexpected provider health %+v", health)
+	}
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/clevertechru/tgbot_aml/internal/domain"
+	"github.com/clevertechru/tgbot_aml/internal/storage"
+)
+
+// errStopScan ends a scan of the audit log early
+var errStopScan = errors.New("stop scan")
+
+// Analyst identifies the Telegram user who requested a check
+type Analyst struct {
+	UserID   int64  `json:"user_id"`
+	Username string `json:"username,omitempty"`
+	Name     string `json:"name,omitempty"`
+}
+
+// AuditEntry is a check as recorded in the audit log
+type AuditEntry struct {
+	ID           string                    `json:"id"`
+	Time         time.Time                 `json:"time"`
+	Analyst      Analyst                   `json:"analyst"`
+	ChatID       int64                     `json:"chat_id"`
+	Query        string                    `json:"query"`
+	Kind         string                    `json:"kind"`
+	Chain        string                    `json:"chain,omitempty"`
+	IsSuspicious bool                      `json:"is_suspicious"`
+	RiskScore    float64                   `json:"risk_score"`
+	Verdict      domain.Verdict            `json:"verdict,omitempty"`
+	FiredRules   []string                  `json:"fired_rules,omitempty"`
+	Details      []string                  `json:"details,omitempty"`
+	Categories   []string                  `json:"categories,omitempty"`
+	Exposure     map[string]float64        `json:"exposure,omitempty"`
+	Factors      []domain.RiskFactor       `json:"factors,omitempty"`
+	Graph        *domain.GraphExposure     `json:"graph,omitempty"`
+	Responses    []domain.ProviderResponse `json:"responses,omitempty"`
+}
+
+// AuditLog records every check in an append-only log
+type AuditLog struct {
+	store *storage.JSONLines[AuditEntry]
+	now   func() time.Time
+}
+
+func NewAuditLog(store *storage.JSONLines[AuditEntry]) *AuditLog {
+	return &AuditLog{
+		store: store,
+		now:   time.Now,
+	}
+}
+
+// Record appends a screening result and returns its entry with a new ID
+func (a *AuditLog) Record(analyst Analyst, chatID int64, result *domain.ScreeningResult) (AuditEntry, error) {
+	now := a.now().UTC()
+	entry := AuditEntry{
+		ID:           newAuditID(now),
+		Time:         now,
+		Analyst:      analyst,
+		ChatID:       chatID,
+		Query:        result.Target.Value,
+		Kind:         result.Target.Kind.String(),
+		Chain:        string(result.Target.Chain),
+		IsSuspicious: result.IsSuspicious,
+		RiskScore:    result.RiskScore,
+		Verdict:      result.Verdict,
+		FiredRules:   result.FiredRules,
+		Details:      result.Details,
+		Categories:   result.Categories,
+		Exposure:     result.Exposure,
+		Factors:      result.Factors,
+		Graph:        result.Graph,
+		Responses:    result.Responses,
+	}
+	return entry, a.store.Append(entry)
+}
+
+// Find returns the entry with the given ID
+func (a *AuditLog) Find(id string) (AuditEntry, bool, error) {
+	var found AuditEntry
+	err := a.store.Scan(func(entry AuditEntry) error {
+		if entry.ID != id {
+			return nil
+		}
+		found = entry
+		return errStopScan
+	})
+	if errors.Is(err, errStopScan) {
+		return found, true, nil
+	}
+	return AuditEntry{}, false, err
+}
+
+// Scan calls fn for every entry recorded in [from, to), oldest first,
+// without loading the whole log into memory
+func (a *AuditLog) Scan(from, to time.Time, fn func(AuditEntry) error) error {
+	return a.store.Scan(func(entry AuditEntry) error {
+		if entry.Time.Before(from) || !entry.Time.Before(to) {
+			return nil
+		}
+		return fn(entry)
+	})
+}
+
+// newAuditID returns a sortable, unique ID such as 20240501-1f3a9c0e2b7d
+func newAuditID(now time.Time) string {
+	var random [6]byte
+	if _, err := rand.Read(random[:]); err != nil {
+		// Fall back to the clock, which is unique enough for a single bot
+		return now.Format("20060102") + "-" + strconv.FormatInt(now.UnixNano(), 16)
+	}
+	return now.Format("20060102") + "-" + hex.EncodeToString(random[:])
+}
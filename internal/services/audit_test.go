@@ -0,0 +1,63 @@
+package services
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clevertechru/tgbot_aml/internal/domain"
+	"github.com/clevertechru/tgbot_aml/internal/storage"
+)
+
+func TestAuditLogRecordAndScan(t *testing.T) {
+	log := NewAuditLog(storage.NewJSONLines[AuditEntry](filepath.Join(t.TempDir(), "audit.jsonl")))
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	log.now = func() time.Time { return now }
+
+	var ids []string
+	for i, value := range []string{"addr1", "addr2", "addr3"} {
+		now = now.Add(time.Duration(i) * 24 * time.Hour)
+		entry, err := log.Record(Analyst{UserID: 42, Username: "alice"}, -100, &domain.ScreeningResult{
+			Target:       domain.Target{Value: value, Kind: domain.KindAddress, Chain: domain.ChainTron},
+			IsSuspicious: i == 1,
+			RiskScore:    0.5,
+			Responses:    []domain.ProviderResponse{{Provider: "mock", RiskScore: 0.5}},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !strings.HasPrefix(entry.ID, now.Format("20060102")+"-") {
+			t.Errorf("unexpected ID %q", entry.ID)
+		}
+		ids = append(ids, entry.ID)
+	}
+
+	entry, found, err := log.Find(ids[1])
+	if err != nil || !found {
+		t.Fatalf("Find() = %v, %v", found, err)
+	}
+	if entry.Query != "addr2" || !entry.IsSuspicious || entry.Kind != "address" ||
+		entry.Chain != string(domain.ChainTron) || entry.Analyst.Username != "alice" ||
+		len(entry.Responses) != 1 || entry.Responses[0].Provider != "mock" {
+		t.Errorf("unexpected entry: %+v", entry)
+	}
+
+	if _, found, err := log.Find("missing"); found || err != nil {
+		t.Errorf("Find(missing) = %v, %v", found, err)
+	}
+
+	var queries []string
+	from := time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 5, 4, 12, 0, 0, 0, time.UTC)
+	err = log.Scan(from, to, func(entry AuditEntry) error {
+		queries = append(queries, entry.Query)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(queries, ",") != "addr2" {
+		t.Errorf("Scan() = %v, want [addr2]", queries)
+	}
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Blocklist holds addresses we consider risky regardless of what providers
+// say. The file lists one address per line, optionally followed by
+// "# reason"; blank lines and lines starting with "#" are ignored.
+type Blocklist struct {
+	mu      sync.RWMutex
+	path    string
+	entries map[string]string
+}
+
+// NewBlocklist loads the blocklist at path. An empty path is an empty list.
+func NewBlocklist(path string) (*Blocklist, error) {
+	b := &Blocklist{}
+	if err := b.Load(path); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Load replaces the list with the one at path. On error the current list
+// stays in force.
+func (b *Blocklist) Load(path string) error {
+	entries := make(map[string]string)
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read blocklist: %w", err)
+		}
+		entries = parseBlocklist(data)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.path = path
+	b.entries = entries
+	return nil
+}
+
+// Replace takes over the entries of other, for reloads that must apply
+// every file or none
+func (b *Blocklist) Replace(other *Blocklist) {
+	other.mu.RLock()
+	path, entries := other.path, other.entries
+	other.mu.RUnlock()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.path = path
+	b.entries = entries
+}
+
+func parseBlocklist(data []byte) map[string]string {
+	entries := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		address, reason, _ := strings.Cut(line, "#")
+		entries[blocklistKey(strings.TrimSpace(address))] = strings.TrimSpace(reason)
+	}
+	return entries
+}
+
+// blocklistKey ignores case, since EVM addresses are often written in
+// mixed-case checksum form
+func blocklistKey(address string) string {
+	return strings.ToLower(address)
+}
+
+// Lookup tells whether an address is listed and why
+func (b *Blocklist) Lookup(address string) (reason string, ok bool) {
+	if b == nil {
+		return "", false
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	reason, ok = b.entries[blocklistKey(address)]
+	return reason, ok
+}
+
+// Len returns the number of listed addresses
+func (b *Blocklist) Len() int {
+	if b == nil {
+		return 0
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return len(b.entries)
+}
@@ -0,0 +1,299 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/clevertechru/tgbot_aml/internal/domain"
+)
+
+var (
+	ErrBulkJobRunning = errors.New("a bulk job is already running")
+	ErrBulkTooMany    = errors.New("too many entries in file")
+	ErrBulkEmpty      = errors.New("no entries found in file")
+)
+
+// headerNames are column titles recognized as holding the address
+var headerNames = map[string]bool{
+	"address": true,
+	"wallet":  true,
+	"target":  true,
+	"hash":    true,
+	"tx_hash": true,
+}
+
+// BulkLimits bounds the work a single user may request at once
+type BulkLimits struct {
+	MaxFileSize int64
+	MaxEntries  int
+	Workers     int
+}
+
+// BulkRow is the screening outcome of a single uploaded entry
+type BulkRow struct {
+	Input  string
+	Result *domain.ScreeningResult
+	Err    error
+}
+
+// BulkJobs screens uploaded lists and keeps at most one running job per user
+type BulkJobs struct {
+	mu         sync.Mutex
+	amlService *AMLService
+	limits     BulkLimits
+	running    map[int64]context.CancelFunc
+}
+
+func NewBulkJobs(amlService *AMLService, limits BulkLimits) *BulkJobs {
+	if limits.Workers < 1 {
+		limits.Workers = 1
+	}
+	return &BulkJobs{
+		amlService: amlService,
+		limits:     limits,
+		running:    make(map[int64]context.CancelFunc),
+	}
+}
+
+func (j *BulkJobs) Limits() BulkLimits {
+	return j.limits
+}
+
+// Start registers a job for the user and returns its context. The caller must
+// call Finish when the job is over.
+func (j *BulkJobs) Start(ctx context.Context, userID int64) (context.Context, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if _, ok := j.running[userID]; ok {
+		return nil, ErrBulkJobRunning
+	}
+	jobCtx, cancel := context.WithCancel(ctx)
+	j.running[userID] = cancel
+	return jobCtx, nil
+}
+
+// Cancel stops the user's running job and reports whether there was one
+func (j *BulkJobs) Cancel(userID int64) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	cancel, ok := j.running[userID]
+	if ok {
+		cancel()
+	}
+	return ok
+}
+
+// Finish releases the user's job slot
+func (j *BulkJobs) Finish(userID int64) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if cancel, ok := j.running[userID]; ok {
+		cancel()
+		delete(j.running, userID)
+	}
+}
+
+// Parse reads one entry per line, or a CSV whose header names the address
+// column. Blank lines and lines starting with # are skipped.
+func (j *BulkJobs) Parse(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	if len(lines) == 0 {
+		return nil, ErrBulkEmpty
+	}
+
+	column := -1
+	if strings.ContainsAny(lines[0], ",;\t") {
+		column = 0
+		header := splitCSVLine(lines[0])
+		for i, name := range header {
+			if headerNames[strings.ToLower(strings.TrimSpace(name))] {
+				column = i
+				lines = lines[1:]
+				break
+			}
+		}
+	} else if headerNames[strings.ToLower(lines[0])] {
+		lines = lines[1:]
+	}
+
+	seen := make(map[string]struct{})
+	var entries []string
+	for _, line := range lines {
+		entry := line
+		if column >= 0 {
+			fields := splitCSVLine(line)
+			if column >= len(fields) {
+				continue
+			}
+			entry = strings.TrimSpace(fields[column])
+		}
+		if entry == "" {
+			continue
+		}
+		if _, dup := seen[entry]; dup {
+			continue
+		}
+		seen[entry] = struct{}{}
+		entries = append(entries, entry)
+		if len(entries) > j.limits.MaxEntries {
+			return nil, ErrBulkTooMany
+		}
+	}
+	if len(entries) == 0 {
+		return nil, ErrBulkEmpty
+	}
+	return entries, nil
+}
+
+func splitCSVLine(line string) []string {
+	reader := csv.NewReader(strings.NewReader(line))
+	reader.LazyQuotes = true
+	switch {
+	case strings.Contains(line, ";"):
+		reader.Comma = ';'
+	case strings.Contains(line, "\t"):
+		reader.Comma = '\t'
+	}
+	fields, err := reader.Read()
+	if err != nil {
+		return []string{line}
+	}
+	return fields
+}
+
+// Run screens entries with bounded concurrency. progress is called after
+// every finished entry, possibly from several workers at once, so it must
+// not assume the counts arrive in order. Entries not reached before ctx is
+// cancelled carry the context error.
+func (j *BulkJobs) Run(ctx context.Context, entries []string, progress func(done, total int)) []BulkRow {
+	rows := make([]BulkRow, len(entries))
+	indexes := make(chan int)
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		done int
+	)
+	for w := 0; w < j.limits.Workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				rows[i] = j.screen(ctx, entries[i])
+
+				// Progress usually edits a Telegram message, which must not
+				// hold up the other workers
+				mu.Lock()
+				done++
+				finished := done
+				mu.Unlock()
+				if progress != nil {
+					progress(finished, len(entries))
+				}
+			}
+		}()
+	}
+
+	next := 0
+feed:
+	for ; next < len(entries); next++ {
+		select {
+		case indexes <- next:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	for i := next; i < len(entries); i++ {
+		rows[i] = BulkRow{Input: entries[i], Err: ctx.Err()}
+	}
+	return rows
+}
+
+func (j *BulkJobs) screen(ctx context.Context, input string) BulkRow {
+	target, ok := domain.ParseTarget(input)
+	if !ok {
+		return BulkRow{Input: input, Err: errors.New("unrecognized address format")}
+	}
+	if err := ctx.Err(); err != nil {
+		return BulkRow{Input: input, Err: err}
+	}
+
+	result, err := j.amlService.Screen(ctx, target)
+	return BulkRow{Input: input, Result: result, Err: err}
+}
+
+// bulkHeader names the columns of bulk results
+var bulkHeader = []string{"address", "chain", "verdict", "risk_score", "categories", "fired_rules", "details", "error"}
+
+// bulkRecord lays out a row in the columns of bulkHeader. The verdict is
+// the policy's (allow, review or block), or error.
+func bulkRecord(row BulkRow) []string {
+	record := []string{row.Input, "", "error", "", "", "", "", ""}
+	if row.Err != nil {
+		record[7] = row.Err.Error()
+		return record
+	}
+	record[1] = string(row.Result.Target.Chain)
+	record[2] = string(row.Result.Verdict)
+	record[3] = strconv.FormatFloat(row.Result.RiskScore, 'f', 2, 64)
+	record[4] = strings.Join(row.Result.Categories, "; ")
+	record[5] = strings.Join(row.Result.FiredRules, "; ")
+	record[6] = strings.Join(row.Result.Details, "; ")
+	return record
+}
+
+// WriteBulkCSV writes one row per entry with its verdict, score, categories,
+// fired rules and error
+func WriteBulkCSV(w io.Writer, rows []BulkRow) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(bulkHeader); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		record := bulkRecord(row)
+		for i, cell := range record {
+			record[i] = csvSafe(cell)
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// csvSafe keeps spreadsheets from running a cell as a formula. Entries
+// come from uploaded files, so a cell starting with =, +, -, @ or a
+// control character is prefixed with a quote.
+func csvSafe(cell string) string {
+	if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
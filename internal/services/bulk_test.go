@@ -0,0 +1,195 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/clevertechru/tgbot_aml/internal/domain"
+)
+
+type stubProvider struct{}
+
+func (stubProvider) Name() string {
+	return "stub"
+}
+
+func (stubProvider) CheckAddress(ctx context.Context, address string) (*domain.CheckResult, error) {
+	if strings.HasPrefix(address, "0xbad") {
+		return &domain.CheckResult{IsSuspicious: true, RiskScore: 0.9, Categories: []string{"mixer"}}, nil
+	}
+	return &domain.CheckResult{RiskScore: 0.1}, nil
+}
+
+func (stubProvider) CheckTransaction(ctx context.Context, txHash string) (*domain.CheckResult, error) {
+	return &domain.CheckResult{RiskScore: 0.2}, nil
+}
+
+func TestBulkJobsParse(t *testing.T) {
+	jobs := NewBulkJobs(NewAMLService(stubProvider{}), BulkLimits{MaxEntries: 10})
+
+	cases := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{"plain lines", "0xaaa\n\n# comment\n0xbbb\n0xaaa\n", []string{"0xaaa", "0xbbb"}},
+		{"single header", "address\n0xaaa\n", []string{"0xaaa"}},
+		{"csv with header", "id,Address,note\n1,0xaaa,x\n2,0xbbb,y\n", []string{"0xaaa", "0xbbb"}},
+		{"csv without header", "0xaaa;first\n0xbbb;second\n", []string{"0xaaa", "0xbbb"}},
+	}
+
+	for _, tc := range cases {
+		entries, err := jobs.Parse(strings.NewReader(tc.input))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.name, err)
+		}
+		if strings.Join(entries, ",") != strings.Join(tc.expected, ",") {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expected, entries)
+		}
+	}
+
+	if _, err := jobs.Parse(strings.NewReader("\n# nothing\n")); !errors.Is(err, ErrBulkEmpty) {
+		t.Errorf("expected ErrBulkEmpty, got %v", err)
+	}
+	var many strings.Builder
+	for i := 0; i < 11; i++ {
+		fmt.Fprintf(&many, "0x%d\n", i)
+	}
+	if _, err := jobs.Parse(strings.NewReader(many.String())); !errors.Is(err, ErrBulkTooMany) {
+		t.Errorf("expected ErrBulkTooMany, got %v", err)
+	}
+}
+
+func TestBulkJobsRun(t *testing.T) {
+	jobs := NewBulkJobs(NewAMLService(stubProvider{}), BulkLimits{MaxEntries: 10, Workers: 3})
+	entries := []string{
+		"0xbad0000000000000000000000000000000000000",
+		"0x742d35Cc6634C0532925a3b844Bc454e4438f44e",
+		"not-an-address",
+	}
+
+	var calls atomic.Int32
+	rows := jobs.Run(context.Background(), entries, func(done, total int) { calls.Add(1) })
+	if calls := int(calls.Load()); calls != len(entries) {
+		t.Errorf("expected %d progress calls, got %d", len(entries), calls)
+	}
+	if !rows[0].Result.IsSuspicious || rows[1].Result.IsSuspicious || rows[2].Err == nil {
+		t.Fatalf("unexpected rows: %+v", rows)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteBulkCSV(&buf, rows); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("expected header and 3 rows, got %d lines", len(lines))
+	}
+	if !strings.Contains(lines[1], "review,0.90,mixer,provider flagged,") {
+		t.Errorf("unexpected first row %q", lines[1])
+	}
+
+	buf.Reset()
+	if err := WriteBulkXLSX(&buf, rows); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sheet := readSheet(t, buf.Bytes())
+	for _, want := range []string{
+		`<c r="C2" t="inlineStr"><is><t xml:space="preserve">review</t></is></c>`,
+		`<c r="F2" t="inlineStr"><is><t xml:space="preserve">provider flagged</t></is></c>`,
+		`<c r="D2"><v>0.90</v></c>`,
+		`<row r="4">`,
+	} {
+		if !strings.Contains(string(sheet), want) {
+			t.Errorf("sheet does not contain %s", want)
+		}
+	}
+}
+
+// readSheet returns the worksheet of a workbook written by WriteBulkXLSX
+func readSheet(t *testing.T, workbook []byte) []byte {
+	t.Helper()
+	archive, err := zip.NewReader(bytes.NewReader(workbook), int64(len(workbook)))
+	if err != nil {
+		t.Fatalf("the workbook is not a zip archive: %v", err)
+	}
+	var sheet []byte
+	for _, file := range archive.File {
+		if file.Name == "xl/worksheets/sheet1.xml" {
+			r, err := file.Open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			sheet, _ = io.ReadAll(r)
+		}
+	}
+	if err := xml.Unmarshal(sheet, new(struct{})); err != nil {
+		t.Fatalf("the sheet is not valid XML: %v", err)
+	}
+	return sheet
+}
+
+func TestBulkResultsFormulaInjection(t *testing.T) {
+	rows := []BulkRow{{Input: `=HYPERLINK("http://evil","x")`, Err: errors.New("@SUM(1+1)")}}
+
+	var buf bytes.Buffer
+	if err := WriteBulkCSV(&buf, rows); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := records[1][0]; got != `'=HYPERLINK("http://evil","x")` {
+		t.Errorf("expected the address to be quoted, got %q", got)
+	}
+	if got := records[1][7]; got != "'@SUM(1+1)" {
+		t.Errorf("expected the error to be quoted, got %q", got)
+	}
+	if got := records[1][2]; got != "error" {
+		t.Errorf("expected other cells to be left alone, got %q", got)
+	}
+
+	buf.Reset()
+	if err := WriteBulkXLSX(&buf, rows); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sheet := string(readSheet(t, buf.Bytes()))
+	if !strings.Contains(sheet, `<c r="A2" t="inlineStr"><is><t xml:space="preserve">=HYPERLINK(`) || strings.Contains(sheet, "<f>") {
+		t.Errorf("expected the address as an inline string, got %s", sheet)
+	}
+}
+
+func TestBulkJobsCancel(t *testing.T) {
+	jobs := NewBulkJobs(NewAMLService(stubProvider{}), BulkLimits{MaxEntries: 10})
+
+	ctx, err := jobs.Start(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := jobs.Start(context.Background(), 1); !errors.Is(err, ErrBulkJobRunning) {
+		t.Errorf("expected ErrBulkJobRunning, got %v", err)
+	}
+	if !jobs.Cancel(1) {
+		t.Fatal("expected running job to be cancelled")
+	}
+
+	rows := jobs.Run(ctx, []string{"0x742d35Cc6634C0532925a3b844Bc454e4438f44e"}, nil)
+	if !errors.Is(rows[0].Err, context.Canceled) {
+		t.Errorf("expected cancelled row, got %+v", rows[0])
+	}
+
+	jobs.Finish(1)
+	if jobs.Cancel(1) {
+		t.Error("expected no running job after Finish")
+	}
+}
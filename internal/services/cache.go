@@ -0,0 +1,134 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	"github.com/clevertechru/tgbot_aml/internal/domain"
+)
+
+// maxCacheEntries bounds the result cache; when it is full the entry closest
+// to expiry makes room for a new one
+const maxCacheEntries = 10000
+
+type cacheEntry struct {
+	result  *domain.CheckResult
+	expires time.Time
+}
+
+// ResultCache keeps provider results for a limited time so repeated checks of
+// the same address do not spend API quota. A zero TTL disables caching.
+// Expired entries are swept once per TTL as results are added.
+type ResultCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	entries    map[string]cacheEntry
+	swept      time.Time
+	now        func() time.Time
+}
+
+func NewResultCache(ttl time.Duration) *ResultCache {
+	return &ResultCache{
+		ttl:        ttl,
+		maxEntries: maxCacheEntries,
+		entries:    make(map[string]cacheEntry),
+		now:        time.Now,
+	}
+}
+
+func (c *ResultCache) Get(key string) (*domain.CheckResult, bool) {
+	if c == nil || c.ttl <= 0 {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if c.now().After(entry.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return entry.result, true
+}
+
+func (c *ResultCache) Put(key string, result *domain.CheckResult) {
+	if c == nil || c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if now.Sub(c.swept) >= c.ttl {
+		c.sweep(now)
+	}
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= c.maxEntries {
+		c.sweep(now)
+		if len(c.entries) >= c.maxEntries {
+			c.evictOldest()
+		}
+	}
+	c.entries[key] = cacheEntry{result: result, expires: now.Add(c.ttl)}
+}
+
+// sweep drops expired entries. The caller holds the lock.
+func (c *ResultCache) sweep(now time.Time) {
+	for key, entry := range c.entries {
+		if now.After(entry.expires) {
+			delete(c.entries, key)
+		}
+	}
+	c.swept = now
+}
+
+// evictOldest drops the entry closest to expiry, which with a single TTL is
+// the one added first. The caller holds the lock.
+func (c *ResultCache) evictOldest() {
+	var oldest string
+	var expires time.Time
+	for key, entry := range c.entries {
+		if oldest == "" || entry.expires.Before(expires) {
+			oldest, expires = key, entry.expires
+		}
+	}
+	delete(c.entries, oldest)
+}
+
+// Flush drops every entry and returns how many there were
+func (c *ResultCache) Flush() int {
+	if c == nil {
+		return 0
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	n := len(c.entries)
+	c.entries = make(map[string]cacheEntry)
+	return n
+}
+
+// Len returns the number of entries that have not expired
+func (c *ResultCache) Len() int {
+	if c == nil {
+		return 0
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	n := 0
+	for _, entry := range c.entries {
+		if !now.After(entry.expires) {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/clevertechru/tgbot_aml/internal/domain"
+)
+
+// maxFirstSeenEntries bounds the first-seen cache, which is emptied when full
+const maxFirstSeenEntries = 10000
+
+// firstSeenCache remembers when addresses were first used. That never
+// changes once known, so entries do not expire.
+type firstSeenCache struct {
+	mu      sync.Mutex
+	entries map[string]time.Time
+}
+
+func newFirstSeenCache() *firstSeenCache {
+	return &firstSeenCache{entries: make(map[string]time.Time)}
+}
+
+func (c *firstSeenCache) get(key string) (time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t, ok := c.entries[key]
+	return t, ok
+}
+
+func (c *firstSeenCache) put(key string, t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.entries) >= maxFirstSeenEntries {
+		c.entries = make(map[string]time.Time)
+	}
+	c.entries[key] = t
+}
+
+// chainDataHealthName is what a chain data source is listed as in provider
+// health
+func chainDataHealthName(source domain.ChainDataSource) string {
+	return fmt.Sprintf("%s (%s)", source.Name(), source.Chain())
+}
+
+// chainDataHealthNames lists the chain data sources in chain order
+func chainDataHealthNames(sources domain.ChainDataSources) []string {
+	chains := make([]string, 0, len(sources))
+	for chain := range sources {
+		chains = append(chains, string(chain))
+	}
+	sort.Strings(chains)
+
+	names := make([]string, 0, len(chains))
+	for _, chain := range chains {
+		if source, ok := sources.For(domain.Chain(chain)); ok {
+			names = append(names, chainDataHealthName(source))
+		}
+	}
+	return names
+}
+
+// lookupFirstSeen fills in when the target address was first used, from the
+// chain data source of its chain. Like graph analysis, failures only show
+// up in provider health.
+func (s *AMLService) lookupFirstSeen(ctx context.Context, result *domain.ScreeningResult) {
+	source, ok := s.chainData.For(result.Target.Chain)
+	if !ok || !result.FirstSeen.IsZero() {
+		return
+	}
+
+	key := string(result.Target.Chain) + ":" + strings.ToLower(result.Target.Value)
+	if t, ok := s.firstSeen.get(key); ok {
+		result.FirstSeen = t
+		return
+	}
+
+	start := time.Now()
+	block, err := source.FirstSeen(ctx, result.Target.Value)
+	if errors.Is(err, domain.ErrNotFound) {
+		// A never used address has no age yet, which is not a failure
+		err = nil
+	}
+	s.health.observe(chainDataHealthName(source), time.Since(start), err)
+	if err != nil || block.Time.IsZero() {
+		return
+	}
+
+	s.firstSeen.put(key, block.Time)
+	result.FirstSeen = block.Time
+}
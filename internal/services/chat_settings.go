@@ -0,0 +1,77 @@
+package services
+
+import (
+	"sync"
+
+	"github.com/clevertechru/tgbot_aml/internal/storage"
+)
+
+// ScanSettings controls passive address detection in a group chat
+type ScanSettings struct {
+	Enabled        bool    `json:"enabled"`
+	OnlySuspicious bool    `json:"only_suspicious"`
+	MinRiskScore   float64 `json:"min_risk_score"`
+}
+
+// ChatSettings keeps per-chat settings and persists every change
+type ChatSettings struct {
+	mu       sync.RWMutex
+	defaults ScanSettings
+	scan     map[int64]ScanSettings
+	store    *storage.JSONFile[map[int64]ScanSettings]
+}
+
+func NewChatSettings(defaults ScanSettings, store *storage.JSONFile[map[int64]ScanSettings]) (*ChatSettings, error) {
+	scan := make(map[int64]ScanSettings)
+	if err := store.Load(&scan); err != nil {
+		return nil, err
+	}
+
+	return &ChatSettings{
+		defaults: defaults,
+		scan:     scan,
+		store:    store,
+	}, nil
+}
+
+// SetDefaults changes the settings of chats that never changed their own
+func (s *ChatSettings) SetDefaults(defaults ScanSettings) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.defaults = defaults
+}
+
+// Scan returns the scan settings of a chat, falling back to the defaults
+func (s *ChatSettings) Scan(chatID int64) ScanSettings {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if settings, ok := s.scan[chatID]; ok {
+		return settings
+	}
+	return s.defaults
+}
+
+// UpdateScan applies fn to the chat's scan settings and saves the result
+func (s *ChatSettings) UpdateScan(chatID int64, fn func(*ScanSettings)) (ScanSettings, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	settings, ok := s.scan[chatID]
+	if !ok {
+		settings = s.defaults
+	}
+	fn(&settings)
+	s.scan[chatID] = settings
+
+	return settings, s.store.Save(s.scan)
+}
+
+// ShouldReport tells whether a result passes the chat's reporting filters
+func (s ScanSettings) ShouldReport(isSuspicious bool, riskScore float64) bool {
+	if s.OnlySuspicious && !isSuspicious {
+		return false
+	}
+	return riskScore >= s.MinRiskScore
+}
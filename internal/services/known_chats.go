@@ -0,0 +1,81 @@
+package services
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/clevertechru/tgbot_aml/internal/storage"
+)
+
+// touchInterval limits how often a chat's last activity is written to disk
+const touchInterval = time.Hour
+
+// KnownChat is a chat the bot has received messages from
+type KnownChat struct {
+	Title    string    `json:"title"`
+	Type     string    `json:"type"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// KnownChats remembers every chat the bot has talked to, for broadcasts
+type KnownChats struct {
+	mu    sync.Mutex
+	chats map[int64]KnownChat
+	store *storage.JSONFile[map[int64]KnownChat]
+	now   func() time.Time
+}
+
+func NewKnownChats(store *storage.JSONFile[map[int64]KnownChat]) (*KnownChats, error) {
+	chats := make(map[int64]KnownChat)
+	if err := store.Load(&chats); err != nil {
+		return nil, err
+	}
+
+	return &KnownChats{
+		chats: chats,
+		store: store,
+		now:   time.Now,
+	}, nil
+}
+
+// Touch records activity in a chat. It only writes to disk for new chats or
+// when the last write is older than touchInterval.
+func (k *KnownChats) Touch(id int64, title, chatType string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	now := k.now()
+	chat, ok := k.chats[id]
+	if ok && chat.Title == title && now.Sub(chat.LastSeen) < touchInterval {
+		return nil
+	}
+
+	k.chats[id] = KnownChat{Title: title, Type: chatType, LastSeen: now}
+	return k.store.Save(k.chats)
+}
+
+// Forget removes a chat, for example after the bot was blocked or removed
+func (k *KnownChats) Forget(id int64) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if _, ok := k.chats[id]; !ok {
+		return nil
+	}
+	delete(k.chats, id)
+	return k.store.Save(k.chats)
+}
+
+// IDs returns the IDs of all known chats in ascending order
+func (k *KnownChats) IDs() []int64 {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	ids := make([]int64, 0, len(k.chats))
+	for id := range k.chats {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
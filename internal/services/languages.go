@@ -0,0 +1,73 @@
+package services
+
+import (
+	"sync"
+
+	"github.com/clevertechru/tgbot_aml/internal/storage"
+)
+
+// LanguageChoices holds the languages picked with /language: per user, and
+// per group chat as a default for its members
+type LanguageChoices struct {
+	Users map[int64]string `json:"users"`
+	Chats map[int64]string `json:"chats"`
+}
+
+// Languages keeps language preferences and persists every change
+type Languages struct {
+	mu      sync.RWMutex
+	choices LanguageChoices
+	store   *storage.JSONFile[LanguageChoices]
+}
+
+func NewLanguages(store *storage.JSONFile[LanguageChoices]) (*Languages, error) {
+	var choices LanguageChoices
+	if err := store.Load(&choices); err != nil {
+		return nil, err
+	}
+	if choices.Users == nil {
+		choices.Users = make(map[int64]string)
+	}
+	if choices.Chats == nil {
+		choices.Chats = make(map[int64]string)
+	}
+
+	return &Languages{
+		choices: choices,
+		store:   store,
+	}, nil
+}
+
+// User returns the language a user picked, if any
+func (l *Languages) User(userID int64) (string, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	code, ok := l.choices.Users[userID]
+	return code, ok
+}
+
+// Chat returns the default language of a group chat, if any
+func (l *Languages) Chat(chatID int64) (string, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	code, ok := l.choices.Chats[chatID]
+	return code, ok
+}
+
+func (l *Languages) SetUser(userID int64, code string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.choices.Users[userID] = code
+	return l.store.Save(l.choices)
+}
+
+func (l *Languages) SetChat(chatID int64, code string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.choices.Chats[chatID] = code
+	return l.store.Save(l.choices)
+}
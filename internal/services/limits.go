@@ -0,0 +1,276 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/clevertechru/tgbot_aml/internal/storage"
+)
+
+var (
+	ErrRateLimited   = errors.New("rate limit exceeded")
+	ErrQuotaExceeded = errors.New("daily check quota exceeded")
+)
+
+// LimitError tells the caller why a request was refused and when it may be
+// retried
+type LimitError struct {
+	Reason     error
+	RetryAfter time.Duration
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%v, retry after %s", e.Reason, e.RetryAfter)
+}
+
+func (e *LimitError) Unwrap() error {
+	return e.Reason
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// RateLimiter is a token bucket per key. A zero rate disables limiting.
+type RateLimiter struct {
+	mu      sync.Mutex
+	rate    float64 // tokens per second
+	burst   float64
+	buckets map[int64]*bucket
+	now     func() time.Time
+}
+
+func NewRateLimiter(perMinute, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		rate:    float64(perMinute) / 60,
+		burst:   float64(burst),
+		buckets: make(map[int64]*bucket),
+		now:     time.Now,
+	}
+}
+
+// SetRate changes the refill rate and burst size. Existing buckets keep
+// their tokens, capped at the new burst.
+func (l *RateLimiter) SetRate(perMinute, burst int) {
+	if burst < 1 {
+		burst = 1
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.rate = float64(perMinute) / 60
+	l.burst = float64(burst)
+}
+
+// Allow takes a token for key. When none is left it returns how long to wait
+// for the next one.
+func (l *RateLimiter) Allow(key int64) (bool, time.Duration) {
+	if l.rate <= 0 {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens += now.Sub(b.last).Seconds() * l.rate
+	if b.tokens > l.burst {
+		b.tokens = l.burst
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+		return false, wait
+	}
+	b.tokens--
+	return true, 0
+}
+
+// QuotaUsage is the number of checks a user made on a given UTC day
+type QuotaUsage struct {
+	Day  string `json:"day"`
+	Used int    `json:"used"`
+}
+
+// QuotaStatus describes a user's quota for the current day
+type QuotaStatus struct {
+	Tier    string
+	Limit   int
+	Used    int
+	ResetAt time.Time
+}
+
+// Unlimited reports whether the user's tier has no daily cap
+func (s QuotaStatus) Unlimited() bool {
+	return s.Limit <= 0
+}
+
+// Remaining returns the checks left today, or -1 when unlimited
+func (s QuotaStatus) Remaining() int {
+	if s.Unlimited() {
+		return -1
+	}
+	if s.Used >= s.Limit {
+		return 0
+	}
+	return s.Limit - s.Used
+}
+
+// QuotaConfig maps users to tiers and tiers to daily check limits.
+// A limit of zero or less means unlimited.
+type QuotaConfig struct {
+	DefaultTier string
+	Tiers       map[string]int
+	UserTiers   map[int64]string
+}
+
+// Quotas tracks daily check usage per user and persists it
+type Quotas struct {
+	mu     sync.Mutex
+	config QuotaConfig
+	usage  map[int64]QuotaUsage
+	store  *storage.JSONFile[map[int64]QuotaUsage]
+	now    func() time.Time
+}
+
+func NewQuotas(config QuotaConfig, store *storage.JSONFile[map[int64]QuotaUsage]) (*Quotas, error) {
+	usage := make(map[int64]QuotaUsage)
+	if err := store.Load(&usage); err != nil {
+		return nil, err
+	}
+
+	return &Quotas{
+		config: config,
+		usage:  usage,
+		store:  store,
+		now:    time.Now,
+	}, nil
+}
+
+// SetConfig replaces the tier configuration. Usage recorded so far is kept.
+func (q *Quotas) SetConfig(config QuotaConfig) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.config = config
+}
+
+func (q *Quotas) tier(userID int64) (string, int) {
+	tier, ok := q.config.UserTiers[userID]
+	if !ok {
+		tier = q.config.DefaultTier
+	}
+	return tier, q.config.Tiers[tier]
+}
+
+// status must be called with q.mu held
+func (q *Quotas) status(userID int64) QuotaStatus {
+	now := q.now().UTC()
+	day := now.Format(time.DateOnly)
+	tier, limit := q.tier(userID)
+
+	used := 0
+	if usage, ok := q.usage[userID]; ok && usage.Day == day {
+		used = usage.Used
+	}
+
+	return QuotaStatus{
+		Tier:    tier,
+		Limit:   limit,
+		Used:    used,
+		ResetAt: time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+// Status returns the user's quota for today
+func (q *Quotas) Status(userID int64) QuotaStatus {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.status(userID)
+}
+
+// Consume records n checks for the user. Nothing is recorded when the checks
+// would exceed the daily limit.
+func (q *Quotas) Consume(userID int64, n int) (QuotaStatus, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	status := q.status(userID)
+	if !status.Unlimited() && status.Used+n > status.Limit {
+		return status, &LimitError{Reason: ErrQuotaExceeded, RetryAfter: status.ResetAt.Sub(q.now())}
+	}
+
+	status.Used += n
+	q.usage[userID] = QuotaUsage{Day: q.now().UTC().Format(time.DateOnly), Used: status.Used}
+	return status, q.store.Save(q.usage)
+}
+
+// Reset clears the user's usage for today
+func (q *Quotas) Reset(userID int64) (QuotaStatus, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	delete(q.usage, userID)
+	return q.status(userID), q.store.Save(q.usage)
+}
+
+// Limits combines per-user and per-chat rate limiting with daily quotas
+type Limits struct {
+	users  *RateLimiter
+	chats  *RateLimiter
+	quotas *Quotas
+}
+
+func NewLimits(users, chats *RateLimiter, quotas *Quotas) *Limits {
+	return &Limits{
+		users:  users,
+		chats:  chats,
+		quotas: quotas,
+	}
+}
+
+func (l *Limits) Quotas() *Quotas {
+	return l.quotas
+}
+
+func (l *Limits) Users() *RateLimiter {
+	return l.users
+}
+
+func (l *Limits) Chats() *RateLimiter {
+	return l.chats
+}
+
+// Allow applies the rate limits of the user and the chat a request came from
+func (l *Limits) Allow(userID, chatID int64) error {
+	if ok, wait := l.users.Allow(userID); !ok {
+		return &LimitError{Reason: ErrRateLimited, RetryAfter: wait}
+	}
+	if ok, wait := l.chats.Allow(chatID); !ok {
+		return &LimitError{Reason: ErrRateLimited, RetryAfter: wait}
+	}
+	return nil
+}
+
+// Consume charges n checks against the user's daily quota. A *LimitError
+// means the checks were refused; any other error means they were counted but
+// could not be persisted.
+func (l *Limits) Consume(userID int64, n int) error {
+	_, err := l.quotas.Consume(userID, n)
+	return err
+}
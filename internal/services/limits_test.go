@@ -0,0 +1,90 @@
+package services
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/clevertechru/tgbot_aml/internal/storage"
+)
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter := NewRateLimiter(60, 2)
+	limiter.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := limiter.Allow(1); !ok {
+			t.Fatalf("request %d should fit in the burst", i)
+		}
+	}
+	ok, wait := limiter.Allow(1)
+	if ok || wait != time.Second {
+		t.Fatalf("expected refusal with 1s wait, got %v/%v", ok, wait)
+	}
+	if ok, _ := limiter.Allow(2); !ok {
+		t.Error("other keys must have their own bucket")
+	}
+
+	now = now.Add(time.Second)
+	if ok, _ := limiter.Allow(1); !ok {
+		t.Error("expected a token to be refilled after a second")
+	}
+
+	if ok, _ := NewRateLimiter(0, 0).Allow(1); !ok {
+		t.Error("zero rate must disable limiting")
+	}
+}
+
+func TestQuotas(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quotas.json")
+	config := QuotaConfig{
+		DefaultTier: "free",
+		Tiers:       map[string]int{"free": 3, "staff": 0},
+		UserTiers:   map[int64]string{2: "staff"},
+	}
+	now := time.Date(2024, 1, 1, 22, 0, 0, 0, time.UTC)
+
+	quotas, err := NewQuotas(config, storage.NewJSONFile[map[int64]QuotaUsage](path))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	quotas.now = func() time.Time { return now }
+
+	if _, err := quotas.Consume(1, 2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err = quotas.Consume(1, 2)
+	var limitErr *LimitError
+	if !errors.As(err, &limitErr) || !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("expected quota error, got %v", err)
+	}
+	if limitErr.RetryAfter != 2*time.Hour {
+		t.Errorf("expected retry at midnight UTC, got %v", limitErr.RetryAfter)
+	}
+	if _, err := quotas.Consume(2, 100); err != nil {
+		t.Errorf("staff tier must be unlimited, got %v", err)
+	}
+
+	// Usage survives a restart
+	reloaded, err := NewQuotas(config, storage.NewJSONFile[map[int64]QuotaUsage](path))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	reloaded.now = quotas.now
+	if status := reloaded.Status(1); status.Used != 2 || status.Remaining() != 1 {
+		t.Errorf("expected 2 used and 1 remaining, got %+v", status)
+	}
+
+	// A new day starts fresh
+	now = now.Add(3 * time.Hour)
+	if status := reloaded.Status(1); status.Used != 0 {
+		t.Errorf("expected usage to reset on a new day, got %+v", status)
+	}
+
+	now = now.Add(-3 * time.Hour)
+	if status, err := reloaded.Reset(1); err != nil || status.Used != 0 {
+		t.Errorf("expected reset to clear usage, got %+v, %v", status, err)
+	}
+}
@@ -0,0 +1,329 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/clevertechru/tgbot_aml/internal/domain"
+	"github.com/clevertechru/tgbot_aml/internal/rules"
+	"gopkg.in/yaml.v3"
+)
+
+// PolicyRule fires when all of its conditions hold for a screening result.
+// A rule needs at least one condition.
+type PolicyRule struct {
+	Name    string         `yaml:"name"`
+	Verdict domain.Verdict `yaml:"verdict"`
+	// Category matches when any provider reported the category
+	Category string `yaml:"category,omitempty"`
+	// MinScore matches risk scores at or above it
+	MinScore *float64 `yaml:"min_score,omitempty"`
+	// Exposure matches when more than Above of the funds come from Category
+	Exposure *ExposureCondition `yaml:"exposure,omitempty"`
+	// Flagged matches on whether any provider flagged the target
+	Flagged *bool `yaml:"flagged,omitempty"`
+	// When is an expression in the rules language, see package rules
+	When string `yaml:"when,omitempty"`
+
+	when *rules.Expr
+}
+
+// ExposureCondition is a minimum share of funds from a category
+type ExposureCondition struct {
+	Category string  `yaml:"category"`
+	Above    float64 `yaml:"above"`
+}
+
+// PolicyDocument is a risk policy as written in YAML
+type PolicyDocument struct {
+	// Default is the verdict when no rule fires
+	Default domain.Verdict `yaml:"default"`
+	Rules   []PolicyRule   `yaml:"rules"`
+}
+
+// PolicyDecision is the verdict of a policy and the rules that fired
+type PolicyDecision struct {
+	Verdict domain.Verdict
+	Fired   []string
+}
+
+// DefaultPolicy keeps the provider's own judgement: anything a provider
+// flags goes to review
+func DefaultPolicy() PolicyDocument {
+	flagged := true
+	return PolicyDocument{
+		Default: domain.VerdictAllow,
+		Rules: []PolicyRule{
+			{Name: "provider flagged", Verdict: domain.VerdictReview, Flagged: &flagged},
+		},
+	}
+}
+
+// ParsePolicy reads and validates a YAML policy. Unknown fields are errors,
+// so a typo cannot silently disable a rule.
+func ParsePolicy(data []byte) (PolicyDocument, error) {
+	doc := PolicyDocument{Default: domain.VerdictAllow}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&doc); err != nil && !errors.Is(err, io.EOF) {
+		return PolicyDocument{}, fmt.Errorf("failed to parse policy: %w", err)
+	}
+	if err := doc.Validate(); err != nil {
+		return PolicyDocument{}, err
+	}
+	for i, rule := range doc.Rules {
+		if rule.When != "" {
+			// Validate has already compiled every expression once
+			doc.Rules[i].when, _ = rules.Compile(rule.When)
+		}
+	}
+	return doc, nil
+}
+
+// Validate checks that every rule has a name, a known verdict and at least
+// one condition
+func (d PolicyDocument) Validate() error {
+	if _, err := domain.ParseVerdict(string(d.Default)); err != nil {
+		return fmt.Errorf("invalid default verdict: %w", err)
+	}
+
+	names := make(map[string]bool)
+	for i, rule := range d.Rules {
+		if rule.Name == "" {
+			return fmt.Errorf("rule %d has no name", i+1)
+		}
+		if names[rule.Name] {
+			return fmt.Errorf("duplicate rule name %q", rule.Name)
+		}
+		names[rule.Name] = true
+
+		if _, err := domain.ParseVerdict(string(rule.Verdict)); err != nil {
+			return fmt.Errorf("rule %q: %w", rule.Name, err)
+		}
+		if rule.Category == "" && rule.MinScore == nil && rule.Exposure == nil && rule.Flagged == nil && rule.When == "" {
+			return fmt.Errorf("rule %q has no conditions", rule.Name)
+		}
+		if rule.MinScore != nil && (*rule.MinScore < 0 || *rule.MinScore > 1) {
+			return fmt.Errorf("rule %q: min_score must be between 0 and 1", rule.Name)
+		}
+		if rule.When != "" {
+			if _, err := rules.Compile(rule.When); err != nil {
+				return fmt.Errorf("rule %q: when %w", rule.Name, err)
+			}
+		}
+		if rule.Exposure != nil {
+			if rule.Exposure.Category == "" {
+				return fmt.Errorf("rule %q: exposure needs a category", rule.Name)
+			}
+			if rule.Exposure.Above < 0 || rule.Exposure.Above >= 1 {
+				return fmt.Errorf("rule %q: exposure above must be between 0 and 1", rule.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// TransfersRule names the built-in rule that makes a transaction at least as
+// strict as its riskiest token transfer
+const TransfersRule = "token transfers"
+
+// Evaluate applies the policy to a screening result. The verdict is the
+// strictest one among the rules that fired, or the default if none did. A
+// transaction is never judged less strictly than any of its token
+// transfers, whose parties the policy has already judged.
+func (d PolicyDocument) Evaluate(result *domain.ScreeningResult) PolicyDecision {
+	decision := PolicyDecision{Verdict: d.Default}
+	env := rules.EnvFor(result, time.Now())
+	fired := false
+	for _, rule := range d.Rules {
+		if !rule.matches(result, env) {
+			continue
+		}
+		if !fired {
+			decision.Verdict = rule.Verdict
+			fired = true
+		}
+		decision.Verdict = decision.Verdict.Worse(rule.Verdict)
+		decision.Fired = append(decision.Fired, rule.Name)
+	}
+
+	transfers := domain.VerdictAllow
+	for _, transfer := range result.Transfers {
+		transfers = transfers.Worse(transfer.Verdict)
+	}
+	if worse := decision.Verdict.Worse(transfers); worse != decision.Verdict {
+		decision.Verdict = worse
+		decision.Fired = append(decision.Fired, TransfersRule)
+	}
+	return decision
+}
+
+func (r PolicyRule) matches(result *domain.ScreeningResult, env rules.Env) bool {
+	if r.Category != "" && !hasCategory(result, r.Category) {
+		return false
+	}
+	if r.MinScore != nil && result.RiskScore < *r.MinScore {
+		return false
+	}
+	if r.Exposure != nil && exposureTo(result, r.Exposure.Category) <= r.Exposure.Above {
+		return false
+	}
+	if r.Flagged != nil && flagged(result) != *r.Flagged {
+		return false
+	}
+	if r.when != nil && !r.when.Eval(env) {
+		return false
+	}
+	return true
+}
+
+// Conditions describes the rule's conditions for display
+func (r PolicyRule) Conditions() string {
+	var conditions []string
+	if r.Category != "" {
+		conditions = append(conditions, fmt.Sprintf("category == %q", r.Category))
+	}
+	if r.MinScore != nil {
+		conditions = append(conditions, fmt.Sprintf("score >= %g", *r.MinScore))
+	}
+	if r.Exposure != nil {
+		conditions = append(conditions, fmt.Sprintf("exposure to %q > %g%%", r.Exposure.Category, r.Exposure.Above*100))
+	}
+	if r.Flagged != nil {
+		conditions = append(conditions, fmt.Sprintf("flagged == %t", *r.Flagged))
+	}
+	if r.When != "" {
+		conditions = append(conditions, "("+r.When+")")
+	}
+	return strings.Join(conditions, " and ")
+}
+
+// normalizeCategory lets "Stolen Funds" in a policy match "stolen_funds"
+func normalizeCategory(category string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(category)), " ", "_")
+}
+
+func hasCategory(result *domain.ScreeningResult, category string) bool {
+	want := normalizeCategory(category)
+	categories := append([]string(nil), result.Categories...)
+	for _, response := range result.Responses {
+		categories = append(categories, response.Categories...)
+	}
+	for _, c := range categories {
+		if normalizeCategory(c) == want {
+			return true
+		}
+	}
+	return false
+}
+
+func exposureTo(result *domain.ScreeningResult, category string) float64 {
+	want := normalizeCategory(category)
+	var share float64
+	for c, s := range result.Exposure {
+		if normalizeCategory(c) == want && s > share {
+			share = s
+		}
+	}
+	return share
+}
+
+// flagged tells whether a provider called the target suspicious, before the
+// policy replaced that judgement
+func flagged(result *domain.ScreeningResult) bool {
+	if len(result.Responses) == 0 {
+		return result.IsSuspicious
+	}
+	for _, response := range result.Responses {
+		if response.IsSuspicious {
+			return true
+		}
+	}
+	return false
+}
+
+// Policy holds the active risk policy, loaded from a YAML file and
+// replaceable at runtime
+type Policy struct {
+	mu   sync.RWMutex
+	path string
+	doc  PolicyDocument
+}
+
+// NewPolicy loads the policy at path. An empty path uses DefaultPolicy.
+func NewPolicy(path string) (*Policy, error) {
+	p := &Policy{}
+	if err := p.Load(path); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Load replaces the policy with the one at path. On error the current
+// policy stays in force.
+func (p *Policy) Load(path string) error {
+	doc := DefaultPolicy()
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read policy: %w", err)
+		}
+		if doc, err = ParsePolicy(data); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.path = path
+	p.doc = doc
+	return nil
+}
+
+// Replace takes over the policy of other. Reloads load every file into new
+// values first, so that a failure leaves all of them as they were.
+func (p *Policy) Replace(other *Policy) {
+	path, doc := other.Path(), other.Document()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.path = path
+	p.doc = doc
+}
+
+// Path returns the file the policy was loaded from, or "" for the default
+func (p *Policy) Path() string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.path
+}
+
+// Document returns the active policy
+func (p *Policy) Document() PolicyDocument {
+	if p == nil {
+		return DefaultPolicy()
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.doc
+}
+
+// Apply evaluates the policy and stores the verdict in the result. Targets
+// that are not allowed are reported as suspicious.
+func (p *Policy) Apply(result *domain.ScreeningResult) {
+	decision := p.Document().Evaluate(result)
+	result.Verdict = decision.Verdict
+	result.FiredRules = decision.Fired
+	result.IsSuspicious = decision.Verdict != domain.VerdictAllow
+}
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/clevertechru/tgbot_aml/internal/domain"
+)
+
+const testPolicy = `
+default: allow
+rules:
+  - name: sanctions
+    category: sanctions
+    verdict: block
+  - name: mixer exposure
+    exposure: {category: mixer, above: 0.2}
+    verdict: review
+  - name: high score
+    min_score: 0.7
+    verdict: review
+`
+
+func TestPolicyEvaluate(t *testing.T) {
+	doc, err := ParsePolicy([]byte(testPolicy))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		result  domain.ScreeningResult
+		verdict domain.Verdict
+		fired   []string
+	}{
+		{
+			name:    "clean",
+			result:  domain.ScreeningResult{RiskScore: 0.1},
+			verdict: domain.VerdictAllow,
+		},
+		{
+			name:    "score at threshold",
+			result:  domain.ScreeningResult{RiskScore: 0.7},
+			verdict: domain.VerdictReview,
+			fired:   []string{"high score"},
+		},
+		{
+			name: "sanctions from one provider outweigh review",
+			result: domain.ScreeningResult{
+				RiskScore: 0.9,
+				Responses: []domain.ProviderResponse{{Categories: []string{"Sanctions"}}},
+			},
+			verdict: domain.VerdictBlock,
+			fired:   []string{"sanctions", "high score"},
+		},
+		{
+			name:    "mixer exposure above the limit",
+			result:  domain.ScreeningResult{Exposure: map[string]float64{"mixer": 0.35}},
+			verdict: domain.VerdictReview,
+			fired:   []string{"mixer exposure"},
+		},
+		{
+			name:    "mixer exposure at the limit",
+			result:  domain.ScreeningResult{Exposure: map[string]float64{"mixer": 0.2}},
+			verdict: domain.VerdictAllow,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision := doc.Evaluate(&tt.result)
+			if decision.Verdict != tt.verdict || !reflect.DeepEqual(decision.Fired, tt.fired) {
+				t.Errorf("Evaluate() = %s %v, want %s %v", decision.Verdict, decision.Fired, tt.verdict, tt.fired)
+			}
+		})
+	}
+}
+
+func TestPolicyWhenExpression(t *testing.T) {
+	doc, err := ParsePolicy([]byte(`
+rules:
+  - name: large gambling payment on TRON
+    category: gambling
+    when: chain == "TRON" and amount > 10000
+    verdict: review
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := doc.Rules[0].Conditions(); got != `category == "gambling" and (chain == "TRON" and amount > 10000)` {
+		t.Errorf("Conditions() = %s", got)
+	}
+
+	result := &domain.ScreeningResult{
+		Target:     domain.Target{Chain: domain.ChainTron},
+		Categories: []string{"gambling"},
+		Amount:     20000,
+	}
+	if decision := doc.Evaluate(result); decision.Verdict != domain.VerdictReview {
+		t.Errorf("expected review, got %+v", decision)
+	}
+	result.Amount = 0
+	if decision := doc.Evaluate(result); decision.Verdict != domain.VerdictAllow {
+		t.Errorf("expected an unknown amount not to match, got %+v", decision)
+	}
+
+	if _, err := ParsePolicy([]byte(`rules: [{name: a, when: 'amount > "x"', verdict: block}]`)); err == nil ||
+		!strings.Contains(err.Error(), "cannot compare") {
+		t.Errorf("expected a type error, got %v", err)
+	}
+}
+
+func TestPolicyApplyReplacesProviderVerdict(t *testing.T) {
+	var policy *Policy // nil uses DefaultPolicy
+
+	flagged := &domain.ScreeningResult{
+		IsSuspicious: true,
+		Responses:    []domain.ProviderResponse{{IsSuspicious: true}},
+	}
+	policy.Apply(flagged)
+	if flagged.Verdict != domain.VerdictReview || !flagged.IsSuspicious {
+		t.Errorf("expected flagged targets to go to review, got %+v", flagged)
+	}
+
+	path := filepath.Join(t.TempDir(), "policy.yml")
+	if err := os.WriteFile(path, []byte(testPolicy), 0600); err != nil {
+		t.Fatal(err)
+	}
+	policy, err := NewPolicy(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The provider's own flag plays no part in this policy
+	policy.Apply(flagged)
+	if flagged.Verdict != domain.VerdictAllow || flagged.IsSuspicious {
+		t.Errorf("expected the policy to allow, got %+v", flagged)
+	}
+}
+
+func TestPolicyLoadKeepsPolicyOnError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yml")
+	if err := os.WriteFile(path, []byte(testPolicy), 0600); err != nil {
+		t.Fatal(err)
+	}
+	policy, err := NewPolicy(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte("rules:\n  - name: typo\n    catgory: mixer\n    verdict: block\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := policy.Load(path); err == nil {
+		t.Fatal("expected an error for an unknown field")
+	}
+	if rules := policy.Document().Rules; len(rules) != 3 {
+		t.Errorf("expected the previous policy to stay, got %+v", rules)
+	}
+}
+
+func TestParsePolicyValidates(t *testing.T) {
+	tests := map[string]string{
+		"unknown verdict":   "rules:\n  - {name: a, category: mixer, verdict: deny}\n",
+		"no conditions":     "rules:\n  - {name: a, verdict: block}\n",
+		"no name":           "rules:\n  - {category: mixer, verdict: block}\n",
+		"duplicate name":    "rules:\n  - {name: a, category: mixer, verdict: block}\n  - {name: a, category: scam, verdict: block}\n",
+		"score range":       "rules:\n  - {name: a, min_score: 70, verdict: review}\n",
+		"exposure range":    "rules:\n  - {name: a, exposure: {category: mixer, above: 20}, verdict: review}\n",
+		"bad default":       "default: maybe\n",
+		"unknown field":     "rules:\n  - {name: a, category: mixer, verdict: block, score: 1}\n",
+		"exposure category": "rules:\n  - {name: a, exposure: {above: 0.2}, verdict: review}\n",
+	}
+	for name, policy := range tests {
+		if _, err := ParsePolicy([]byte(policy)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestBundledPolicy(t *testing.T) {
+	if _, err := NewPolicy(filepath.Join("..", "..", "config", "policy.yml")); err != nil {
+		t.Fatalf("the bundled policy does not load: %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"sync"
+	"time"
+)
+
+// ProviderHealth summarizes the calls made to a provider since startup
+type ProviderHealth struct {
+	Name          string
+	Calls         int
+	Errors        int
+	TotalLatency  time.Duration
+	LastLatency   time.Duration
+	LastError     string
+	LastErrorAt   time.Time
+	LastSuccessAt time.Time
+}
+
+// AvgLatency returns the mean latency of all calls
+func (h ProviderHealth) AvgLatency() time.Duration {
+	if h.Calls == 0 {
+		return 0
+	}
+	return h.TotalLatency / time.Duration(h.Calls)
+}
+
+// Healthy reports whether the most recent call succeeded. A provider that
+// has not been called yet counts as healthy.
+func (h ProviderHealth) Healthy() bool {
+	return h.LastErrorAt.IsZero() || h.LastSuccessAt.After(h.LastErrorAt)
+}
+
+type healthMonitor struct {
+	mu        sync.Mutex
+	providers map[string]*ProviderHealth
+}
+
+func newHealthMonitor() *healthMonitor {
+	return &healthMonitor{providers: make(map[string]*ProviderHealth)}
+}
+
+func (m *healthMonitor) observe(name string, latency time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	health, ok := m.providers[name]
+	if !ok {
+		health = &ProviderHealth{Name: name}
+		m.providers[name] = health
+	}
+
+	health.Calls++
+	health.TotalLatency += latency
+	health.LastLatency = latency
+	if err != nil {
+		health.Errors++
+		health.LastError = err.Error()
+		health.LastErrorAt = time.Now()
+	} else {
+		health.LastSuccessAt = time.Now()
+	}
+}
+
+// snapshot returns the health of the named providers in the given order
+func (m *healthMonitor) snapshot(names []string) []ProviderHealth {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := make([]ProviderHealth, 0, len(names))
+	for _, name := range names {
+		if health, ok := m.providers[name]; ok {
+			result = append(result, *health)
+		} else {
+			result = append(result, ProviderHealth{Name: name})
+		}
+	}
+	return result
+}
@@ -0,0 +1,50 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/multi/qrcode"
+)
+
+// DecodeQRCodes returns the text of every QR code found in an image.
+// An image without codes yields an empty slice and no error.
+func DecodeQRCodes(data []byte) ([]string, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	bitmap, err := gozxing.NewBinaryBitmapFromImage(img)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare image: %w", err)
+	}
+
+	results, err := qrcode.NewQRCodeMultiReader().DecodeMultiple(bitmap, map[gozxing.DecodeHintType]interface{}{
+		gozxing.DecodeHintType_TRY_HARDER: true,
+	})
+	var unreadable gozxing.ReaderException
+	if errors.As(err, &unreadable) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read QR codes: %w", err)
+	}
+
+	seen := make(map[string]struct{})
+	var texts []string
+	for _, result := range results {
+		text := result.GetText()
+		if _, dup := seen[text]; dup {
+			continue
+		}
+		seen[text] = struct{}{}
+		texts = append(texts, text)
+	}
+	return texts, nil
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"sort"
+	"testing"
+
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/qrcode"
+)
+
+func encodeQR(t *testing.T, text string) image.Image {
+	t.Helper()
+	matrix, err := qrcode.NewQRCodeWriter().Encode(text, gozxing.BarcodeFormat_QR_CODE, 200, 200, nil)
+	if err != nil {
+		t.Fatalf("failed to encode QR code: %v", err)
+	}
+	return matrix
+}
+
+func TestDecodeQRCodes(t *testing.T) {
+	texts := []string{
+		"bitcoin:bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq?amount=0.1",
+		"ethereum:0x742d35Cc6634C0532925a3b844Bc454e4438f44e?value=1e18",
+	}
+
+	canvas := image.NewGray(image.Rect(0, 0, 480, 240))
+	draw.Draw(canvas, canvas.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(canvas, image.Rect(10, 20, 210, 220), encodeQR(t, texts[0]), image.Point{}, draw.Src)
+	draw.Draw(canvas, image.Rect(270, 20, 470, 220), encodeQR(t, texts[1]), image.Point{}, draw.Src)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, canvas); err != nil {
+		t.Fatalf("failed to encode image: %v", err)
+	}
+
+	codes, err := DecodeQRCodes(buf.Bytes())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sort.Strings(codes)
+	if len(codes) != 2 || codes[0] != texts[0] || codes[1] != texts[1] {
+		t.Errorf("expected %v, got %v", texts, codes)
+	}
+}
+
+func TestDecodeQRCodesEmptyImage(t *testing.T) {
+	canvas := image.NewGray(image.Rect(0, 0, 100, 100))
+	draw.Draw(canvas, canvas.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, canvas); err != nil {
+		t.Fatalf("failed to encode image: %v", err)
+	}
+
+	codes, err := DecodeQRCodes(buf.Bytes())
+	if err != nil || len(codes) != 0 {
+		t.Errorf("expected no codes and no error, got %v, %v", codes, err)
+	}
+}
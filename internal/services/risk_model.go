@@ -0,0 +1,258 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/clevertechru/tgbot_aml/internal/domain"
+)
+
+const (
+	// Addresses younger than newAddressAge count fully towards the age
+	// factor, which fades out until matureAddressAge
+	newAddressAge    = 7 * 24 * time.Hour
+	matureAddressAge = 180 * 24 * time.Hour
+)
+
+// RiskWeights scale each factor's signal into its share of the risk score
+type RiskWeights struct {
+	Direct    float64
+	Exposure  float64
+	Sanctions float64
+	Blocklist float64
+	Age       float64
+	Transfers float64
+}
+
+// DefaultRiskWeights keep the provider's score as it is when nothing else
+// is known, and push sanctions and blocklist hits to the maximum
+func DefaultRiskWeights() RiskWeights {
+	return RiskWeights{
+		Direct:    1,
+		Exposure:  0.5,
+		Sanctions: 1,
+		Blocklist: 1,
+		Age:       0.2,
+		Transfers: 1,
+	}
+}
+
+// RiskModel computes risk scores as the sum of weighted factors, capped at
+// 1, so every score can be explained factor by factor
+type RiskModel struct {
+	mu        sync.RWMutex
+	weights   RiskWeights
+	blocklist *Blocklist
+	now       func() time.Time
+}
+
+func NewRiskModel(weights RiskWeights, blocklist *Blocklist) *RiskModel {
+	return &RiskModel{
+		weights:   weights,
+		blocklist: blocklist,
+		now:       time.Now,
+	}
+}
+
+// SetWeights changes the factor weights
+func (m *RiskModel) SetWeights(weights RiskWeights) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.weights = weights
+}
+
+// Weights returns the factor weights
+func (m *RiskModel) Weights() RiskWeights {
+	if m == nil {
+		return DefaultRiskWeights()
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.weights
+}
+
+// Score fills in the factors of a result and sets its risk score to their
+// sum
+func (m *RiskModel) Score(result *domain.ScreeningResult) {
+	weights := m.Weights()
+	now := time.Now()
+	var blocklist *Blocklist
+	if m != nil {
+		now = m.now()
+		blocklist = m.blocklist
+	}
+
+	factors := []domain.RiskFactor{
+		directFactor(result),
+		exposureFactor(result),
+		sanctionsFactor(result),
+		blocklistFactor(result, blocklist),
+		ageFactor(result, now),
+	}
+	if result.Target.Kind == domain.KindTransaction {
+		factors = append(factors, transfersFactor(result))
+	}
+	weight := map[domain.RiskFactorKind]float64{
+		domain.FactorDirect:    weights.Direct,
+		domain.FactorExposure:  weights.Exposure,
+		domain.FactorSanctions: weights.Sanctions,
+		domain.FactorBlocklist: weights.Blocklist,
+		domain.FactorAge:       weights.Age,
+		domain.FactorTransfers: weights.Transfers,
+	}
+
+	var score float64
+	for i := range factors {
+		factors[i].Weight = weight[factors[i].Kind]
+		factors[i].Contribution = factors[i].Weight * factors[i].Signal
+		score += factors[i].Contribution
+	}
+	sort.SliceStable(factors, func(i, j int) bool {
+		return factors[i].Contribution > factors[j].Contribution
+	})
+
+	if score > 1 {
+		score = 1
+	}
+	result.RiskScore = score
+	result.Factors = factors
+}
+
+// directFactor is the highest score a provider gave the target itself
+func directFactor(result *domain.ScreeningResult) domain.RiskFactor {
+	factor := domain.RiskFactor{Kind: domain.FactorDirect}
+	if len(result.Responses) == 0 {
+		factor.Signal = result.RiskScore
+		return factor
+	}
+	for _, response := range result.Responses {
+		if response.RiskScore > factor.Signal {
+			factor.Signal = response.RiskScore
+		}
+		if response.RiskScore > 0 || len(response.Categories) > 0 {
+			factor.Sources = append(factor.Sources, providerSource(response.Provider, response.Categories))
+		}
+	}
+	return factor
+}
+
+// exposureFactor weighs where the target's funds came from or, when that is
+// worse, where they went according to the transaction graph
+func exposureFactor(result *domain.ScreeningResult) domain.RiskFactor {
+	factor := domain.RiskFactor{
+		Kind:    domain.FactorExposure,
+		Signal:  domain.ExposureSignal(result.Exposure),
+		Sources: exposureSources(result.Exposure, ""),
+	}
+	if result.Graph != nil {
+		outgoing := domain.Shares(result.Graph.Outgoing)
+		if signal := domain.ExposureSignal(outgoing); signal > factor.Signal {
+			factor.Signal = signal
+		}
+		factor.Sources = append(factor.Sources, exposureSources(outgoing, "→ ")...)
+	}
+	return factor
+}
+
+// exposureSources lists the risky categories of an exposure, largest share
+// first
+func exposureSources(exposure map[string]float64, prefix string) []string {
+	categories := make([]string, 0, len(exposure))
+	for category, share := range exposure {
+		if share > 0 && domain.CategorySeverity(category) > domain.SeverityLow {
+			categories = append(categories, category)
+		}
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		if exposure[categories[i]] != exposure[categories[j]] {
+			return exposure[categories[i]] > exposure[categories[j]]
+		}
+		return categories[i] < categories[j]
+	})
+	sources := make([]string, 0, len(categories))
+	for _, category := range categories {
+		sources = append(sources, fmt.Sprintf("%s%.0f%% %s", prefix, exposure[category]*100, category))
+	}
+	return sources
+}
+
+func sanctionsFactor(result *domain.ScreeningResult) domain.RiskFactor {
+	factor := domain.RiskFactor{Kind: domain.FactorSanctions}
+	for _, response := range result.Responses {
+		var hits []string
+		for _, category := range response.Categories {
+			if strings.Contains(strings.ToLower(category), "sanction") {
+				hits = append(hits, category)
+			}
+		}
+		if len(hits) > 0 {
+			factor.Signal = 1
+			factor.Sources = append(factor.Sources, providerSource(response.Provider, hits))
+		}
+	}
+	return factor
+}
+
+func blocklistFactor(result *domain.ScreeningResult, blocklist *Blocklist) domain.RiskFactor {
+	factor := domain.RiskFactor{Kind: domain.FactorBlocklist}
+	if reason, ok := blocklist.Lookup(result.Target.Value); ok {
+		factor.Signal = 1
+		factor.Sources = []string{providerSource("blocklist", []string{reason})}
+	}
+	return factor
+}
+
+// ageFactor is 1 for addresses first seen within newAddressAge and fades to
+// 0 at matureAddressAge. Its source is the day the address was first seen.
+func ageFactor(result *domain.ScreeningResult, now time.Time) domain.RiskFactor {
+	factor := domain.RiskFactor{Kind: domain.FactorAge}
+	if result.FirstSeen.IsZero() {
+		factor.Unknown = true
+		return factor
+	}
+
+	age := now.Sub(result.FirstSeen)
+	switch {
+	case age <= newAddressAge:
+		factor.Signal = 1
+	case age < matureAddressAge:
+		factor.Signal = float64(matureAddressAge-age) / float64(matureAddressAge-newAddressAge)
+	}
+	factor.Sources = []string{result.FirstSeen.UTC().Format(time.DateOnly)}
+	return factor
+}
+
+// transfersFactor is the risk of the riskiest token transfer in a
+// transaction, whose sources are the transfers that carry any risk
+func transfersFactor(result *domain.ScreeningResult) domain.RiskFactor {
+	factor := domain.RiskFactor{Kind: domain.FactorTransfers}
+	for _, transfer := range result.Transfers {
+		if transfer.RiskScore > factor.Signal {
+			factor.Signal = transfer.RiskScore
+		}
+		if transfer.RiskScore > 0 {
+			factor.Sources = append(factor.Sources, fmt.Sprintf("%s %s → %s",
+				transfer.Transfer.Symbol, transfer.Sender.Target.ShortValue(), transfer.Receiver.Target.ShortValue()))
+		}
+	}
+	return factor
+}
+
+func providerSource(provider string, categories []string) string {
+	var nonEmpty []string
+	for _, category := range categories {
+		if category != "" {
+			nonEmpty = append(nonEmpty, category)
+		}
+	}
+	if len(nonEmpty) == 0 {
+		return provider
+	}
+	return provider + ": " + strings.Join(nonEmpty, ", ")
+}
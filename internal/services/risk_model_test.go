@@ -0,0 +1,120 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/clevertechru/tgbot_aml/internal/domain"
+)
+
+func TestRiskModelKeepsProviderScore(t *testing.T) {
+	var model *RiskModel // nil uses DefaultRiskWeights
+
+	result := &domain.ScreeningResult{
+		RiskScore: 0.42,
+		Responses: []domain.ProviderResponse{{Provider: "mock", RiskScore: 0.42, Categories: []string{"gambling"}}},
+	}
+	model.Score(result)
+
+	if result.RiskScore != 0.42 {
+		t.Errorf("RiskScore = %v, want the provider's 0.42", result.RiskScore)
+	}
+	top := result.Factors[0]
+	if top.Kind != domain.FactorDirect || top.Contribution != 0.42 || len(top.Sources) != 1 || top.Sources[0] != "mock: gambling" {
+		t.Errorf("unexpected top factor %+v", top)
+	}
+	for _, factor := range result.Factors {
+		if factor.Kind == domain.FactorAge && !factor.Unknown {
+			t.Errorf("expected the age to be unknown, got %+v", factor)
+		}
+	}
+}
+
+func TestRiskModelFactors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+	if err := os.WriteFile(path, []byte("# test list\n0xBAD0 # drainer contract\n\nTXYZ\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	blocklist, err := NewBlocklist(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if blocklist.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", blocklist.Len())
+	}
+	if n := (*Blocklist)(nil).Len(); n != 0 {
+		t.Errorf("nil Len() = %d, want 0", n)
+	}
+
+	model := NewRiskModel(RiskWeights{Direct: 0.5, Exposure: 0.4, Sanctions: 0.3, Blocklist: 0.2, Age: 0.1}, blocklist)
+	now := time.Date(2024, 5, 31, 0, 0, 0, 0, time.UTC)
+	model.now = func() time.Time { return now }
+
+	result := &domain.ScreeningResult{
+		Target:    domain.Target{Value: "0xbad0"},
+		Exposure:  map[string]float64{"mixer": 0.4, "exchange": 0.6},
+		FirstSeen: now.AddDate(0, 0, -2),
+		Responses: []domain.ProviderResponse{
+			{Provider: "a", RiskScore: 0.2, Categories: []string{"OFAC sanctions"}},
+			{Provider: "b", RiskScore: 0.6},
+		},
+	}
+	model.Score(result)
+
+	want := []struct {
+		kind         domain.RiskFactorKind
+		contribution float64
+		source       string
+	}{
+		{domain.FactorDirect, 0.3, "a: OFAC sanctions"},
+		{domain.FactorSanctions, 0.3, "a: OFAC sanctions"},
+		{domain.FactorBlocklist, 0.2, "blocklist: drainer contract"},
+		{domain.FactorAge, 0.1, "2024-05-29"},
+		{domain.FactorExposure, 0.08, "40% mixer"},
+	}
+	if len(result.Factors) != len(want) {
+		t.Fatalf("got %d factors, want %d", len(result.Factors), len(want))
+	}
+	var sum float64
+	for i, w := range want {
+		factor := result.Factors[i]
+		if factor.Kind != w.kind || !almostEqual(factor.Contribution, w.contribution) || factor.Sources[0] != w.source {
+			t.Errorf("factor %d = %+v, want %s %v %q", i, factor, w.kind, w.contribution, w.source)
+		}
+		sum += factor.Contribution
+	}
+	if !almostEqual(result.RiskScore, sum) {
+		t.Errorf("RiskScore = %v, want the sum of contributions %v", result.RiskScore, sum)
+	}
+
+	model.SetWeights(DefaultRiskWeights())
+	model.Score(result)
+	if result.RiskScore != 1 {
+		t.Errorf("RiskScore = %v, want it capped at 1", result.RiskScore)
+	}
+}
+
+func TestAgeFactorFades(t *testing.T) {
+	now := time.Date(2024, 5, 31, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		days   int
+		signal float64
+	}{{1, 1}, {7, 1}, {180, 0}, {365, 0}}
+	for _, tt := range tests {
+		factor := ageFactor(&domain.ScreeningResult{FirstSeen: now.AddDate(0, 0, -tt.days)}, now)
+		if factor.Signal != tt.signal {
+			t.Errorf("%d days: signal = %v, want %v", tt.days, factor.Signal, tt.signal)
+		}
+	}
+	middle := ageFactor(&domain.ScreeningResult{FirstSeen: now.AddDate(0, 0, -90)}, now)
+	if middle.Signal <= 0 || middle.Signal >= 1 {
+		t.Errorf("90 days: signal = %v, want between 0 and 1", middle.Signal)
+	}
+}
+
+func almostEqual(a, b float64) bool {
+	d := a - b
+	return d < 1e-9 && d > -1e-9
+}
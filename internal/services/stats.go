@@ -0,0 +1,109 @@
+package services
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/clevertechru/tgbot_aml/internal/storage"
+)
+
+// DailyStats counts the checks made on a UTC day
+type DailyStats struct {
+	Day        string        `json:"day"`
+	Checks     int           `json:"checks"`
+	Suspicious int           `json:"suspicious"`
+	Users      map[int64]int `json:"users"`
+}
+
+// UserCount is a user and the number of checks they made
+type UserCount struct {
+	UserID int64
+	Checks int
+}
+
+// SuspiciousRate returns the share of checks that came back suspicious
+func (d DailyStats) SuspiciousRate() float64 {
+	if d.Checks == 0 {
+		return 0
+	}
+	return float64(d.Suspicious) / float64(d.Checks)
+}
+
+// TopUsers returns up to n users with the most checks
+func (d DailyStats) TopUsers(n int) []UserCount {
+	top := make([]UserCount, 0, len(d.Users))
+	for id, checks := range d.Users {
+		top = append(top, UserCount{UserID: id, Checks: checks})
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Checks != top[j].Checks {
+			return top[i].Checks > top[j].Checks
+		}
+		return top[i].UserID < top[j].UserID
+	})
+	if len(top) > n {
+		top = top[:n]
+	}
+	return top
+}
+
+// Stats counts today's checks and persists the counters
+type Stats struct {
+	mu    sync.Mutex
+	today DailyStats
+	store *storage.JSONFile[DailyStats]
+	now   func() time.Time
+}
+
+func NewStats(store *storage.JSONFile[DailyStats]) (*Stats, error) {
+	var today DailyStats
+	if err := store.Load(&today); err != nil {
+		return nil, err
+	}
+
+	return &Stats{
+		today: today,
+		store: store,
+		now:   time.Now,
+	}, nil
+}
+
+// rollover must be called with s.mu held
+func (s *Stats) rollover() {
+	day := s.now().UTC().Format(time.DateOnly)
+	if s.today.Day != day {
+		s.today = DailyStats{Day: day}
+	}
+	if s.today.Users == nil {
+		s.today.Users = make(map[int64]int)
+	}
+}
+
+// Record counts one check made by a user
+func (s *Stats) Record(userID int64, suspicious bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rollover()
+	s.today.Checks++
+	if suspicious {
+		s.today.Suspicious++
+	}
+	s.today.Users[userID]++
+	return s.store.Save(s.today)
+}
+
+// Today returns a copy of today's counters
+func (s *Stats) Today() DailyStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rollover()
+	today := s.today
+	today.Users = make(map[int64]int, len(s.today.Users))
+	for id, checks := range s.today.Users {
+		today.Users[id] = checks
+	}
+	return today
+}
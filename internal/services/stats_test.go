@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/clevertechru/tgbot_aml/internal/domain"
+	"github.com/clevertechru/tgbot_aml/internal/storage"
+)
+
+func TestStatsRollsOverDaily(t *testing.T) {
+	store := storage.NewJSONFile[DailyStats](filepath.Join(t.TempDir(), "stats.json"))
+	stats, err := NewStats(store)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	now := time.Date(2024, 5, 1, 23, 0, 0, 0, time.UTC)
+	stats.now = func() time.Time { return now }
+
+	for _, record := range []struct {
+		userID     int64
+		suspicious bool
+	}{{1, true}, {2, false}, {2, false}, {3, true}} {
+		if err := stats.Record(record.userID, record.suspicious); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	today := stats.Today()
+	if today.Checks != 4 || today.Suspicious != 2 || today.SuspiciousRate() != 0.5 {
+		t.Errorf("unexpected counters: %+v", today)
+	}
+	top := today.TopUsers(2)
+	if len(top) != 2 || top[0].UserID != 2 || top[1].UserID != 1 {
+		t.Errorf("unexpected top users: %+v", top)
+	}
+
+	reloaded, err := NewStats(store)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	reloaded.now = stats.now
+	if got := reloaded.Today().Checks; got != 4 {
+		t.Errorf("expected persisted counters, got %d checks", got)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if got := stats.Today(); got.Checks != 0 || got.Day != "2024-05-02" {
+		t.Errorf("expected a fresh day, got %+v", got)
+	}
+}
+
+func TestAMLServiceCachesResults(t *testing.T) {
+	service := NewAMLService(stubProvider{})
+	service.SetCache(NewResultCache(time.Minute))
+
+	for i := 0; i < 3; i++ {
+		if _, err := service.CheckAddress(context.Background(), "0xbad1"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	health := service.ProviderHealth()
+	if len(health) != 1 || health[0].Calls != 1 {
+		t.Errorf("expected one provider call, got %+v", health)
+	}
+	if n := service.Cache().Flush(); n != 1 {
+		t.Errorf("expected one cached entry, got %d", n)
+	}
+}
+
+func TestResultCacheBounds(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	cache := NewResultCache(time.Minute)
+	cache.now = func() time.Time { return now }
+	cache.maxEntries = 2
+
+	cache.Put("a", &domain.CheckResult{})
+	now = now.Add(30 * time.Second)
+	cache.Put("b", &domain.CheckResult{})
+	cache.Put("c", &domain.CheckResult{})
+	if _, ok := cache.Get("a"); ok {
+		t.Error("expected the oldest entry to make room")
+	}
+	if n := cache.Len(); n != 2 {
+		t.Errorf("expected 2 entries, got %d", n)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if n := cache.Len(); n != 0 {
+		t.Errorf("expected expired entries not to count, got %d", n)
+	}
+	cache.Put("d", &domain.CheckResult{})
+	if n := cache.Flush(); n != 1 {
+		t.Errorf("expected expired entries to be swept, got %d", n)
+	}
+}
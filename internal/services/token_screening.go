@@ -0,0 +1,183 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/clevertechru/tgbot_aml/internal/domain"
+)
+
+// maxScreenedParties bounds how many distinct senders, receivers and token
+// contracts of one transaction are screened, since the whole transaction
+// is charged as one check but each party costs a provider check and chain
+// lookups
+const maxScreenedParties = 6
+
+// TokenFreezes tells whether a token's issuer froze an address, as Tether
+// and Circle do for addresses tied to crime
+type TokenFreezes interface {
+	Name() string
+	Supports(chain domain.Chain) bool
+	Frozen(ctx context.Context, chain domain.Chain, token, address string) (bool, error)
+}
+
+// SetTokenFreezes sets where issuer freezes are looked up. Without it, no
+// address is considered frozen.
+func (s *AMLService) SetTokenFreezes(freezes TokenFreezes) {
+	s.freezes = freezes
+}
+
+// checkIssuerFreeze adds a response from the freeze lookup when an issuer
+// froze the target. Payments are checked against the token being paid,
+// other screens against every freezable token of the chain. Known token
+// contracts are not looked up. Lookup failures only show up in provider
+// health.
+func (s *AMLService) checkIssuerFreeze(ctx context.Context, result *domain.ScreeningResult, token string) {
+	chain := result.Target.Chain
+	if s.freezes == nil || !s.freezes.Supports(chain) {
+		return
+	}
+	if _, ok := domain.KnownToken(chain, result.Target.Value); ok {
+		return
+	}
+	tokens := []string{token}
+	if token == "" {
+		tokens = domain.FreezableTokens(chain)
+	}
+
+	var details []string
+	for _, token := range tokens {
+		start := time.Now()
+		frozen, err := s.freezes.Frozen(ctx, chain, token, result.Target.Value)
+		s.health.observe(s.freezes.Name(), time.Since(start), err)
+		if err != nil || !frozen {
+			continue
+		}
+
+		name := token
+		if known, ok := domain.KnownToken(chain, token); ok {
+			name = known.Symbol
+		}
+		details = append(details, fmt.Sprintf("Frozen by the %s issuer", name))
+	}
+	if len(details) == 0 {
+		return
+	}
+
+	response := domain.ProviderResponse{
+		Provider:     s.freezes.Name(),
+		IsSuspicious: true,
+		RiskScore:    1,
+		Details:      details,
+		Categories:   []string{domain.CategoryIssuerFrozen},
+	}
+	result.Responses = append(result.Responses, response)
+	result.IsSuspicious = true
+	// The categories may be those of a cached provider result, so append to
+	// a copy rather than into its backing array
+	result.Details = append(slices.Clip(result.Details), response.Details...)
+	result.Categories = append(slices.Clip(result.Categories), response.Categories...)
+}
+
+// findTransaction looks a transaction up in the chain data source of its
+// chain. Bare hex hashes may be Bitcoin or TRON; only TRON has tokens, but
+// there is no TRON source apart from fixtures, so without one the result
+// says why its token transfers were not screened.
+func (s *AMLService) findTransaction(ctx context.Context, result *domain.ScreeningResult) (domain.Chain, *domain.ChainTransaction) {
+	target := result.Target
+	chain := target.Chain
+	if chain == domain.ChainUnknown {
+		chain = domain.ChainTron
+	}
+	source, ok := s.chainData.For(chain)
+	if !ok {
+		if chain == domain.ChainTron {
+			result.Details = append(result.Details,
+				"Token transfers were not screened: TRON transactions cannot be read yet")
+		}
+		return "", nil
+	}
+
+	start := time.Now()
+	tx, err := source.Transaction(ctx, target.Value)
+	if errors.Is(err, domain.ErrNotFound) {
+		err = nil
+	}
+	s.health.observe(chainDataHealthName(source), time.Since(start), err)
+	if err != nil || tx == nil {
+		return "", nil
+	}
+	return chain, tx
+}
+
+// screenTransfers screens the sender, receiver and token contract of every
+// token transfer in a transaction. The risk model and policy then weigh the
+// transfers into the transaction's own score and verdict.
+func (s *AMLService) screenTransfers(ctx context.Context, result *domain.ScreeningResult) error {
+	chain, tx := s.findTransaction(ctx, result)
+	if tx == nil {
+		return nil
+	}
+	transfers := domain.DecodeTokenTransfers(chain, tx)
+
+	// The same address often appears in several transfers
+	screened := make(map[string]*domain.ScreeningResult)
+	key := func(address, token string) string {
+		return strings.ToLower(address) + "|" + strings.ToLower(token)
+	}
+	party := func(address, token string) (*domain.ScreeningResult, error) {
+		if party, ok := screened[key(address, token)]; ok {
+			return party, nil
+		}
+		party, err := s.screenParty(ctx, domain.Target{Value: address, Kind: domain.KindAddress, Chain: chain}, token)
+		if err != nil {
+			return nil, fmt.Errorf("failed to screen %s: %w", address, err)
+		}
+		screened[key(address, token)] = party
+		return party, nil
+	}
+
+	for i, transfer := range transfers {
+		parties := map[string]bool{
+			key(transfer.From, transfer.Token): true,
+			key(transfer.To, transfer.Token):   true,
+			key(transfer.Token, ""):            true,
+		}
+		for k := range parties {
+			if screened[k] != nil {
+				delete(parties, k)
+			}
+		}
+		if len(screened)+len(parties) > maxScreenedParties {
+			result.Details = append(result.Details, fmt.Sprintf(
+				"Only the first %d of %d token transfers were screened", i, len(transfers)))
+			break
+		}
+
+		row := domain.TransferRisk{Transfer: transfer, Verdict: domain.VerdictAllow}
+		var err error
+		if row.Sender, err = party(transfer.From, transfer.Token); err != nil {
+			return err
+		}
+		if row.Receiver, err = party(transfer.To, transfer.Token); err != nil {
+			return err
+		}
+		if row.Token, err = party(transfer.Token, ""); err != nil {
+			return err
+		}
+		for _, party := range []*domain.ScreeningResult{row.Sender, row.Receiver, row.Token} {
+			row.IsSuspicious = row.IsSuspicious || party.IsSuspicious
+			row.Verdict = row.Verdict.Worse(party.Verdict)
+			if party.RiskScore > row.RiskScore {
+				row.RiskScore = party.RiskScore
+			}
+		}
+
+		result.Transfers = append(result.Transfers, row)
+	}
+	return nil
+}
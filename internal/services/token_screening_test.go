@@ -0,0 +1,255 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clevertechru/tgbot_aml/internal/domain"
+	"github.com/clevertechru/tgbot_aml/internal/graph"
+)
+
+const (
+	testUSDT     = "0xdac17f958d2ee523a2206206994597c13d831ec7"
+	testSender   = "0xbad0000000000000000000000000000000000001"
+	testReceiver = "0x00000000000000000000000000000000000000c1"
+)
+
+type stubFreezes map[string]bool
+
+func (stubFreezes) Name() string {
+	return "freezes"
+}
+
+func (stubFreezes) Supports(chain domain.Chain) bool {
+	return chain == domain.ChainEthereum
+}
+
+func (f stubFreezes) Frozen(ctx context.Context, chain domain.Chain, token, address string) (bool, error) {
+	return f[strings.ToLower(token)+"|"+strings.ToLower(address)], nil
+}
+
+func transferLog(index int, token, from, to string, amount string) domain.EventLog {
+	pad := func(address string) string {
+		return "0x000000000000000000000000" + strings.TrimPrefix(address, "0x")
+	}
+	return domain.EventLog{
+		Index:   index,
+		Address: token,
+		Topics:  []string{"0x" + domain.TransferEventTopic, pad(from), pad(to)},
+		Data:    "0x" + strings.Repeat("0", 64-len(amount)) + amount,
+	}
+}
+
+func TestAMLServiceScreenTransfers(t *testing.T) {
+	fixture := domain.NewChainFixture(domain.ChainEthereum, []domain.ChainTransaction{{
+		Hash:    "0x" + strings.Repeat("ab", 32),
+		Inputs:  []domain.TxLeg{{Address: testSender}},
+		Outputs: []domain.TxLeg{{Address: testUSDT}},
+		Logs: []domain.EventLog{
+			transferLog(0, testUSDT, testSender, testReceiver, "4a817c80"),
+			transferLog(1, testUSDT, testReceiver, testSender, "0f4240"),
+		},
+	}}, nil)
+
+	service := NewAMLService(stubProvider{})
+	service.SetChainData(domain.ChainDataSources{domain.ChainEthereum: fixture})
+	service.SetTokenFreezes(stubFreezes{testUSDT + "|" + testReceiver: true})
+
+	target := domain.Target{Value: "0x" + strings.Repeat("AB", 32), Kind: domain.KindTransaction, Chain: domain.ChainEthereum}
+	result, err := service.Screen(context.Background(), target)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Transfers) != 2 {
+		t.Fatalf("got %d transfer rows, want 2", len(result.Transfers))
+	}
+
+	row := result.Transfers[0]
+	if row.Transfer.Value != 1250 || row.Transfer.Symbol != "USDT" {
+		t.Errorf("unexpected transfer %+v", row.Transfer)
+	}
+	if !row.Sender.IsSuspicious || row.Sender.Target.Value != testSender {
+		t.Errorf("expected the sender to be screened as suspicious, got %+v", row.Sender)
+	}
+	if !containsString(row.Receiver.Categories, domain.CategoryIssuerFrozen) {
+		t.Errorf("expected the receiver to be frozen, got %v", row.Receiver.Categories)
+	}
+	if row.Token.Target.Value != testUSDT || containsString(row.Token.Categories, domain.CategoryIssuerFrozen) {
+		t.Errorf("unexpected token result %+v", row.Token)
+	}
+	if !row.IsSuspicious || row.RiskScore != 1 || row.Verdict != domain.VerdictReview {
+		t.Errorf("row = %v/%v/%v, want the frozen receiver's risk", row.IsSuspicious, row.RiskScore, row.Verdict)
+	}
+	// Both rows share the screened parties
+	if result.Transfers[1].Sender != row.Receiver {
+		t.Error("expected parties to be screened once per transaction")
+	}
+
+	if !result.IsSuspicious || result.RiskScore != 1 || result.Verdict != domain.VerdictReview {
+		t.Errorf("transaction = %v/%v/%v, want its riskiest transfer", result.IsSuspicious, result.RiskScore, result.Verdict)
+	}
+	// The transfer risk is part of the score and the policy decision, so
+	// /why explains it
+	var sum float64
+	var transfers *domain.RiskFactor
+	for i, factor := range result.Factors {
+		sum += factor.Contribution
+		if factor.Kind == domain.FactorTransfers {
+			transfers = &result.Factors[i]
+		}
+	}
+	if transfers == nil || transfers.Signal != 1 || len(transfers.Sources) != 2 {
+		t.Errorf("expected a transfers factor for both risky transfers, got %+v", result.Factors)
+	}
+	if math.Min(sum, 1) != result.RiskScore {
+		t.Errorf("factors add up to %v, want the risk score %v", sum, result.RiskScore)
+	}
+	if !containsString(result.FiredRules, TransfersRule) {
+		t.Errorf("expected the transfers rule to fire, got %v", result.FiredRules)
+	}
+}
+
+func TestAMLServiceScreenTransfersLimits(t *testing.T) {
+	address := func(n int) string {
+		return fmt.Sprintf("0x%040x", n)
+	}
+	var logs []domain.EventLog
+	for i := 0; i < 4; i++ {
+		logs = append(logs, transferLog(i, testUSDT, address(2*i+1), address(2*i+2), "0f4240"))
+	}
+	hash := "0x" + strings.Repeat("ef", 32)
+	fixture := domain.NewChainFixture(domain.ChainEthereum, []domain.ChainTransaction{{Hash: hash, Logs: logs}}, nil)
+
+	service := NewAMLService(stubProvider{})
+	service.SetChainData(domain.ChainDataSources{domain.ChainEthereum: fixture})
+	service.SetGraph(graph.NewAnalyzer(graph.Fixture{}, nil, graph.Options{Hops: 1, MaxAddresses: 10}))
+
+	result, err := service.Screen(context.Background(), domain.Target{Value: hash, Kind: domain.KindTransaction, Chain: domain.ChainEthereum})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The token contract and two parties per transfer: 3, 5, then 7 > 6
+	if len(result.Transfers) != 2 || !containsString(result.Details, "Only the first 2 of 4 token transfers were screened") {
+		t.Errorf("expected 2 of 4 transfers to be screened, got %d rows and %v", len(result.Transfers), result.Details)
+	}
+	for _, health := range service.ProviderHealth() {
+		if health.Name == "graph" && health.Calls != 0 {
+			t.Errorf("expected no graph walks for transfer parties, got %+v", health)
+		}
+	}
+}
+
+func TestAMLServiceScreenTronTransaction(t *testing.T) {
+	service := NewAMLService(stubProvider{})
+	service.SetChainData(domain.ChainDataSources{domain.ChainEthereum: domain.NewChainFixture(domain.ChainEthereum, nil, nil)})
+
+	// A bare hash may be TRON, which has no chain data source
+	target, _ := domain.ParseTarget(strings.Repeat("cd", 32))
+	result, err := service.Screen(context.Background(), target)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Transfers) != 0 || !containsString(result.Details, "Token transfers were not screened: TRON transactions cannot be read yet") {
+		t.Errorf("expected a note that TRON transfers were not screened, got %+v", result)
+	}
+}
+
+func TestAMLServiceCheckPaymentFrozenRecipient(t *testing.T) {
+	service := NewAMLService(stubProvider{})
+	service.SetTokenFreezes(stubFreezes{testUSDT + "|" + testReceiver: true})
+
+	result, err := service.CheckPayment(context.Background(), &domain.PaymentRequest{
+		Chain:         domain.ChainEthereum,
+		Address:       testReceiver,
+		TokenContract: testUSDT,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.IsSuspicious || result.Recipient.Details[len(result.Recipient.Details)-1] != "Frozen by the USDT issuer" {
+		t.Errorf("expected the frozen recipient to be flagged, got %+v", result.Recipient)
+	}
+	health := service.ProviderHealth()
+	if health[len(health)-1].Name != "freezes" || health[len(health)-1].Calls != 1 {
+		t.Errorf("expected one freeze lookup in provider health, got %+v", health)
+	}
+}
+
+func containsString(values []string, want string) bool {
+	for _, value := range values {
+		if value == want {
+			return true
+		}
+	}
+	return false
+}
+
+// spareProvider answers with categories that have room to grow, as slices
+// decoded from JSON often do
+type spareProvider struct{ stubProvider }
+
+func (spareProvider) CheckAddress(ctx context.Context, address string) (*domain.CheckResult, error) {
+	return &domain.CheckResult{RiskScore: 0.5, Categories: append(make([]string, 0, 4), "gambling")}, nil
+}
+
+func TestAMLServiceScreenFrozenCachedAddress(t *testing.T) {
+	const usdc = "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"
+	service := NewAMLService(spareProvider{})
+	service.SetCache(NewResultCache(time.Minute))
+	service.SetTokenFreezes(stubFreezes{usdc + "|" + testReceiver: true})
+
+	target := domain.Target{Value: testReceiver, Kind: domain.KindAddress, Chain: domain.ChainEthereum}
+	first, err := service.Screen(context.Background(), target)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, err := service.Screen(context.Background(), target)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := []string{"gambling", domain.CategoryIssuerFrozen}; !slices.Equal(second.Categories, want) {
+		t.Errorf("expected categories %v, got %v", want, second.Categories)
+	}
+	first.Categories[1] = "changed"
+	if second.Categories[1] != domain.CategoryIssuerFrozen {
+		t.Errorf("expected results not to share categories, got %v", second.Categories)
+	}
+	cached, _ := service.Cache().Get("address:" + testReceiver)
+	if got := cached.Categories[:cap(cached.Categories)]; slices.Contains(got, domain.CategoryIssuerFrozen) {
+		t.Errorf("expected the cached result to be left alone, got %v", got)
+	}
+}
+
+func TestAMLServiceScreenFrozenAddress(t *testing.T) {
+	const usdc = "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"
+	service := NewAMLService(stubProvider{})
+	service.SetTokenFreezes(stubFreezes{usdc + "|" + testReceiver: true})
+
+	target := domain.Target{Value: testReceiver, Kind: domain.KindAddress, Chain: domain.ChainEthereum}
+	result, err := service.Screen(context.Background(), target)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.IsSuspicious || !containsString(result.Categories, domain.CategoryIssuerFrozen) {
+		t.Errorf("expected a frozen address, got %+v", result)
+	}
+	if !containsString(result.Details, "Frozen by the USDC issuer") {
+		t.Errorf("expected the issuer in the details, got %v", result.Details)
+	}
+
+	// Only chains with a freeze lookup are checked
+	target = domain.Target{Value: "TLZVYZskxoJt4M4bYHPJg5BzHr73oZRZzc", Kind: domain.KindAddress, Chain: domain.ChainTron}
+	result, err = service.Screen(context.Background(), target)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if containsString(result.Categories, domain.CategoryIssuerFrozen) {
+		t.Errorf("unexpected freeze on an unsupported chain: %+v", result)
+	}
+}
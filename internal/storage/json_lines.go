@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// maxLineSize bounds a single record read back from a JSONLines file
+const maxLineSize = 1 << 20
+
+// JSONLines appends values to a file as one JSON document per line and reads
+// them back one at a time, so the file never has to fit in memory.
+// A JSONLines with an empty path keeps nothing and is safe to use in tests.
+type JSONLines[T any] struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewJSONLines creates a JSONLines stored at path
+func NewJSONLines[T any](path string) *JSONLines[T] {
+	return &JSONLines[T]{path: path}
+}
+
+// Path returns the file location, or an empty string for in-memory files
+func (f *JSONLines[T]) Path() string {
+	return f.path
+}
+
+// Append writes v as a new line at the end of the file
+func (f *JSONLines[T]) Append(v T) error {
+	if f.path == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", f.path, err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(f.path), 0755); err != nil {
+		return fmt.Errorf("failed to create storage directory: %w", err)
+	}
+
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", f.path, err)
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write %s: %w", f.path, err)
+	}
+	return file.Close()
+}
+
+// Scan calls fn for every stored value in the order they were appended.
+// It stops at the first error fn returns. A missing file holds no values.
+func (f *JSONLines[T]) Scan(fn func(T) error) error {
+	if f.path == "" {
+		return nil
+	}
+
+	file, err := os.Open(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", f.path, err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var v T
+		if err := json.Unmarshal(scanner.Bytes(), &v); err != nil {
+			return fmt.Errorf("failed to parse %s line %d: %w", f.path, line, err)
+		}
+		if err := fn(v); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read %s: %w", f.path, err)
+	}
+	return nil
+}